@@ -0,0 +1,432 @@
+package zoombackup
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	// legacyFolderPattern and legacyFilePattern match the
+	// Topic-MM-DD-YYYY[-hash]/<start>-<type>.<ext> layout of this backup.
+	legacyFolderPattern = regexp.MustCompile(`^(.*)-(\d{2}-\d{2}-\d{4})(?:-[0-9a-f]{8})?$`)
+	legacyFilePattern   = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z)-(.+)\.(\w+)$`)
+	// cloudDownloadPattern matches files downloaded from the Zoom web portal,
+	// e.g. GMT20200914-150239_Weekly-Sync_gallery_1920x1080.mp4.
+	cloudDownloadPattern = regexp.MustCompile(`^GMT(\d{8}-\d{6})_(.*)\.(\w+)$`)
+	// localFolderPattern matches the folders of Zoom client local recordings,
+	// e.g. "2020-09-14 15.02.39 Weekly Sync 123456789".
+	localFolderPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}\.\d{2}\.\d{2}) (.*?)(?: \d{9,11})?$`)
+	// datePattern finds a date anywhere in a path as a last resort.
+	datePattern     = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+	resolutionToken = regexp.MustCompile(`_\d+x\d+$`)
+)
+
+// cloudDownloadTypes maps the suffixes Zoom appends to downloaded file names
+// to recording types.
+var cloudDownloadTypes = map[string]string{
+	"gallery":       "gallery_view",
+	"gvo":           "gallery_view",
+	"as":            "active_speaker",
+	"avo":           "active_speaker",
+	"speaker":       "active_speaker",
+	"shared_screen": "shared_screen",
+}
+
+// adoptableExts are the extensions of the files worth adopting; playlists,
+// the client's conversion placeholders and the like are left alone.
+var adoptableExts = map[string]string{
+	"mp4": "video",
+	"m4a": "audio_only",
+	"vtt": "audio_transcript",
+	"txt": "chat_file",
+}
+
+// adoptFile is a hand-uploaded object with what could be inferred from its
+// name.
+type adoptFile struct {
+	attrs *storage.ObjectAttrs
+	// group identifies the meeting the file belongs to.
+	group         string
+	topic         string
+	start         time.Time
+	dateOnly      bool
+	recordingType string
+	ext           string
+}
+
+// adoptGroup is one meeting made of adopted files.
+type adoptGroup struct {
+	key      string
+	files    []adoptFile
+	topic    string
+	start    time.Time
+	dateOnly bool
+}
+
+type adoptOptions struct {
+	prefixes        []string
+	dryRun          bool
+	yes             bool
+	deleteOriginals bool
+	in              *bufio.Reader
+	out             io.Writer
+}
+
+func adoptCommand(args []string) int {
+	fs := flag.NewFlagSet("zoom-backup adopt", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: zoom-backup adopt [flags] PREFIX...")
+		fmt.Fprintln(fs.Output(), "Folds hand-uploaded recordings below the given bucket prefixes into the archive.")
+		fs.PrintDefaults()
+	}
+	job := fs.String("job", "", "job whose bucket, naming and manifest to use; required with several jobs")
+	dryRun := fs.Bool("dry-run", false, "only print what would be adopted")
+	yes := fs.Bool("yes", false, "never prompt; skip recordings whose topic or start time cannot be inferred")
+	deleteOriginals := fs.Bool("delete-originals", false, "delete each original after it was copied to its new name")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	storageClient, jobs, err := setupCLI(ctx, func(*config) error { return nil })
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	cfg, err := selectJob(jobs, *job)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	err = adopt(ctx, storageClient, cfg, adoptOptions{
+		prefixes:        fs.Args(),
+		dryRun:          *dryRun,
+		yes:             *yes,
+		deleteOriginals: *deleteOriginals,
+		in:              bufio.NewReader(os.Stdin),
+		out:             os.Stdout,
+	})
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// adopt copies the recordings found below the prefixes to the names
+// NAMING_TEMPLATE gives them, records them in the manifest and regenerates
+// the index, so a manual archive becomes part of the managed one.
+func adopt(ctx context.Context, storageClient *storage.Client, cfg *config, opts adoptOptions) error {
+	m, err := loadManifest(ctx, storageClient, cfg)
+	if err != nil {
+		return err
+	}
+	managed := map[string]bool{}
+	for _, mtg := range m.Meetings {
+		for _, file := range mtg.Files {
+			managed[file.Object] = true
+		}
+	}
+
+	groups := map[string]*adoptGroup{}
+	var keys []string
+	for _, prefix := range opts.prefixes {
+		it := storageClient.Bucket(cfg.Bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+		for {
+			attrs, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("Bucket(%q).Objects: %v", cfg.Bucket, err)
+			}
+			if managed[attrs.Name] || isInternalObject(cfg, attrs.Name) {
+				continue
+			}
+			file, ok := inferAdoptFile(cfg, attrs)
+			if !ok {
+				log.Println("Not adopting", attrs.Name)
+				continue
+			}
+			g, ok := groups[file.group]
+			if !ok {
+				g = &adoptGroup{key: file.group}
+				groups[file.group] = g
+				keys = append(keys, file.group)
+			}
+			g.files = append(g.files, file)
+		}
+	}
+	sort.Strings(keys)
+
+	run := &backupRun{
+		cfg:           cfg,
+		storageClient: storageClient,
+		archived:      &manifestRecorder{},
+		report:        newRunReport(cfg.JobName),
+	}
+	adopted, skipped := 0, 0
+	for _, key := range keys {
+		g := groups[key]
+		g.resolve()
+		if g.topic == "" || g.start.IsZero() || g.dateOnly {
+			if opts.yes || !g.prompt(cfg, opts) {
+				log.Println("Skipping", g.key, "because its topic or start time is unknown")
+				skipped += len(g.files)
+				continue
+			}
+		}
+		n, err := run.adoptGroup(ctx, g, opts)
+		adopted += n
+		if err != nil {
+			return err
+		}
+	}
+
+	if !opts.dryRun && adopted > 0 {
+		if cfg.ManifestEnabled {
+			if err := updateManifest(ctx, storageClient, cfg, run.archived); err != nil {
+				return fmt.Errorf("Could not update manifest: %v", err)
+			}
+		}
+		if cfg.IndexEnabled {
+			if err := generateURLSListHTML(ctx, storageClient, cfg); err != nil {
+				return fmt.Errorf("Could not generate html file: %v", err)
+			}
+		}
+	}
+	fmt.Fprintf(opts.out, "Adopted %d files, skipped %d\n", adopted, skipped)
+	return nil
+}
+
+// adoptGroup copies the group's files into place and returns how many were
+// adopted.
+func (run *backupRun) adoptGroup(ctx context.Context, g *adoptGroup, opts adoptOptions) (int, error) {
+	cfg := run.cfg
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(cfg.Bucket + "/" + g.key))
+	mtg := meeting{
+		ID:        fmt.Sprintf("adopted-%016x", h.Sum64()),
+		Topic:     g.topic,
+		StartTime: g.start.UTC().Format(time.RFC3339),
+	}
+
+	used := map[string]bool{}
+	adopted := 0
+	for _, file := range g.files {
+		start := file.start
+		if start.IsZero() || file.dateOnly {
+			start = g.start
+		}
+		recording := recordingFile{
+			RecordingStart: start.UTC().Format(time.RFC3339),
+			FileType:       strings.ToUpper(file.ext),
+			RecordingType:  file.recordingType,
+			Status:         "completed",
+			FileSize:       file.attrs.Size,
+		}
+		name, err := getFileSaveName(cfg, mtg, recording)
+		if err != nil {
+			return adopted, err
+		}
+		// Local recordings may hold several files of the same type, which
+		// are numbered like the segments of a Zoom recording.
+		for i := 2; used[name]; i++ {
+			recording.Segment = i
+			if name, err = getFileSaveName(cfg, mtg, recording); err != nil {
+				return adopted, err
+			}
+		}
+		used[name] = true
+		dst := cfg.objectName(name)
+
+		fmt.Fprintf(opts.out, "%s -> %s\n", file.attrs.Name, dst)
+		if opts.dryRun {
+			adopted++
+			continue
+		}
+
+		attrs := file.attrs
+		if dst != file.attrs.Name {
+			existing, err := run.storageClient.Bucket(cfg.Bucket).Object(dst).Attrs(ctx)
+			switch {
+			case err == nil:
+				log.Println("Keeping existing", dst)
+				attrs = existing
+			case err == storage.ErrObjectNotExist:
+				src := run.storageClient.Bucket(cfg.Bucket).Object(file.attrs.Name)
+				copier := run.contentObject(dst).CopierFrom(src)
+				copier.ContentType = cfg.contentType(recording.FileType)
+				copier.StorageClass = cfg.StorageClass
+				copier.DestinationKMSKeyName = cfg.KMSKeyName
+				copier.Metadata = recordingMetadata(cfg, mtg, recording)
+				copier.Metadata["adopted_from"] = file.attrs.Name
+				if cfg.ObjectCustomTime {
+					copier.CustomTime = g.start
+				}
+				if attrs, err = copier.Run(ctx); err != nil {
+					return adopted, fmt.Errorf("failed to copy %s to %s: %w", file.attrs.Name, dst, err)
+				}
+			default:
+				return adopted, fmt.Errorf("failed to check %s: %w", dst, err)
+			}
+		}
+		run.archived.record(mtg, recording, attrs)
+		adopted++
+
+		if opts.deleteOriginals && dst != file.attrs.Name {
+			if err := run.storageClient.Bucket(cfg.Bucket).Object(file.attrs.Name).Delete(ctx); err != nil {
+				return adopted, fmt.Errorf("failed to delete original %s: %w", file.attrs.Name, err)
+			}
+		}
+	}
+	return adopted, nil
+}
+
+// inferAdoptFile works out what it can about a recording from its name. ok
+// is false for objects that are not recordings.
+func inferAdoptFile(cfg *config, attrs *storage.ObjectAttrs) (file adoptFile, ok bool) {
+	dir, base := path.Split(attrs.Name)
+	dir = strings.TrimSuffix(dir, "/")
+	folder := path.Base(dir)
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(base), "."))
+	defaultType, ok := adoptableExts[ext]
+	if !ok {
+		return file, false
+	}
+	file = adoptFile{attrs: attrs, group: dir, recordingType: defaultType, ext: ext}
+
+	if f := legacyFolderPattern.FindStringSubmatch(folder); f != nil {
+		if m := legacyFilePattern.FindStringSubmatch(base); m != nil {
+			if start, err := time.Parse(time.RFC3339, m[1]); err == nil {
+				file.topic = f[1]
+				file.start = start
+				file.recordingType = m[2]
+				return file, true
+			}
+		}
+	}
+
+	if m := cloudDownloadPattern.FindStringSubmatch(base); m != nil {
+		if start, err := time.Parse("20060102-150405", m[1]); err == nil {
+			file.group = dir + "/" + m[1]
+			file.start = start
+			rest := resolutionToken.ReplaceAllString(m[2], "")
+			for suffix, recordingType := range cloudDownloadTypes {
+				if strings.HasSuffix(rest, "_"+suffix) {
+					rest = strings.TrimSuffix(rest, "_"+suffix)
+					file.recordingType = recordingType
+					break
+				}
+			}
+			if rest != "Recording" {
+				file.topic = strings.Replace(rest, "_", " ", -1)
+			}
+			return file, true
+		}
+	}
+
+	if m := localFolderPattern.FindStringSubmatch(folder); m != nil {
+		if start, err := time.ParseInLocation("2006-01-02 15.04.05", m[1], cfg.location); err == nil {
+			file.topic = m[2]
+			file.start = start
+			if strings.HasPrefix(base, "zoom_") || strings.HasPrefix(base, "video") {
+				file.recordingType = "local_recording"
+			}
+			return file, true
+		}
+	}
+
+	if date := datePattern.FindString(attrs.Name); date != "" {
+		if start, err := time.ParseInLocation(ymdFormat, date, cfg.location); err == nil {
+			file.start = start
+			file.dateOnly = true
+		}
+	}
+	file.topic = strings.TrimSpace(strings.Trim(datePattern.ReplaceAllString(folder, ""), " -_"))
+	return file, true
+}
+
+// resolve settles the topic and start of the meeting from its files.
+func (g *adoptGroup) resolve() {
+	sort.Slice(g.files, func(a, b int) bool { return g.files[a].attrs.Name < g.files[b].attrs.Name })
+	for _, file := range g.files {
+		if g.topic == "" {
+			g.topic = file.topic
+		}
+		if file.start.IsZero() {
+			continue
+		}
+		if g.start.IsZero() || (g.dateOnly && !file.dateOnly) || (g.dateOnly == file.dateOnly && file.start.Before(g.start)) {
+			g.start = file.start
+			g.dateOnly = file.dateOnly
+		}
+	}
+}
+
+// prompt asks for the topic and start time of a meeting that could not be
+// inferred, suggesting what was. It returns false when the meeting should be
+// skipped.
+func (g *adoptGroup) prompt(cfg *config, opts adoptOptions) bool {
+	fmt.Fprintf(opts.out, "\n%s (%d files)\n", g.key, len(g.files))
+	for _, file := range g.files {
+		fmt.Fprintf(opts.out, "  %s\n", path.Base(file.attrs.Name))
+	}
+
+	topic := ask(opts, "Topic", g.topic)
+	if topic == "" {
+		return false
+	}
+	suggested := ""
+	if !g.start.IsZero() {
+		suggested = g.start.In(cfg.location).Format("2006-01-02 15:04")
+	}
+	for {
+		answer := ask(opts, "Start time (YYYY-MM-DD HH:MM in "+cfg.location.String()+", empty to skip)", suggested)
+		if answer == "" {
+			return false
+		}
+		start, err := time.ParseInLocation("2006-01-02 15:04", answer, cfg.location)
+		if err != nil {
+			fmt.Fprintln(opts.out, "Could not parse", answer)
+			continue
+		}
+		g.topic, g.start, g.dateOnly = topic, start, false
+		return true
+	}
+}
+
+// ask prompts for a value, returning suggested when the answer is empty.
+func ask(opts adoptOptions, question, suggested string) string {
+	if suggested != "" {
+		fmt.Fprintf(opts.out, "%s [%s]: ", question, suggested)
+	} else {
+		fmt.Fprintf(opts.out, "%s: ", question)
+	}
+	answer, _ := opts.in.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return suggested
+	}
+	return answer
+}
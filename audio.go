@@ -0,0 +1,51 @@
+package zoombackup
+
+import (
+	"context"
+	"io"
+	"log"
+)
+
+// selectAudio keeps only the audio of the meetings matching
+// AUDIO_ONLY_TOPIC_PATTERN: Zoom's M4A audio recording when there is one, or
+// else the audio track extracted from the first MP4. Critical meetings
+// without an M4A keep their video, as their verification requires the files
+// exactly as Zoom serves them.
+func selectAudio(cfg *config, meetings []meeting) []meeting {
+	if cfg.audioOnlyTopic == nil {
+		return meetings
+	}
+	for i, mtg := range meetings {
+		if !cfg.audioOnlyTopic.MatchString(mtg.Topic) {
+			continue
+		}
+		switch {
+		case len(mtg.Audio) > 0:
+			meetings[i].Files = mtg.Audio
+		case len(mtg.Files) > 0 && !cfg.isCritical(mtg):
+			audio := mtg.Files[0]
+			audio.FileType = "M4A"
+			audio.RecordingType = "audio_only"
+			audio.extractAudio = true
+			meetings[i].Files = []recordingFile{audio}
+		default:
+			continue
+		}
+		log.Println("Archiving only the audio of", mtg.ID, mtg.Topic)
+	}
+	return meetings
+}
+
+// extractAudio copies the audio track of the downloaded MP4 into an M4A
+// without re-encoding it.
+func (run *backupRun) extractAudio(ctx context.Context, body io.Reader) (io.ReadCloser, int64, error) {
+	return run.ffmpeg(ctx, body, ".m4a", func(in, out string) []string {
+		return []string{
+			"-nostdin", "-hide_banner", "-loglevel", "error", "-y",
+			"-i", in,
+			"-vn", "-c:a", "copy",
+			"-movflags", "+faststart",
+			out,
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bigquery "google.golang.org/api/bigquery/v2"
+)
+
+// auditBatchSize is the most rows streamed into BigQuery per request.
+const auditBatchSize = 500
+
+// auditLog collects one row per file archived during a run for
+// BIGQUERY_AUDIT_TABLE and streams them into the table when the run ends.
+type auditLog struct {
+	project, dataset, table string
+
+	mu   sync.Mutex
+	rows []*bigquery.TableDataInsertAllRequestRows
+}
+
+// parseAuditTable splits BIGQUERY_AUDIT_TABLE, project.dataset.table.
+func parseAuditTable(table string) (project, dataset, name string, err error) {
+	parts := strings.Split(table, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", errors.New("BIGQUERY_AUDIT_TABLE must look like project.dataset.table")
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// newAuditLog returns nil without BIGQUERY_AUDIT_TABLE.
+func newAuditLog(cfg *config) *auditLog {
+	if cfg.BigQueryAuditTable == "" {
+		return nil
+	}
+	project, dataset, table, err := parseAuditTable(cfg.BigQueryAuditTable)
+	if err != nil {
+		// validate rejected the table already.
+		log.Println(err)
+		return nil
+	}
+	return &auditLog{project: project, dataset: dataset, table: table}
+}
+
+// recordMeeting adds a row for every archived file of the meeting. deletedAt
+// is when the meeting's recordings were deleted from Zoom, zero if they were
+// not.
+func (a *auditLog) recordMeeting(cfg *config, mtg meeting, files []archivedFile, deletedAt time.Time) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, file := range files {
+		row := map[string]bigquery.JsonValue{
+			"job":            cfg.JobName,
+			"meeting_uuid":   mtg.ID,
+			"host_id":        mtg.HostID,
+			"host_email":     mtg.HostEmail,
+			"topic":          mtg.Topic,
+			"source":         mtg.source(),
+			"file_type":      file.recording.FileType,
+			"recording_type": file.recording.RecordingType,
+			"bytes":          strconv.FormatInt(file.attrs.Size, 10),
+			"object":         fmt.Sprintf("gs://%s/%s", file.attrs.Bucket, file.attrs.Name),
+			"crc32c":         fmt.Sprintf("%08x", file.attrs.CRC32C),
+			"archived_at":    file.attrs.Created.UTC().Format(time.RFC3339Nano),
+		}
+		if start, err := time.Parse(time.RFC3339, mtg.StartTime); err == nil {
+			row["start_time"] = start.UTC().Format(time.RFC3339)
+		}
+		if len(file.attrs.MD5) > 0 {
+			row["md5"] = hex.EncodeToString(file.attrs.MD5)
+		}
+		if file.sha256 != nil {
+			row["sha256"] = hex.EncodeToString(file.sha256)
+		}
+		if file.checksum != nil {
+			row["checksum_algorithm"] = cfg.ChecksumAlgorithm
+			row["checksum"] = hex.EncodeToString(file.checksum)
+		}
+		if !deletedAt.IsZero() {
+			row["deleted_at"] = deletedAt.UTC().Format(time.RFC3339Nano)
+		}
+		a.rows = append(a.rows, &bigquery.TableDataInsertAllRequestRows{
+			// Retried requests and files archived again by a later run
+			// with an unchanged object are recorded only once.
+			InsertId: fmt.Sprintf("%s#%d#%t", file.attrs.Name, file.attrs.Generation, !deletedAt.IsZero()),
+			Json:     row,
+		})
+	}
+}
+
+// flush streams the collected rows into the table.
+func (a *auditLog) flush(ctx context.Context) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	rows := a.rows
+	a.rows = nil
+	a.mu.Unlock()
+	if len(rows) == 0 {
+		return nil
+	}
+
+	service, err := bigquery.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create BigQuery client: %w", err)
+	}
+	for len(rows) > 0 {
+		batch := rows
+		if len(batch) > auditBatchSize {
+			batch = batch[:auditBatchSize]
+		}
+		rows = rows[len(batch):]
+		resp, err := service.Tabledata.InsertAll(a.project, a.dataset, a.table, &bigquery.TableDataInsertAllRequest{Rows: batch}).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to insert audit rows: %w", err)
+		}
+		if len(resp.InsertErrors) > 0 {
+			first := resp.InsertErrors[0]
+			msg := "unknown error"
+			if len(first.Errors) > 0 {
+				msg = first.Errors[0].Message
+			}
+			return fmt.Errorf("BigQuery rejected %d audit rows, e.g. row %d: %s", len(resp.InsertErrors), first.Index, msg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,2191 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        (unknown)
+// source: backup.proto
+
+// Package zoombackup.v1 lets backup platforms drive the Zoom backup
+// pipeline: start runs and follow their progress, and look up and re-share
+// what is in the archive.
+
+package backuppb
+
+import (
+	context "context"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type RunStatus_State int32
+
+const (
+	RunStatus_STATE_UNSPECIFIED RunStatus_State = 0
+	RunStatus_RUNNING           RunStatus_State = 1
+	// SUCCEEDED runs finished without any errors.
+	RunStatus_SUCCEEDED RunStatus_State = 2
+	RunStatus_FAILED    RunStatus_State = 3
+)
+
+// Enum value maps for RunStatus_State.
+var (
+	RunStatus_State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "RUNNING",
+		2: "SUCCEEDED",
+		3: "FAILED",
+	}
+	RunStatus_State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"RUNNING":           1,
+		"SUCCEEDED":         2,
+		"FAILED":            3,
+	}
+)
+
+func (x RunStatus_State) Enum() *RunStatus_State {
+	p := new(RunStatus_State)
+	*p = x
+	return p
+}
+
+func (x RunStatus_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RunStatus_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_backup_proto_enumTypes[0].Descriptor()
+}
+
+func (RunStatus_State) Type() protoreflect.EnumType {
+	return &file_backup_proto_enumTypes[0]
+}
+
+func (x RunStatus_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RunStatus_State.Descriptor instead.
+func (RunStatus_State) EnumDescriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type StartRunRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Jobs are the names of the jobs to run, all of them when empty.
+	Jobs []string `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+}
+
+func (x *StartRunRequest) Reset() {
+	*x = StartRunRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRunRequest) ProtoMessage() {}
+
+func (x *StartRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRunRequest.ProtoReflect.Descriptor instead.
+func (*StartRunRequest) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartRunRequest) GetJobs() []string {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+// RunProgress is either a pipeline event or, as the last message of the
+// stream, the final status of the run.
+type RunProgress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RunId string `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	// Types that are assignable to Update:
+	//	*RunProgress_Event
+	//	*RunProgress_Status
+	Update isRunProgress_Update `protobuf_oneof:"update"`
+}
+
+func (x *RunProgress) Reset() {
+	*x = RunProgress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RunProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunProgress) ProtoMessage() {}
+
+func (x *RunProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunProgress.ProtoReflect.Descriptor instead.
+func (*RunProgress) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RunProgress) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (m *RunProgress) GetUpdate() isRunProgress_Update {
+	if m != nil {
+		return m.Update
+	}
+	return nil
+}
+
+func (x *RunProgress) GetEvent() *Event {
+	if x, ok := x.GetUpdate().(*RunProgress_Event); ok {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *RunProgress) GetStatus() *RunStatus {
+	if x, ok := x.GetUpdate().(*RunProgress_Status); ok {
+		return x.Status
+	}
+	return nil
+}
+
+type isRunProgress_Update interface {
+	isRunProgress_Update()
+}
+
+type RunProgress_Event struct {
+	Event *Event `protobuf:"bytes,2,opt,name=event,proto3,oneof"`
+}
+
+type RunProgress_Status struct {
+	Status *RunStatus `protobuf:"bytes,3,opt,name=status,proto3,oneof"`
+}
+
+func (*RunProgress_Event) isRunProgress_Update() {}
+
+func (*RunProgress_Status) isRunProgress_Update() {}
+
+// Event mirrors one line of the EVENTS_OUT stream.
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Time *timestamp.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Job  string               `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	// Action is discovered, downloaded, uploaded, verified, deleted or failed.
+	Action      string `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	MeetingUuid string `protobuf:"bytes,4,opt,name=meeting_uuid,json=meetingUuid,proto3" json:"meeting_uuid,omitempty"`
+	Topic       string `protobuf:"bytes,5,opt,name=topic,proto3" json:"topic,omitempty"`
+	File        string `protobuf:"bytes,6,opt,name=file,proto3" json:"file,omitempty"`
+	Object      string `protobuf:"bytes,7,opt,name=object,proto3" json:"object,omitempty"`
+	Bytes       int64  `protobuf:"varint,8,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Error       string `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Event) GetTime() *timestamp.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetJob() string {
+	if x != nil {
+		return x.Job
+	}
+	return ""
+}
+
+func (x *Event) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *Event) GetMeetingUuid() string {
+	if x != nil {
+		return x.MeetingUuid
+	}
+	return ""
+}
+
+func (x *Event) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *Event) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Event) GetObject() string {
+	if x != nil {
+		return x.Object
+	}
+	return ""
+}
+
+func (x *Event) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GetRunStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RunId string `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+}
+
+func (x *GetRunStatusRequest) Reset() {
+	*x = GetRunStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRunStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRunStatusRequest) ProtoMessage() {}
+
+func (x *GetRunStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRunStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetRunStatusRequest) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetRunStatusRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type RunStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RunId      string               `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	State      RunStatus_State      `protobuf:"varint,2,opt,name=state,proto3,enum=zoombackup.v1.RunStatus_State" json:"state,omitempty"`
+	StartedAt  *timestamp.Timestamp `protobuf:"bytes,3,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt *timestamp.Timestamp `protobuf:"bytes,4,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	// Reports holds one report per finished job.
+	Reports []*JobReport `protobuf:"bytes,5,rep,name=reports,proto3" json:"reports,omitempty"`
+}
+
+func (x *RunStatus) Reset() {
+	*x = RunStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RunStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunStatus) ProtoMessage() {}
+
+func (x *RunStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunStatus.ProtoReflect.Descriptor instead.
+func (*RunStatus) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RunStatus) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *RunStatus) GetState() RunStatus_State {
+	if x != nil {
+		return x.State
+	}
+	return RunStatus_STATE_UNSPECIFIED
+}
+
+func (x *RunStatus) GetStartedAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *RunStatus) GetFinishedAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *RunStatus) GetReports() []*JobReport {
+	if x != nil {
+		return x.Reports
+	}
+	return nil
+}
+
+type JobReport struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Job             string               `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	StartedAt       *timestamp.Timestamp `protobuf:"bytes,2,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt      *timestamp.Timestamp `protobuf:"bytes,3,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Paused          bool                 `protobuf:"varint,4,opt,name=paused,proto3" json:"paused,omitempty"`
+	Canary          string               `protobuf:"bytes,5,opt,name=canary,proto3" json:"canary,omitempty"`
+	Users           int32                `protobuf:"varint,6,opt,name=users,proto3" json:"users,omitempty"`
+	Meetings        int32                `protobuf:"varint,7,opt,name=meetings,proto3" json:"meetings,omitempty"`
+	FilesArchived   int32                `protobuf:"varint,8,opt,name=files_archived,json=filesArchived,proto3" json:"files_archived,omitempty"`
+	FilesFailed     int32                `protobuf:"varint,9,opt,name=files_failed,json=filesFailed,proto3" json:"files_failed,omitempty"`
+	BytesArchived   int64                `protobuf:"varint,10,opt,name=bytes_archived,json=bytesArchived,proto3" json:"bytes_archived,omitempty"`
+	MeetingsDeleted int32                `protobuf:"varint,11,opt,name=meetings_deleted,json=meetingsDeleted,proto3" json:"meetings_deleted,omitempty"`
+	Errors          []string             `protobuf:"bytes,12,rep,name=errors,proto3" json:"errors,omitempty"`
+	// SettingsDrift counts recording settings that differed from
+	// RECORDING_SETTINGS.
+	SettingsDrift int32 `protobuf:"varint,13,opt,name=settings_drift,json=settingsDrift,proto3" json:"settings_drift,omitempty"`
+	OutsideWindow bool  `protobuf:"varint,14,opt,name=outside_window,json=outsideWindow,proto3" json:"outside_window,omitempty"`
+	// MeetingsDeferred were left for the next run by the end of
+	// ALLOWED_HOURS.
+	MeetingsDeferred int32 `protobuf:"varint,15,opt,name=meetings_deferred,json=meetingsDeferred,proto3" json:"meetings_deferred,omitempty"`
+	// ZoomApiDeprecations lists the Zoom API endpoints used during the run
+	// that Zoom announced to be deprecated or sunset.
+	ZoomApiDeprecations []*ApiDeprecation `protobuf:"bytes,16,rep,name=zoom_api_deprecations,json=zoomApiDeprecations,proto3" json:"zoom_api_deprecations,omitempty"`
+	// Cancelled runs were stopped before they finished, e.g. by a shutdown.
+	Cancelled bool `protobuf:"varint,17,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	// Timelines has the steps of every meeting in the order they were
+	// discovered.
+	Timelines []*MeetingTimeline `protobuf:"bytes,18,rep,name=timelines,proto3" json:"timelines,omitempty"`
+	// MeetingsPostponed were left for later runs by MAX_MEETINGS_PER_RUN.
+	MeetingsPostponed int32 `protobuf:"varint,19,opt,name=meetings_postponed,json=meetingsPostponed,proto3" json:"meetings_postponed,omitempty"`
+	// IndexError tells why the index could not be generated or was degraded.
+	IndexError string `protobuf:"bytes,20,opt,name=index_error,json=indexError,proto3" json:"index_error,omitempty"`
+	// ObjectsPruned were deleted or moved to ARCHIVE by retention.
+	ObjectsPruned int32 `protobuf:"varint,21,opt,name=objects_pruned,json=objectsPruned,proto3" json:"objects_pruned,omitempty"`
+	// Policy names the weekday policies the run applied.
+	Policy string `protobuf:"bytes,22,opt,name=policy,proto3" json:"policy,omitempty"`
+	// VerifyProblems counts the problems VERIFY_DAYS found in the archive.
+	VerifyProblems int32 `protobuf:"varint,23,opt,name=verify_problems,json=verifyProblems,proto3" json:"verify_problems,omitempty"`
+	// FilesSkipped were left on Zoom for later runs.
+	FilesSkipped int32 `protobuf:"varint,24,opt,name=files_skipped,json=filesSkipped,proto3" json:"files_skipped,omitempty"`
+	// Fatal is the error that stopped the run early.
+	Fatal string `protobuf:"bytes,25,opt,name=fatal,proto3" json:"fatal,omitempty"`
+}
+
+func (x *JobReport) Reset() {
+	*x = JobReport{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobReport) ProtoMessage() {}
+
+func (x *JobReport) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobReport.ProtoReflect.Descriptor instead.
+func (*JobReport) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *JobReport) GetJob() string {
+	if x != nil {
+		return x.Job
+	}
+	return ""
+}
+
+func (x *JobReport) GetStartedAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *JobReport) GetFinishedAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *JobReport) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *JobReport) GetCanary() string {
+	if x != nil {
+		return x.Canary
+	}
+	return ""
+}
+
+func (x *JobReport) GetUsers() int32 {
+	if x != nil {
+		return x.Users
+	}
+	return 0
+}
+
+func (x *JobReport) GetMeetings() int32 {
+	if x != nil {
+		return x.Meetings
+	}
+	return 0
+}
+
+func (x *JobReport) GetFilesArchived() int32 {
+	if x != nil {
+		return x.FilesArchived
+	}
+	return 0
+}
+
+func (x *JobReport) GetFilesFailed() int32 {
+	if x != nil {
+		return x.FilesFailed
+	}
+	return 0
+}
+
+func (x *JobReport) GetBytesArchived() int64 {
+	if x != nil {
+		return x.BytesArchived
+	}
+	return 0
+}
+
+func (x *JobReport) GetMeetingsDeleted() int32 {
+	if x != nil {
+		return x.MeetingsDeleted
+	}
+	return 0
+}
+
+func (x *JobReport) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *JobReport) GetSettingsDrift() int32 {
+	if x != nil {
+		return x.SettingsDrift
+	}
+	return 0
+}
+
+func (x *JobReport) GetOutsideWindow() bool {
+	if x != nil {
+		return x.OutsideWindow
+	}
+	return false
+}
+
+func (x *JobReport) GetMeetingsDeferred() int32 {
+	if x != nil {
+		return x.MeetingsDeferred
+	}
+	return 0
+}
+
+func (x *JobReport) GetZoomApiDeprecations() []*ApiDeprecation {
+	if x != nil {
+		return x.ZoomApiDeprecations
+	}
+	return nil
+}
+
+func (x *JobReport) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+func (x *JobReport) GetTimelines() []*MeetingTimeline {
+	if x != nil {
+		return x.Timelines
+	}
+	return nil
+}
+
+func (x *JobReport) GetMeetingsPostponed() int32 {
+	if x != nil {
+		return x.MeetingsPostponed
+	}
+	return 0
+}
+
+func (x *JobReport) GetIndexError() string {
+	if x != nil {
+		return x.IndexError
+	}
+	return ""
+}
+
+func (x *JobReport) GetObjectsPruned() int32 {
+	if x != nil {
+		return x.ObjectsPruned
+	}
+	return 0
+}
+
+func (x *JobReport) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *JobReport) GetVerifyProblems() int32 {
+	if x != nil {
+		return x.VerifyProblems
+	}
+	return 0
+}
+
+func (x *JobReport) GetFilesSkipped() int32 {
+	if x != nil {
+		return x.FilesSkipped
+	}
+	return 0
+}
+
+func (x *JobReport) GetFatal() string {
+	if x != nil {
+		return x.Fatal
+	}
+	return ""
+}
+
+// MeetingTimeline records when each step of a meeting happened during a run.
+// Steps that did not happen are unset.
+type MeetingTimeline struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MeetingUuid string               `protobuf:"bytes,1,opt,name=meeting_uuid,json=meetingUuid,proto3" json:"meeting_uuid,omitempty"`
+	Topic       string               `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Discovered  *timestamp.Timestamp `protobuf:"bytes,3,opt,name=discovered,proto3" json:"discovered,omitempty"`
+	Files       []*FileTimeline      `protobuf:"bytes,4,rep,name=files,proto3" json:"files,omitempty"`
+	Deleted     *timestamp.Timestamp `protobuf:"bytes,5,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Error       string               `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *MeetingTimeline) Reset() {
+	*x = MeetingTimeline{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MeetingTimeline) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MeetingTimeline) ProtoMessage() {}
+
+func (x *MeetingTimeline) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MeetingTimeline.ProtoReflect.Descriptor instead.
+func (*MeetingTimeline) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MeetingTimeline) GetMeetingUuid() string {
+	if x != nil {
+		return x.MeetingUuid
+	}
+	return ""
+}
+
+func (x *MeetingTimeline) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *MeetingTimeline) GetDiscovered() *timestamp.Timestamp {
+	if x != nil {
+		return x.Discovered
+	}
+	return nil
+}
+
+func (x *MeetingTimeline) GetFiles() []*FileTimeline {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *MeetingTimeline) GetDeleted() *timestamp.Timestamp {
+	if x != nil {
+		return x.Deleted
+	}
+	return nil
+}
+
+func (x *MeetingTimeline) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type FileTimeline struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	File            string               `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	DownloadStarted *timestamp.Timestamp `protobuf:"bytes,2,opt,name=download_started,json=downloadStarted,proto3" json:"download_started,omitempty"`
+	Downloaded      *timestamp.Timestamp `protobuf:"bytes,3,opt,name=downloaded,proto3" json:"downloaded,omitempty"`
+	Uploaded        *timestamp.Timestamp `protobuf:"bytes,4,opt,name=uploaded,proto3" json:"uploaded,omitempty"`
+	Verified        *timestamp.Timestamp `protobuf:"bytes,5,opt,name=verified,proto3" json:"verified,omitempty"`
+	Error           string               `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *FileTimeline) Reset() {
+	*x = FileTimeline{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FileTimeline) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileTimeline) ProtoMessage() {}
+
+func (x *FileTimeline) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileTimeline.ProtoReflect.Descriptor instead.
+func (*FileTimeline) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FileTimeline) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *FileTimeline) GetDownloadStarted() *timestamp.Timestamp {
+	if x != nil {
+		return x.DownloadStarted
+	}
+	return nil
+}
+
+func (x *FileTimeline) GetDownloaded() *timestamp.Timestamp {
+	if x != nil {
+		return x.Downloaded
+	}
+	return nil
+}
+
+func (x *FileTimeline) GetUploaded() *timestamp.Timestamp {
+	if x != nil {
+		return x.Uploaded
+	}
+	return nil
+}
+
+func (x *FileTimeline) GetVerified() *timestamp.Timestamp {
+	if x != nil {
+		return x.Verified
+	}
+	return nil
+}
+
+func (x *FileTimeline) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ApiDeprecation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Endpoint is the method and path with IDs replaced, e.g.
+	// GET /v2/users/{id}/recordings.
+	Endpoint string `protobuf:"bytes,1,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// Deprecation is when the endpoint was deprecated in RFC 3339, or "true".
+	Deprecation string `protobuf:"bytes,2,opt,name=deprecation,proto3" json:"deprecation,omitempty"`
+	// Sunset is when the endpoint will stop working in RFC 3339.
+	Sunset    string               `protobuf:"bytes,3,opt,name=sunset,proto3" json:"sunset,omitempty"`
+	Link      string               `protobuf:"bytes,4,opt,name=link,proto3" json:"link,omitempty"`
+	FirstSeen *timestamp.Timestamp `protobuf:"bytes,5,opt,name=first_seen,json=firstSeen,proto3" json:"first_seen,omitempty"`
+	LastSeen  *timestamp.Timestamp `protobuf:"bytes,6,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Requests  int32                `protobuf:"varint,7,opt,name=requests,proto3" json:"requests,omitempty"`
+}
+
+func (x *ApiDeprecation) Reset() {
+	*x = ApiDeprecation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ApiDeprecation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiDeprecation) ProtoMessage() {}
+
+func (x *ApiDeprecation) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiDeprecation.ProtoReflect.Descriptor instead.
+func (*ApiDeprecation) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ApiDeprecation) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *ApiDeprecation) GetDeprecation() string {
+	if x != nil {
+		return x.Deprecation
+	}
+	return ""
+}
+
+func (x *ApiDeprecation) GetSunset() string {
+	if x != nil {
+		return x.Sunset
+	}
+	return ""
+}
+
+func (x *ApiDeprecation) GetLink() string {
+	if x != nil {
+		return x.Link
+	}
+	return ""
+}
+
+func (x *ApiDeprecation) GetFirstSeen() *timestamp.Timestamp {
+	if x != nil {
+		return x.FirstSeen
+	}
+	return nil
+}
+
+func (x *ApiDeprecation) GetLastSeen() *timestamp.Timestamp {
+	if x != nil {
+		return x.LastSeen
+	}
+	return nil
+}
+
+func (x *ApiDeprecation) GetRequests() int32 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+type ListArchivedMeetingsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Job may be left empty when only one job is configured.
+	Job string `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	// From and to limit the meetings to those that started on or between the
+	// given dates in TIMEZONE, formatted as YYYY-MM-DD. Both are optional.
+	From string `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To   string `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (x *ListArchivedMeetingsRequest) Reset() {
+	*x = ListArchivedMeetingsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListArchivedMeetingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArchivedMeetingsRequest) ProtoMessage() {}
+
+func (x *ListArchivedMeetingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArchivedMeetingsRequest.ProtoReflect.Descriptor instead.
+func (*ListArchivedMeetingsRequest) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListArchivedMeetingsRequest) GetJob() string {
+	if x != nil {
+		return x.Job
+	}
+	return ""
+}
+
+func (x *ListArchivedMeetingsRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ListArchivedMeetingsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type ListArchivedMeetingsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Meetings []*ArchivedMeeting `protobuf:"bytes,1,rep,name=meetings,proto3" json:"meetings,omitempty"`
+}
+
+func (x *ListArchivedMeetingsResponse) Reset() {
+	*x = ListArchivedMeetingsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListArchivedMeetingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArchivedMeetingsResponse) ProtoMessage() {}
+
+func (x *ListArchivedMeetingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArchivedMeetingsResponse.ProtoReflect.Descriptor instead.
+func (*ListArchivedMeetingsResponse) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListArchivedMeetingsResponse) GetMeetings() []*ArchivedMeeting {
+	if x != nil {
+		return x.Meetings
+	}
+	return nil
+}
+
+type ArchivedMeeting struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uuid      string          `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Topic     string          `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	StartTime string          `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	Duration  int32           `protobuf:"varint,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	Folder    string          `protobuf:"bytes,5,opt,name=folder,proto3" json:"folder,omitempty"`
+	Files     []*ArchivedFile `protobuf:"bytes,6,rep,name=files,proto3" json:"files,omitempty"`
+}
+
+func (x *ArchivedMeeting) Reset() {
+	*x = ArchivedMeeting{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ArchivedMeeting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchivedMeeting) ProtoMessage() {}
+
+func (x *ArchivedMeeting) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchivedMeeting.ProtoReflect.Descriptor instead.
+func (*ArchivedMeeting) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ArchivedMeeting) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *ArchivedMeeting) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ArchivedMeeting) GetStartTime() string {
+	if x != nil {
+		return x.StartTime
+	}
+	return ""
+}
+
+func (x *ArchivedMeeting) GetDuration() int32 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *ArchivedMeeting) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *ArchivedMeeting) GetFiles() []*ArchivedFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+type ArchivedFile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Object         string               `protobuf:"bytes,1,opt,name=object,proto3" json:"object,omitempty"`
+	RecordingStart string               `protobuf:"bytes,2,opt,name=recording_start,json=recordingStart,proto3" json:"recording_start,omitempty"`
+	RecordingType  string               `protobuf:"bytes,3,opt,name=recording_type,json=recordingType,proto3" json:"recording_type,omitempty"`
+	FileType       string               `protobuf:"bytes,4,opt,name=file_type,json=fileType,proto3" json:"file_type,omitempty"`
+	Size           int64                `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	Md5            string               `protobuf:"bytes,6,opt,name=md5,proto3" json:"md5,omitempty"`
+	ArchivedAt     *timestamp.Timestamp `protobuf:"bytes,7,opt,name=archived_at,json=archivedAt,proto3" json:"archived_at,omitempty"`
+	// URL is only set by RestoreMeeting.
+	Url string `protobuf:"bytes,8,opt,name=url,proto3" json:"url,omitempty"`
+}
+
+func (x *ArchivedFile) Reset() {
+	*x = ArchivedFile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ArchivedFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchivedFile) ProtoMessage() {}
+
+func (x *ArchivedFile) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchivedFile.ProtoReflect.Descriptor instead.
+func (*ArchivedFile) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ArchivedFile) GetObject() string {
+	if x != nil {
+		return x.Object
+	}
+	return ""
+}
+
+func (x *ArchivedFile) GetRecordingStart() string {
+	if x != nil {
+		return x.RecordingStart
+	}
+	return ""
+}
+
+func (x *ArchivedFile) GetRecordingType() string {
+	if x != nil {
+		return x.RecordingType
+	}
+	return ""
+}
+
+func (x *ArchivedFile) GetFileType() string {
+	if x != nil {
+		return x.FileType
+	}
+	return ""
+}
+
+func (x *ArchivedFile) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ArchivedFile) GetMd5() string {
+	if x != nil {
+		return x.Md5
+	}
+	return ""
+}
+
+func (x *ArchivedFile) GetArchivedAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.ArchivedAt
+	}
+	return nil
+}
+
+func (x *ArchivedFile) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type RestoreMeetingRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Job         string `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	MeetingUuid string `protobuf:"bytes,2,opt,name=meeting_uuid,json=meetingUuid,proto3" json:"meeting_uuid,omitempty"`
+	// LinkExpirySeconds defaults to FEED_LINK_EXPIRY and may not exceed a
+	// week.
+	LinkExpirySeconds int64 `protobuf:"varint,3,opt,name=link_expiry_seconds,json=linkExpirySeconds,proto3" json:"link_expiry_seconds,omitempty"`
+}
+
+func (x *RestoreMeetingRequest) Reset() {
+	*x = RestoreMeetingRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RestoreMeetingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreMeetingRequest) ProtoMessage() {}
+
+func (x *RestoreMeetingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreMeetingRequest.ProtoReflect.Descriptor instead.
+func (*RestoreMeetingRequest) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RestoreMeetingRequest) GetJob() string {
+	if x != nil {
+		return x.Job
+	}
+	return ""
+}
+
+func (x *RestoreMeetingRequest) GetMeetingUuid() string {
+	if x != nil {
+		return x.MeetingUuid
+	}
+	return ""
+}
+
+func (x *RestoreMeetingRequest) GetLinkExpirySeconds() int64 {
+	if x != nil {
+		return x.LinkExpirySeconds
+	}
+	return 0
+}
+
+type RestoreMeetingResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Meeting       *ArchivedMeeting     `protobuf:"bytes,1,opt,name=meeting,proto3" json:"meeting,omitempty"`
+	LinksExpireAt *timestamp.Timestamp `protobuf:"bytes,2,opt,name=links_expire_at,json=linksExpireAt,proto3" json:"links_expire_at,omitempty"`
+}
+
+func (x *RestoreMeetingResponse) Reset() {
+	*x = RestoreMeetingResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_backup_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RestoreMeetingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreMeetingResponse) ProtoMessage() {}
+
+func (x *RestoreMeetingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_backup_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreMeetingResponse.ProtoReflect.Descriptor instead.
+func (*RestoreMeetingResponse) Descriptor() ([]byte, []int) {
+	return file_backup_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RestoreMeetingResponse) GetMeeting() *ArchivedMeeting {
+	if x != nil {
+		return x.Meeting
+	}
+	return nil
+}
+
+func (x *RestoreMeetingResponse) GetLinksExpireAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.LinksExpireAt
+	}
+	return nil
+}
+
+var File_backup_proto protoreflect.FileDescriptor
+
+var file_backup_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d,
+	0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x25,
+	0x0a, 0x0f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x75, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x6a, 0x6f, 0x62, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x04, 0x6a, 0x6f, 0x62, 0x73, 0x22, 0x90, 0x01, 0x0a, 0x0b, 0x52, 0x75, 0x6e, 0x50, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x75, 0x6e, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64, 0x12, 0x2c, 0x0a, 0x05,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x7a, 0x6f,
+	0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x48, 0x00, 0x52, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x32, 0x0a, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x7a, 0x6f, 0x6f,
+	0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x75, 0x6e, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x42, 0x08,
+	0x0a, 0x06, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x22, 0xf2, 0x01, 0x0a, 0x05, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69,
+	0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6a, 0x6f, 0x62, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6a, 0x6f, 0x62, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0c,
+	0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x75, 0x75, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x55, 0x75, 0x69, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x74, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x62, 0x6a,
+	0x65, 0x63, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x62, 0x6a, 0x65, 0x63,
+	0x74, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x2c, 0x0a,
+	0x13, 0x47, 0x65, 0x74, 0x52, 0x75, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x75, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64, 0x22, 0xcc, 0x02, 0x0a, 0x09,
+	0x52, 0x75, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x75, 0x6e,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64,
+	0x12, 0x34, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x1e, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e,
+	0x52, 0x75, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52,
+	0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x39, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41,
+	0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x5f, 0x61, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x0a, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x41, 0x74, 0x12, 0x32,
+	0x0a, 0x07, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e,
+	0x4a, 0x6f, 0x62, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6f, 0x72,
+	0x74, 0x73, 0x22, 0x46, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x15, 0x0a, 0x11, 0x53,
+	0x54, 0x41, 0x54, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x55, 0x4e, 0x4e, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12,
+	0x0d, 0x0a, 0x09, 0x53, 0x55, 0x43, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x02, 0x12, 0x0a,
+	0x0a, 0x06, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x03, 0x22, 0xc8, 0x07, 0x0a, 0x09, 0x4a,
+	0x6f, 0x62, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6a, 0x6f, 0x62, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6a, 0x6f, 0x62, 0x12, 0x39, 0x0a, 0x0a, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64,
+	0x41, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x61,
+	0x6e, 0x61, 0x72, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x61, 0x6e, 0x61,
+	0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6d, 0x65, 0x65, 0x74,
+	0x69, 0x6e, 0x67, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6d, 0x65, 0x65, 0x74,
+	0x69, 0x6e, 0x67, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x5f, 0x61, 0x72,
+	0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x66, 0x69,
+	0x6c, 0x65, 0x73, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x66,
+	0x69, 0x6c, 0x65, 0x73, 0x5f, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0b, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x46, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x12, 0x25,
+	0x0a, 0x0e, 0x62, 0x79, 0x74, 0x65, 0x73, 0x5f, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x62, 0x79, 0x74, 0x65, 0x73, 0x41, 0x72, 0x63,
+	0x68, 0x69, 0x76, 0x65, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67,
+	0x73, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0f, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x65, 0x74, 0x74,
+	0x69, 0x6e, 0x67, 0x73, 0x5f, 0x64, 0x72, 0x69, 0x66, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0d, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x44, 0x72, 0x69, 0x66, 0x74, 0x12,
+	0x25, 0x0a, 0x0e, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f,
+	0x77, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65,
+	0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x2b, 0x0a, 0x11, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e,
+	0x67, 0x73, 0x5f, 0x64, 0x65, 0x66, 0x65, 0x72, 0x72, 0x65, 0x64, 0x18, 0x0f, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x10, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x44, 0x65, 0x66, 0x65, 0x72,
+	0x72, 0x65, 0x64, 0x12, 0x51, 0x0a, 0x15, 0x7a, 0x6f, 0x6f, 0x6d, 0x5f, 0x61, 0x70, 0x69, 0x5f,
+	0x64, 0x65, 0x70, 0x72, 0x65, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x10, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x70, 0x69, 0x44, 0x65, 0x70, 0x72, 0x65, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x13, 0x7a, 0x6f, 0x6f, 0x6d, 0x41, 0x70, 0x69, 0x44, 0x65, 0x70, 0x72, 0x65, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c,
+	0x6c, 0x65, 0x64, 0x18, 0x11, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x63, 0x61, 0x6e, 0x63, 0x65,
+	0x6c, 0x6c, 0x65, 0x64, 0x12, 0x3c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6c, 0x69, 0x6e, 0x65,
+	0x73, 0x18, 0x12, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61,
+	0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x54,
+	0x69, 0x6d, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6c, 0x69, 0x6e,
+	0x65, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x5f, 0x70,
+	0x6f, 0x73, 0x74, 0x70, 0x6f, 0x6e, 0x65, 0x64, 0x18, 0x13, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11,
+	0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x50, 0x6f, 0x73, 0x74, 0x70, 0x6f, 0x6e, 0x65,
+	0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x5f, 0x70, 0x72,
+	0x75, 0x6e, 0x65, 0x64, 0x18, 0x15, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x6f, 0x62, 0x6a, 0x65,
+	0x63, 0x74, 0x73, 0x50, 0x72, 0x75, 0x6e, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x6c,
+	0x69, 0x63, 0x79, 0x18, 0x16, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x12, 0x27, 0x0a, 0x0f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x79, 0x5f, 0x70, 0x72, 0x6f, 0x62,
+	0x6c, 0x65, 0x6d, 0x73, 0x18, 0x17, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x76, 0x65, 0x72, 0x69,
+	0x66, 0x79, 0x50, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x66, 0x69,
+	0x6c, 0x65, 0x73, 0x5f, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x18, 0x18, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0c, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x53, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x66, 0x61, 0x74, 0x61, 0x6c, 0x18, 0x19, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x66, 0x61, 0x74, 0x61, 0x6c, 0x22, 0x85, 0x02, 0x0a, 0x0f, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e,
+	0x67, 0x54, 0x69, 0x6d, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x65,
+	0x74, 0x69, 0x6e, 0x67, 0x5f, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x55, 0x75, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05,
+	0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x70,
+	0x69, 0x63, 0x12, 0x3a, 0x0a, 0x0a, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x65, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x0a, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x65, 0x64, 0x12, 0x31,
+	0x0a, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e,
+	0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69,
+	0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65,
+	0x73, 0x12, 0x34, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0xab, 0x02,
+	0x0a, 0x0c, 0x46, 0x69, 0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x69,
+	0x6c, 0x65, 0x12, 0x45, 0x0a, 0x10, 0x64, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0f, 0x64, 0x6f, 0x77, 0x6e, 0x6c, 0x6f,
+	0x61, 0x64, 0x53, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x12, 0x3a, 0x0a, 0x0a, 0x64, 0x6f, 0x77,
+	0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x64, 0x6f, 0x77, 0x6e, 0x6c,
+	0x6f, 0x61, 0x64, 0x65, 0x64, 0x12, 0x36, 0x0a, 0x08, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65,
+	0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x08, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x65, 0x64, 0x12, 0x36, 0x0a,
+	0x08, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x76, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x8a, 0x02, 0x0a, 0x0e,
+	0x41, 0x70, 0x69, 0x44, 0x65, 0x70, 0x72, 0x65, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a,
+	0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65,
+	0x70, 0x72, 0x65, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x64, 0x65, 0x70, 0x72, 0x65, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x75, 0x6e, 0x73, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x75,
+	0x6e, 0x73, 0x65, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x6b, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x6b, 0x12, 0x39, 0x0a, 0x0a, 0x66, 0x69, 0x72, 0x73,
+	0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x66, 0x69, 0x72, 0x73, 0x74, 0x53,
+	0x65, 0x65, 0x6e, 0x12, 0x37, 0x0a, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x12, 0x1a, 0x0a, 0x08,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x22, 0x53, 0x0a, 0x1b, 0x4c, 0x69, 0x73, 0x74,
+	0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6a, 0x6f, 0x62, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6a, 0x6f, 0x62, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f,
+	0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a,
+	0x02, 0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x22, 0x5a, 0x0a,
+	0x1c, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x4d, 0x65, 0x65,
+	0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3a, 0x0a,
+	0x08, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1e, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e,
+	0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x52,
+	0x08, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x22, 0xc1, 0x01, 0x0a, 0x0f, 0x41, 0x72,
+	0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x12, 0x0a,
+	0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69,
+	0x64, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x6c, 0x64, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x6c, 0x64, 0x65, 0x72, 0x12, 0x31, 0x0a, 0x05, 0x66, 0x69,
+	0x6c, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d,
+	0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76,
+	0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x22, 0x88, 0x02,
+	0x0a, 0x0c, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x69, 0x6e, 0x67, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12,
+	0x25, 0x0a, 0x0e, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69,
+	0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x64, 0x35, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x64, 0x35, 0x12, 0x3b, 0x0a, 0x0b, 0x61, 0x72, 0x63,
+	0x68, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x61, 0x72, 0x63, 0x68,
+	0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x22, 0x7c, 0x0a, 0x15, 0x52, 0x65, 0x73, 0x74,
+	0x6f, 0x72, 0x65, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x10, 0x0a, 0x03, 0x6a, 0x6f, 0x62, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6a, 0x6f, 0x62, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x75,
+	0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6d, 0x65, 0x65, 0x74, 0x69,
+	0x6e, 0x67, 0x55, 0x75, 0x69, 0x64, 0x12, 0x2e, 0x0a, 0x13, 0x6c, 0x69, 0x6e, 0x6b, 0x5f, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x79, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x11, 0x6c, 0x69, 0x6e, 0x6b, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x96, 0x01, 0x0a, 0x16, 0x52, 0x65, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x38, 0x0a, 0x07, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x4d, 0x65, 0x65, 0x74, 0x69,
+	0x6e, 0x67, 0x52, 0x07, 0x6d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x42, 0x0a, 0x0f, 0x6c,
+	0x69, 0x6e, 0x6b, 0x73, 0x5f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x5f, 0x61, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x0d, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x32,
+	0xf0, 0x02, 0x0a, 0x06, 0x42, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x12, 0x48, 0x0a, 0x08, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x52, 0x75, 0x6e, 0x12, 0x1e, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63,
+	0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x75, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63,
+	0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x75, 0x6e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x30, 0x01, 0x12, 0x4c, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x52, 0x75, 0x6e, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x22, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x75, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62,
+	0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x75, 0x6e, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x6f, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76,
+	0x65, 0x64, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x2a, 0x2e, 0x7a, 0x6f, 0x6f,
+	0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41,
+	0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2b, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63,
+	0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x72, 0x63, 0x68, 0x69,
+	0x76, 0x65, 0x64, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x5d, 0x0a, 0x0e, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x65,
+	0x65, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x24, 0x2e, 0x7a, 0x6f, 0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b,
+	0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x65, 0x65,
+	0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x7a, 0x6f,
+	0x6f, 0x6d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x73, 0x74,
+	0x6f, 0x72, 0x65, 0x4d, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x42, 0x2c, 0x5a, 0x2a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x63, 0x6f, 0x64, 0x65, 0x67, 0x6f, 0x61, 0x6c, 0x69, 0x65, 0x2f, 0x7a, 0x6f, 0x6f, 0x6d,
+	0x2d, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x2f, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x70, 0x62,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_backup_proto_rawDescOnce sync.Once
+	file_backup_proto_rawDescData = file_backup_proto_rawDesc
+)
+
+func file_backup_proto_rawDescGZIP() []byte {
+	file_backup_proto_rawDescOnce.Do(func() {
+		file_backup_proto_rawDescData = protoimpl.X.CompressGZIP(file_backup_proto_rawDescData)
+	})
+	return file_backup_proto_rawDescData
+}
+
+var file_backup_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_backup_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_backup_proto_goTypes = []interface{}{
+	(RunStatus_State)(0),                 // 0: zoombackup.v1.RunStatus.State
+	(*StartRunRequest)(nil),              // 1: zoombackup.v1.StartRunRequest
+	(*RunProgress)(nil),                  // 2: zoombackup.v1.RunProgress
+	(*Event)(nil),                        // 3: zoombackup.v1.Event
+	(*GetRunStatusRequest)(nil),          // 4: zoombackup.v1.GetRunStatusRequest
+	(*RunStatus)(nil),                    // 5: zoombackup.v1.RunStatus
+	(*JobReport)(nil),                    // 6: zoombackup.v1.JobReport
+	(*MeetingTimeline)(nil),              // 7: zoombackup.v1.MeetingTimeline
+	(*FileTimeline)(nil),                 // 8: zoombackup.v1.FileTimeline
+	(*ApiDeprecation)(nil),               // 9: zoombackup.v1.ApiDeprecation
+	(*ListArchivedMeetingsRequest)(nil),  // 10: zoombackup.v1.ListArchivedMeetingsRequest
+	(*ListArchivedMeetingsResponse)(nil), // 11: zoombackup.v1.ListArchivedMeetingsResponse
+	(*ArchivedMeeting)(nil),              // 12: zoombackup.v1.ArchivedMeeting
+	(*ArchivedFile)(nil),                 // 13: zoombackup.v1.ArchivedFile
+	(*RestoreMeetingRequest)(nil),        // 14: zoombackup.v1.RestoreMeetingRequest
+	(*RestoreMeetingResponse)(nil),       // 15: zoombackup.v1.RestoreMeetingResponse
+	(*timestamp.Timestamp)(nil),          // 16: google.protobuf.Timestamp
+}
+var file_backup_proto_depIdxs = []int32{
+	3,  // 0: zoombackup.v1.RunProgress.event:type_name -> zoombackup.v1.Event
+	5,  // 1: zoombackup.v1.RunProgress.status:type_name -> zoombackup.v1.RunStatus
+	16, // 2: zoombackup.v1.Event.time:type_name -> google.protobuf.Timestamp
+	0,  // 3: zoombackup.v1.RunStatus.state:type_name -> zoombackup.v1.RunStatus.State
+	16, // 4: zoombackup.v1.RunStatus.started_at:type_name -> google.protobuf.Timestamp
+	16, // 5: zoombackup.v1.RunStatus.finished_at:type_name -> google.protobuf.Timestamp
+	6,  // 6: zoombackup.v1.RunStatus.reports:type_name -> zoombackup.v1.JobReport
+	16, // 7: zoombackup.v1.JobReport.started_at:type_name -> google.protobuf.Timestamp
+	16, // 8: zoombackup.v1.JobReport.finished_at:type_name -> google.protobuf.Timestamp
+	9,  // 9: zoombackup.v1.JobReport.zoom_api_deprecations:type_name -> zoombackup.v1.ApiDeprecation
+	7,  // 10: zoombackup.v1.JobReport.timelines:type_name -> zoombackup.v1.MeetingTimeline
+	16, // 11: zoombackup.v1.MeetingTimeline.discovered:type_name -> google.protobuf.Timestamp
+	8,  // 12: zoombackup.v1.MeetingTimeline.files:type_name -> zoombackup.v1.FileTimeline
+	16, // 13: zoombackup.v1.MeetingTimeline.deleted:type_name -> google.protobuf.Timestamp
+	16, // 14: zoombackup.v1.FileTimeline.download_started:type_name -> google.protobuf.Timestamp
+	16, // 15: zoombackup.v1.FileTimeline.downloaded:type_name -> google.protobuf.Timestamp
+	16, // 16: zoombackup.v1.FileTimeline.uploaded:type_name -> google.protobuf.Timestamp
+	16, // 17: zoombackup.v1.FileTimeline.verified:type_name -> google.protobuf.Timestamp
+	16, // 18: zoombackup.v1.ApiDeprecation.first_seen:type_name -> google.protobuf.Timestamp
+	16, // 19: zoombackup.v1.ApiDeprecation.last_seen:type_name -> google.protobuf.Timestamp
+	12, // 20: zoombackup.v1.ListArchivedMeetingsResponse.meetings:type_name -> zoombackup.v1.ArchivedMeeting
+	13, // 21: zoombackup.v1.ArchivedMeeting.files:type_name -> zoombackup.v1.ArchivedFile
+	16, // 22: zoombackup.v1.ArchivedFile.archived_at:type_name -> google.protobuf.Timestamp
+	12, // 23: zoombackup.v1.RestoreMeetingResponse.meeting:type_name -> zoombackup.v1.ArchivedMeeting
+	16, // 24: zoombackup.v1.RestoreMeetingResponse.links_expire_at:type_name -> google.protobuf.Timestamp
+	1,  // 25: zoombackup.v1.Backup.StartRun:input_type -> zoombackup.v1.StartRunRequest
+	4,  // 26: zoombackup.v1.Backup.GetRunStatus:input_type -> zoombackup.v1.GetRunStatusRequest
+	10, // 27: zoombackup.v1.Backup.ListArchivedMeetings:input_type -> zoombackup.v1.ListArchivedMeetingsRequest
+	14, // 28: zoombackup.v1.Backup.RestoreMeeting:input_type -> zoombackup.v1.RestoreMeetingRequest
+	2,  // 29: zoombackup.v1.Backup.StartRun:output_type -> zoombackup.v1.RunProgress
+	5,  // 30: zoombackup.v1.Backup.GetRunStatus:output_type -> zoombackup.v1.RunStatus
+	11, // 31: zoombackup.v1.Backup.ListArchivedMeetings:output_type -> zoombackup.v1.ListArchivedMeetingsResponse
+	15, // 32: zoombackup.v1.Backup.RestoreMeeting:output_type -> zoombackup.v1.RestoreMeetingResponse
+	29, // [29:33] is the sub-list for method output_type
+	25, // [25:29] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
+}
+
+func init() { file_backup_proto_init() }
+func file_backup_proto_init() {
+	if File_backup_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_backup_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartRunRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RunProgress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetRunStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RunStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JobReport); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MeetingTimeline); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FileTimeline); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ApiDeprecation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListArchivedMeetingsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListArchivedMeetingsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ArchivedMeeting); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ArchivedFile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RestoreMeetingRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_backup_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RestoreMeetingResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_backup_proto_msgTypes[1].OneofWrappers = []interface{}{
+		(*RunProgress_Event)(nil),
+		(*RunProgress_Status)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_backup_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_backup_proto_goTypes,
+		DependencyIndexes: file_backup_proto_depIdxs,
+		EnumInfos:         file_backup_proto_enumTypes,
+		MessageInfos:      file_backup_proto_msgTypes,
+	}.Build()
+	File_backup_proto = out.File
+	file_backup_proto_rawDesc = nil
+	file_backup_proto_goTypes = nil
+	file_backup_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// BackupClient is the client API for Backup service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BackupClient interface {
+	// StartRun starts a run of the selected jobs and streams its progress.
+	// The run carries on when the client disconnects; use GetRunStatus to
+	// follow it afterwards. Only one run is in progress at a time.
+	StartRun(ctx context.Context, in *StartRunRequest, opts ...grpc.CallOption) (Backup_StartRunClient, error)
+	GetRunStatus(ctx context.Context, in *GetRunStatusRequest, opts ...grpc.CallOption) (*RunStatus, error)
+	// ListArchivedMeetings lists the meetings in a job's manifest.
+	ListArchivedMeetings(ctx context.Context, in *ListArchivedMeetingsRequest, opts ...grpc.CallOption) (*ListArchivedMeetingsResponse, error)
+	// RestoreMeeting re-shares an archived meeting as links to its files,
+	// signed when SIGNING_SERVICE_ACCOUNT is set.
+	RestoreMeeting(ctx context.Context, in *RestoreMeetingRequest, opts ...grpc.CallOption) (*RestoreMeetingResponse, error)
+}
+
+type backupClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBackupClient(cc grpc.ClientConnInterface) BackupClient {
+	return &backupClient{cc}
+}
+
+func (c *backupClient) StartRun(ctx context.Context, in *StartRunRequest, opts ...grpc.CallOption) (Backup_StartRunClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Backup_serviceDesc.Streams[0], "/zoombackup.v1.Backup/StartRun", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backupStartRunClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Backup_StartRunClient interface {
+	Recv() (*RunProgress, error)
+	grpc.ClientStream
+}
+
+type backupStartRunClient struct {
+	grpc.ClientStream
+}
+
+func (x *backupStartRunClient) Recv() (*RunProgress, error) {
+	m := new(RunProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *backupClient) GetRunStatus(ctx context.Context, in *GetRunStatusRequest, opts ...grpc.CallOption) (*RunStatus, error) {
+	out := new(RunStatus)
+	err := c.cc.Invoke(ctx, "/zoombackup.v1.Backup/GetRunStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backupClient) ListArchivedMeetings(ctx context.Context, in *ListArchivedMeetingsRequest, opts ...grpc.CallOption) (*ListArchivedMeetingsResponse, error) {
+	out := new(ListArchivedMeetingsResponse)
+	err := c.cc.Invoke(ctx, "/zoombackup.v1.Backup/ListArchivedMeetings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backupClient) RestoreMeeting(ctx context.Context, in *RestoreMeetingRequest, opts ...grpc.CallOption) (*RestoreMeetingResponse, error) {
+	out := new(RestoreMeetingResponse)
+	err := c.cc.Invoke(ctx, "/zoombackup.v1.Backup/RestoreMeeting", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BackupServer is the server API for Backup service.
+type BackupServer interface {
+	// StartRun starts a run of the selected jobs and streams its progress.
+	// The run carries on when the client disconnects; use GetRunStatus to
+	// follow it afterwards. Only one run is in progress at a time.
+	StartRun(*StartRunRequest, Backup_StartRunServer) error
+	GetRunStatus(context.Context, *GetRunStatusRequest) (*RunStatus, error)
+	// ListArchivedMeetings lists the meetings in a job's manifest.
+	ListArchivedMeetings(context.Context, *ListArchivedMeetingsRequest) (*ListArchivedMeetingsResponse, error)
+	// RestoreMeeting re-shares an archived meeting as links to its files,
+	// signed when SIGNING_SERVICE_ACCOUNT is set.
+	RestoreMeeting(context.Context, *RestoreMeetingRequest) (*RestoreMeetingResponse, error)
+}
+
+// UnimplementedBackupServer can be embedded to have forward compatible implementations.
+type UnimplementedBackupServer struct {
+}
+
+func (*UnimplementedBackupServer) StartRun(*StartRunRequest, Backup_StartRunServer) error {
+	return status.Errorf(codes.Unimplemented, "method StartRun not implemented")
+}
+func (*UnimplementedBackupServer) GetRunStatus(context.Context, *GetRunStatusRequest) (*RunStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRunStatus not implemented")
+}
+func (*UnimplementedBackupServer) ListArchivedMeetings(context.Context, *ListArchivedMeetingsRequest) (*ListArchivedMeetingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListArchivedMeetings not implemented")
+}
+func (*UnimplementedBackupServer) RestoreMeeting(context.Context, *RestoreMeetingRequest) (*RestoreMeetingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreMeeting not implemented")
+}
+
+func RegisterBackupServer(s *grpc.Server, srv BackupServer) {
+	s.RegisterService(&_Backup_serviceDesc, srv)
+}
+
+func _Backup_StartRun_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StartRunRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BackupServer).StartRun(m, &backupStartRunServer{stream})
+}
+
+type Backup_StartRunServer interface {
+	Send(*RunProgress) error
+	grpc.ServerStream
+}
+
+type backupStartRunServer struct {
+	grpc.ServerStream
+}
+
+func (x *backupStartRunServer) Send(m *RunProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Backup_GetRunStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRunStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackupServer).GetRunStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zoombackup.v1.Backup/GetRunStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackupServer).GetRunStatus(ctx, req.(*GetRunStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backup_ListArchivedMeetings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArchivedMeetingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackupServer).ListArchivedMeetings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zoombackup.v1.Backup/ListArchivedMeetings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackupServer).ListArchivedMeetings(ctx, req.(*ListArchivedMeetingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backup_RestoreMeeting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreMeetingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackupServer).RestoreMeeting(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zoombackup.v1.Backup/RestoreMeeting",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackupServer).RestoreMeeting(ctx, req.(*RestoreMeetingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Backup_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "zoombackup.v1.Backup",
+	HandlerType: (*BackupServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRunStatus",
+			Handler:    _Backup_GetRunStatus_Handler,
+		},
+		{
+			MethodName: "ListArchivedMeetings",
+			Handler:    _Backup_ListArchivedMeetings_Handler,
+		},
+		{
+			MethodName: "RestoreMeeting",
+			Handler:    _Backup_RestoreMeeting_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StartRun",
+			Handler:       _Backup_StartRun_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "backup.proto",
+}
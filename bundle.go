@@ -0,0 +1,102 @@
+package zoombackup
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// writeMeetingBundle streams everything archived for the meeting, its
+// recordings and the files exported next to them, into one ZIP object in the
+// meeting folder, so sharing or restoring a meeting takes a single object.
+func (run *backupRun) writeMeetingBundle(ctx context.Context, mtg meeting, files []archivedFile) error {
+	cfg := run.cfg
+	folder, err := meetingFolder(cfg, mtg)
+	if err != nil {
+		return err
+	}
+	var objects []string
+	for _, file := range files {
+		objects = append(objects, file.attrs.Name)
+	}
+	export := func(name, fileType string) {
+		objects = append(objects, cfg.compressedName(cfg.objectName(path.Join(folder, name)), fileType))
+	}
+	if cfg.MeetingSidecar {
+		objects = append(objects, cfg.objectName(path.Join(folder, cfg.MeetingSidecarName)))
+	}
+	if cfg.ParticipantsExport {
+		export("participants.json", "JSON")
+		export("participants.csv", "CSV")
+	}
+	if cfg.MeetingChatExport && len(mtg.Chat) > 0 {
+		for i := range mtg.Chat {
+			export(meetingChatFileName(i), "CHAT")
+		}
+		export(meetingChatName, "JSON")
+	}
+	if mtg.isWebinar() && cfg.WebinarQAExport {
+		export("qa.json", "JSON")
+	}
+	if mtg.isWebinar() && cfg.WebinarPollsExport {
+		export("polls.json", "JSON")
+	}
+	if cfg.Transcribe != "" && !mtg.HasTranscript {
+		export(transcriptName, "VTT")
+	}
+
+	// Cancelling the upload's context abandons it without committing a
+	// partial bundle.
+	uploadCtx, cancelUpload := context.WithCancel(ctx)
+	defer cancelUpload()
+	name := cfg.objectName(path.Join(folder, cfg.MeetingBundleName))
+	wc := run.meetingWriter(uploadCtx, mtg, name)
+	wc.ContentType = "application/zip"
+	zw := zip.NewWriter(wc)
+	for _, object := range objects {
+		if err := run.addToBundle(ctx, zw, object); err != nil {
+			return fmt.Errorf("failed to add %s to %s: %w", object, name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return wc.Close()
+}
+
+// addToBundle copies the object, as it was downloaded from Zoom, into the
+// bundle. Exports Zoom had nothing for, and so were never written, are left
+// out.
+func (run *backupRun) addToBundle(ctx context.Context, zw *zip.Writer, object string) error {
+	r, err := run.openStored(ctx, object)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	entry := &zip.FileHeader{
+		Name:     path.Base(strings.TrimSuffix(object, zstdExt)),
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	}
+	switch strings.ToLower(path.Ext(entry.Name)) {
+	case ".mp4", ".m4a":
+		// Media is compressed already.
+		entry.Method = zip.Store
+	}
+	w, err := zw.CreateHeader(entry)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
@@ -0,0 +1,171 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"time"
+)
+
+const (
+	canaryModeMeeting   = "meeting"
+	canaryModeSynthetic = "synthetic"
+
+	canaryObjectPrefix = "canary/"
+)
+
+// runCanary proves the pipeline works end to end before anything is deleted
+// from Zoom. In meeting mode the smallest meeting is archived, verified
+// against the sizes Zoom reported and only then deleted; in synthetic mode a
+// test object is written, read back and removed. When the canary fails every
+// deletion of the run is withheld while archiving carries on. The meetings
+// still to be processed are returned.
+func (run *backupRun) runCanary(ctx context.Context, meetings []meeting) []meeting {
+	var err error
+	switch run.cfg.CanaryMode {
+	case "":
+		return meetings
+	case canaryModeSynthetic:
+		err = run.syntheticCanary(ctx)
+	case canaryModeMeeting:
+		if len(meetings) == 0 {
+			return meetings
+		}
+		canary, rest, ok := takeSmallestMeeting(meetings)
+		if !ok {
+			log.Println("No meeting can be the canary, withholding deletions for this run")
+			run.report.Canary = "skipped"
+			run.canaryFailed = true
+			return meetings
+		}
+		meetings = rest
+		err = run.meetingCanary(ctx, canary)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("canary failed, withholding deletions for this run: %w", err)
+		log.Println(err)
+		run.report.fail(err)
+		run.report.Canary = "failed"
+		run.canaryFailed = true
+		return meetings
+	}
+
+	log.Println("Canary passed")
+	run.report.Canary = "passed"
+	return meetings
+}
+
+func (run *backupRun) meetingCanary(ctx context.Context, canary meeting) error {
+	log.Println("Archiving canary meeting", canary.ID, canary.Topic)
+	files, complete := run.archiveMeeting(ctx, canary)
+	err := fmt.Errorf("meeting %s was not archived completely", canary.ID)
+	if complete {
+		err = run.readBackCanary(ctx, canary, files)
+	}
+	if err != nil {
+		// Settled still, without deleting it, for the audit log and the
+		// after meeting hook.
+		run.settle(ctx, canary, files, false)
+		return err
+	}
+
+	// The canary is settled like every other meeting, so it is only deleted
+	// once it is old enough and not while Zoom is still processing it.
+	if _, err := run.settle(ctx, canary, files, complete); err != nil {
+		return fmt.Errorf("failed to settle meeting %s: %w", canary.ID, err)
+	}
+	return nil
+}
+
+// readBackCanary checks that every archived file of the canary meeting has
+// the size Zoom reported.
+func (run *backupRun) readBackCanary(ctx context.Context, canary meeting, files []archivedFile) error {
+	for _, file := range files {
+		attrs, err := run.storageClient.Bucket(run.cfg.Bucket).Object(file.attrs.Name).Attrs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read back %s: %w", file.attrs.Name, err)
+		}
+		if file.recording.FileSize > 0 && attrs.Size != file.recording.FileSize {
+			return fmt.Errorf("%s is %d bytes but Zoom reported %d", attrs.Name, attrs.Size, file.recording.FileSize)
+		}
+		run.emit(eventVerified, canary, event{File: file.recording.FileName(), FileID: file.recording.ID, Object: attrs.Name, Bytes: attrs.Size})
+	}
+	return nil
+}
+
+func (run *backupRun) syntheticCanary(ctx context.Context) error {
+	payload := make([]byte, 1024)
+	if _, err := rand.Read(payload); err != nil {
+		return err
+	}
+
+	name := run.cfg.objectName(canaryObjectPrefix + time.Now().UTC().Format("20060102T150405Z") + ".bin")
+	// The canary goes through the same encryption as recordings so a
+	// missing key permission shows up before anything is deleted.
+	obj := run.contentObject(name)
+
+	wc := run.contentWriter(ctx, name)
+	if _, err := wc.Write(payload); err != nil {
+		_ = wc.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	defer func() {
+		if err := obj.Delete(ctx); err != nil {
+			log.Println("Could not delete canary object", name, err)
+		}
+	}()
+
+	r, err := obj.NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer r.Close()
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if !bytes.Equal(got, payload) {
+		return errors.New("canary object content does not match what was written")
+	}
+	return nil
+}
+
+// takeSmallestMeeting removes the meeting with the fewest bytes to archive
+// from meetings and returns it along with the rest. Meetings without files
+// to archive or with files Zoom is still processing prove nothing and are
+// never picked; ok is false when no meeting is left to pick.
+func takeSmallestMeeting(meetings []meeting) (canary meeting, rest []meeting, ok bool) {
+	smallest := -1
+	var smallestSize int64
+	for i, m := range meetings {
+		if len(m.Files) == 0 || m.Processing {
+			continue
+		}
+		if size := meetingSize(m); smallest < 0 || size < smallestSize {
+			smallest, smallestSize = i, size
+		}
+	}
+	if smallest < 0 {
+		return meeting{}, meetings, false
+	}
+
+	canary = meetings[smallest]
+	rest = append(append([]meeting(nil), meetings[:smallest]...), meetings[smallest+1:]...)
+	return canary, rest, true
+}
+
+func meetingSize(m meeting) int64 {
+	var size int64
+	for _, file := range m.Files {
+		size += file.FileSize
+	}
+	return size
+}
@@ -0,0 +1,175 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	zoomChatChannelsPath = "/chat/users/%s/channels?page_size=50"
+	zoomChatMessagesPath = "/chat/users/%s/messages?to_channel=%s&from=%s&to=%s&page_size=50"
+)
+
+type chatChannel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type int    `json:"type"`
+}
+
+type chatChannelsResponse struct {
+	NextPageToken string        `json:"next_page_token"`
+	Channels      []chatChannel `json:"channels"`
+}
+
+type chatMessagesResponse struct {
+	NextPageToken string            `json:"next_page_token"`
+	Messages      []json.RawMessage `json:"messages"`
+}
+
+// chatExport is stored per channel and day. Messages are kept exactly as
+// Zoom returned them.
+type chatExport struct {
+	ChannelID   string            `json:"channel_id"`
+	ChannelName string            `json:"channel_name"`
+	UserID      string            `json:"user_id"`
+	Date        string            `json:"date"`
+	Messages    []json.RawMessage `json:"messages"`
+	ExportedAt  time.Time         `json:"exported_at"`
+}
+
+func (c *zoomClient) listChatChannels(ctx context.Context, userID string) ([]chatChannel, error) {
+	var channels []chatChannel
+	nextPageToken := ""
+	for {
+		reqPath := fmt.Sprintf(zoomChatChannelsPath, url.PathEscape(userID))
+		if nextPageToken != "" {
+			reqPath += "&next_page_token=" + url.QueryEscape(nextPageToken)
+		}
+		response := &chatChannelsResponse{}
+		if err := c.getJSON(ctx, reqPath, response); err != nil {
+			return nil, err
+		}
+		channels = append(channels, response.Channels...)
+		if response.NextPageToken == "" {
+			return channels, nil
+		}
+		nextPageToken = response.NextPageToken
+	}
+}
+
+// listChatMessages lists the messages the user can see in the channel
+// between the two times.
+func (c *zoomClient) listChatMessages(ctx context.Context, userID, channelID string, from, to time.Time) ([]json.RawMessage, error) {
+	messages := []json.RawMessage{}
+	nextPageToken := ""
+	for {
+		reqPath := fmt.Sprintf(zoomChatMessagesPath, url.PathEscape(userID), url.QueryEscape(channelID),
+			url.QueryEscape(from.UTC().Format(time.RFC3339)), url.QueryEscape(to.UTC().Format(time.RFC3339)))
+		if nextPageToken != "" {
+			reqPath += "&next_page_token=" + url.QueryEscape(nextPageToken)
+		}
+		response := &chatMessagesResponse{}
+		if err := c.getJSON(ctx, reqPath, response); err != nil {
+			return nil, err
+		}
+		messages = append(messages, response.Messages...)
+		if response.NextPageToken == "" {
+			return messages, nil
+		}
+		nextPageToken = response.NextPageToken
+	}
+}
+
+// exportChats stores the Team Chat messages of the last CHAT_LOOKBACK_DAYS of
+// every channel the job's users are in as CHAT_PREFIX/<channel>/<date>.json,
+// one object per channel and day in TIMEZONE. Channels shared by several
+// users are exported once. Days are exported again by every run, so an
+// object always holds the whole day as far as it has happened.
+func (run *backupRun) exportChats(ctx context.Context) error {
+	userIDs, err := resolveUserIDs(ctx, run.zoom, run.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to resolve users: %w", err)
+	}
+	today := time.Now().In(run.cfg.location)
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, run.cfg.location)
+
+	var problems []string
+	exported := map[string]bool{}
+	objects := 0
+	for _, userID := range userIDs {
+		channels, err := run.zoom.listChatChannels(ctx, userID)
+		if errors.Is(err, errZoomNotFound) {
+			log.Println("No Team Chat channels for", userID)
+			continue
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("channels of %s: %v", userID, err))
+			continue
+		}
+		for _, channel := range channels {
+			if exported[channel.ID] {
+				continue
+			}
+			exported[channel.ID] = true
+			for day := 0; day < run.cfg.ChatLookbackDays; day++ {
+				from := today.AddDate(0, 0, -day)
+				written, err := run.exportChatDay(ctx, userID, channel, from)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s on %s: %v", channel.Name, from.Format(ymdFormat), err))
+					continue
+				}
+				if written {
+					objects++
+				}
+			}
+		}
+	}
+	log.Printf("Exported %d days of chat from %d channels", objects, len(exported))
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// exportChatDay stores the channel's messages of the day starting at from.
+// Days without messages are not stored.
+func (run *backupRun) exportChatDay(ctx context.Context, userID string, channel chatChannel, from time.Time) (bool, error) {
+	messages, err := run.zoom.listChatMessages(ctx, userID, channel.ID, from, from.AddDate(0, 0, 1).Add(-time.Second))
+	if err != nil {
+		return false, err
+	}
+	if len(messages) == 0 {
+		return false, nil
+	}
+	raw, err := json.MarshalIndent(chatExport{
+		ChannelID:   channel.ID,
+		ChannelName: channel.Name,
+		UserID:      userID,
+		Date:        from.Format(ymdFormat),
+		Messages:    messages,
+		ExportedAt:  time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	name := run.cfg.objectName(path.Join(run.cfg.ChatPrefix, slugify(channel.Name)+"-"+channel.ID, from.Format(ymdFormat)+".json"))
+	// The day stands in for the meeting, so OBJECT_CUSTOM_TIME ages chats
+	// out by when they were written.
+	day := meeting{StartTime: from.Format(time.RFC3339)}
+	if err := run.writeMeetingFile(ctx, day, name, "JSON", run.cfg.contentType("JSON"), raw); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// isChatExport reports whether the object was written by CHAT_EXPORT.
+func isChatExport(cfg *config, name string) bool {
+	return cfg.ChatPrefix != "" && strings.HasPrefix(name, cfg.objectName(cfg.ChatPrefix)+"/")
+}
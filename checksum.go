@@ -0,0 +1,147 @@
+package zoombackup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"path"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+const (
+	// checksumFilesSidecar writes <file>.<algorithm> next to every file.
+	checksumFilesSidecar = "sidecar"
+	// checksumFilesSums keeps one <ALGORITHM>SUMS file per folder.
+	checksumFilesSums = "sums"
+)
+
+// checksumAlgorithms are the CHECKSUM_ALGORITHM values, named like the
+// coreutils tools that verify their files.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// checksumLine is one line in the format of sha256sum and friends, for the
+// file as it was downloaded from Zoom, i.e. without the .zst of COMPRESSION.
+func checksumLine(object string, sum []byte) string {
+	return fmt.Sprintf("%x  %s\n", sum, path.Base(strings.TrimSuffix(object, zstdExt)))
+}
+
+// sumsName is the per-folder checksum file, e.g. SHA256SUMS.
+func (cfg *config) sumsName() string {
+	return strings.ToUpper(cfg.ChecksumAlgorithm) + "SUMS"
+}
+
+// isChecksumFile reports whether the object was written for CHECKSUM_FILES.
+func isChecksumFile(cfg *config, name string) bool {
+	switch cfg.ChecksumFiles {
+	case checksumFilesSidecar:
+		return strings.HasSuffix(name, "."+cfg.ChecksumAlgorithm)
+	case checksumFilesSums:
+		return path.Base(name) == cfg.sumsName()
+	}
+	return false
+}
+
+// writeChecksumSidecar stores the checksum of one archived file next to it.
+func (run *backupRun) writeChecksumSidecar(ctx context.Context, mtg meeting, file archivedFile) error {
+	name := strings.TrimSuffix(file.attrs.Name, zstdExt) + "." + run.cfg.ChecksumAlgorithm
+	return writeChecksumFile(run.meetingWriter(ctx, mtg, name), name, checksumLine(file.attrs.Name, file.checksum))
+}
+
+// writeChecksumSums adds the checksums of the meeting's files to the SUMS
+// file of their folders. Lines of files archived earlier are kept, so a
+// folder shared by several meetings or runs lists all of them.
+func (run *backupRun) writeChecksumSums(ctx context.Context, mtg meeting, files []archivedFile) error {
+	folders := map[string]map[string]string{}
+	for _, file := range files {
+		if file.checksum == nil {
+			continue
+		}
+		folder := path.Dir(file.attrs.Name)
+		if folders[folder] == nil {
+			folders[folder] = map[string]string{}
+		}
+		folders[folder][path.Base(strings.TrimSuffix(file.attrs.Name, zstdExt))] = checksumLine(file.attrs.Name, file.checksum)
+	}
+
+	// Meetings sharing a folder are archived concurrently, so reading and
+	// rewriting the SUMS file is serialized within the run, and other runs,
+	// tasks and queue workers are caught by the generation precondition.
+	run.checksumsMu.Lock()
+	defer run.checksumsMu.Unlock()
+	for folder, lines := range folders {
+		name := path.Join(folder, run.cfg.sumsName())
+		err := retryOnConflict(ctx, name, func() error {
+			existing, generation, err := run.readChecksumFile(ctx, name)
+			if err != nil {
+				return err
+			}
+			merged := map[string]string{}
+			for file, line := range existing {
+				merged[file] = line
+			}
+			for file, line := range lines {
+				merged[file] = line
+			}
+			var names []string
+			for file := range merged {
+				names = append(names, file)
+			}
+			sort.Strings(names)
+			var content strings.Builder
+			for _, file := range names {
+				content.WriteString(merged[file])
+			}
+			return writeChecksumFile(run.meetingObjectWriter(ctx, mtg, ifGeneration(run.contentObject(name), generation)), name, content.String())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readChecksumFile returns the lines of a SUMS file by file name and its
+// generation, none and 0 when it does not exist yet.
+func (run *backupRun) readChecksumFile(ctx context.Context, name string) (map[string]string, int64, error) {
+	lines := map[string]string{}
+	raw, generation, err := readObject(ctx, run.contentObject(name))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		if fields := strings.SplitN(scanner.Text(), "  ", 2); len(fields) == 2 {
+			lines[fields[1]] = scanner.Text() + "\n"
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return lines, generation, nil
+}
+
+func writeChecksumFile(wc *storage.Writer, name, content string) error {
+	wc.ContentType = "text/plain"
+	if _, err := bytes.NewReader([]byte(content)).WriteTo(wc); err != nil {
+		_ = wc.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
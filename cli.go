@@ -0,0 +1,259 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"cloud.google.com/go/storage"
+	"github.com/gobuffalo/envy"
+	"google.golang.org/api/option"
+)
+
+// Exit codes of a backup, so cron and other monitoring can tell a run that
+// archived everything from one that left work undone.
+const (
+	exitOK = 0
+	// exitFatal means the run could not start or a job stopped before it
+	// archived anything.
+	exitFatal = 1
+	// exitPartial means some files or steps of a job failed.
+	exitPartial = 2
+)
+
+// RunCLI runs the command line with the same configuration as the Cloud
+// Function and returns the process exit code. The first argument may name a
+// command; without one a backup is run.
+func RunCLI(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "backup":
+			return backupCommand(args[1:])
+		case "adopt":
+			return adoptCommand(args[1:])
+		case "restore":
+			return restoreCommand(args[1:])
+		case "verify":
+			return verifyCommand(args[1:])
+		case "prune":
+			return pruneCommand(args[1:])
+		case "publish":
+			return publishCommand(args[1:])
+		case "recover":
+			return recoverCommand(args[1:])
+		}
+	}
+	return backupCommand(args)
+}
+
+func backupCommand(args []string) int {
+	fs := flag.NewFlagSet("zoom-backup backup", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	input := fs.String("input", "", "process the meetings in this JSON file (list recordings response) instead of asking Zoom; local path or gs://bucket/object")
+	eventsOut := fs.String("events-out", "", "write one JSON line per pipeline action to this file, or - for stdout")
+	grpcAddr := fs.String("grpc-addr", "", "serve the gRPC control API on this address, e.g. localhost:9090, instead of running once")
+	queueWorker := fs.Bool("queue-worker", false, "archive meetings queued in QUEUE_MODE instead of listing them")
+	meetingUUID := fs.String("meeting-uuid", "", "back up only the meeting with this UUID right away")
+	jobName := fs.String("job", "", "run only this job; required with -meeting-uuid when several jobs are configured")
+	reportOut := fs.String("report", "", "write the JSON report of every job, with the outcome of each meeting and file, to this file, or - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return exitFatal
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	var events *eventStream
+	storageClient, jobs, err := setupCLI(ctx, func(cfg *config) error {
+		if *input != "" {
+			cfg.InputFile = *input
+		}
+		if *grpcAddr != "" {
+			cfg.GRPCAddr = *grpcAddr
+		}
+		if *eventsOut != "" {
+			cfg.EventsOut = *eventsOut
+		}
+		var err error
+		events, err = openEventStream(cfg.EventsOut)
+		return err
+	})
+	if err != nil {
+		log.Println(err)
+		return exitFatal
+	}
+	defer events.Close()
+	if *jobName != "" || *meetingUUID != "" {
+		selected, err := selectJob(jobs, *jobName)
+		if err != nil {
+			log.Println(err)
+			return exitFatal
+		}
+		selected.meetingUUID = *meetingUUID
+		jobs = []*config{selected}
+	}
+	for _, job := range jobs {
+		job.queueWorker = *queueWorker
+	}
+
+	if grpcAddr := jobs[0].GRPCAddr; grpcAddr != "" {
+		if err := serveGRPC(ctx, grpcAddr, storageClient, jobs, events); err != nil {
+			log.Println(err)
+			return exitFatal
+		}
+		return exitOK
+	}
+
+	reports := runJobs(ctx, storageClient, jobs, events)
+	printSummary(os.Stderr, reports)
+	if *reportOut != "" {
+		if err := writeReports(*reportOut, reports); err != nil {
+			log.Println(err)
+			return exitFatal
+		}
+	}
+	return exitCode(reports)
+}
+
+// writeReports writes the reports as JSON to stdout for "-" and to the file
+// at dest otherwise.
+func writeReports(dest string, reports []*runReport) error {
+	raw, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	raw = append(raw, '\n')
+	if dest == "-" {
+		_, err = os.Stdout.Write(raw)
+	} else {
+		err = ioutil.WriteFile(dest, raw, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// printSummary writes one line per job with what became of its files.
+func printSummary(w io.Writer, reports []*runReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSTATUS\tARCHIVED\tFAILED\tSKIPPED\tERRORS")
+	for _, r := range reports {
+		r.mu.Lock()
+		job := r.Job
+		if job == "" {
+			job = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\n", job, r.status(), r.FilesArchived, r.FilesFailed, r.FilesSkipped, len(r.Errors))
+		r.mu.Unlock()
+	}
+	_ = tw.Flush()
+}
+
+// exitCode is exitFatal when a job stopped early, exitPartial when anything
+// else failed and exitOK otherwise.
+func exitCode(reports []*runReport) int {
+	code := exitOK
+	for _, r := range reports {
+		r.mu.Lock()
+		fatal := r.Fatal != ""
+		r.mu.Unlock()
+		if fatal {
+			return exitFatal
+		}
+		if r.failed() {
+			code = exitPartial
+		}
+	}
+	return code
+}
+
+// signalContext is cancelled by SIGINT or SIGTERM, so a run stops starting
+// new work, abandons uploads in flight and records what it archived instead
+// of being killed mid-write. A second signal kills the process.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Println("Received", sig, "shutting down")
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, cancel
+}
+
+// setupCLI loads the configuration, lets override apply command line flags
+// to it before the jobs are derived from it and connects to GCS.
+func setupCLI(ctx context.Context, override func(*config) error) (*storage.Client, []*config, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := override(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	storageClient, err := newCLIStorageClient(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jobs, err := loadJobs(ctx, storageClient, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return storageClient, jobs, nil
+}
+
+// selectJob returns the named job, which may be left out when there is only
+// one.
+func selectJob(jobs []*config, name string) (*config, error) {
+	if name == "" {
+		if len(jobs) != 1 {
+			return nil, errors.New("-job is required when more than one job is configured")
+		}
+		return jobs[0], nil
+	}
+	for _, job := range jobs {
+		if job.JobName == name {
+			return job, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown job %q", name)
+}
+
+// newCLIStorageClient authenticates with the service account key in
+// GCLOUD_STORAGE_CREDS, which may name a secret, or else with Application
+// Default Credentials like the Cloud Function, which covers workload identity
+// on GKE and the attached service account on GCE and Cloud Run.
+func newCLIStorageClient(ctx context.Context, cfg *config) (*storage.Client, error) {
+	creds, err := secrets.resolve(ctx, cfg.client(), envy.Get("GCLOUD_STORAGE_CREDS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("GCLOUD_STORAGE_CREDS: %w", err)
+	}
+	var opts []option.ClientOption
+	if creds != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(creds)))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		if creds == "" {
+			return nil, fmt.Errorf("Error creating new storage client, set GCLOUD_STORAGE_CREDS or Application Default Credentials: %w", err)
+		}
+		return nil, fmt.Errorf("Error creating new storage client: %w", err)
+	}
+	return client, nil
+}
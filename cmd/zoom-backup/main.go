@@ -0,0 +1,14 @@
+// Command zoom-backup backs up Zoom cloud recordings to Google Cloud Storage.
+// It reads the same environment variables, or .env file, as the Cloud
+// Function.
+package main
+
+import (
+	"os"
+
+	zoombackup "github.com/codegoalie/zoom-backup"
+)
+
+func main() {
+	os.Exit(zoombackup.RunCLI(os.Args[1:]))
+}
@@ -0,0 +1,107 @@
+package zoombackup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	compressionNone = "none"
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+
+	zstdExt = ".zst"
+)
+
+// compresses reports whether files of the type are compressed on upload.
+// Video, audio and images are compressed already and gain nothing.
+func (cfg *config) compresses(fileType string) bool {
+	if cfg.Compression == compressionNone {
+		return false
+	}
+	switch strings.ToUpper(fileType) {
+	case "MP4", "M4A", "JPG":
+		return false
+	}
+	return true
+}
+
+// compressedName returns the name a file of the type is stored under. gzip
+// files keep their name because Cloud Storage serves them decompressed to
+// clients that do not accept gzip, which it cannot do for zstd, so zstd
+// files get a .zst extension.
+func (cfg *config) compressedName(name, fileType string) string {
+	if cfg.Compression == compressionZstd && cfg.compresses(fileType) {
+		return name + zstdExt
+	}
+	return name
+}
+
+// compressingWriter writes a meeting file into its object, compressing it
+// with COMPRESSION when the file type allows.
+type compressingWriter struct {
+	sw  *storage.Writer
+	enc io.WriteCloser
+}
+
+// compressingWriter returns a writer for a file of the type stored as name,
+// which must come from compressedName. contentType describes the
+// uncompressed content.
+func (run *backupRun) compressingWriter(ctx context.Context, mtg meeting, name, fileType, contentType string) (*compressingWriter, error) {
+	w := &compressingWriter{sw: run.meetingWriter(ctx, mtg, name)}
+	w.sw.ContentType = contentType
+	if !run.cfg.compresses(fileType) {
+		return w, nil
+	}
+	switch run.cfg.Compression {
+	case compressionGzip:
+		w.sw.ContentEncoding = "gzip"
+		w.enc = gzip.NewWriter(w.sw)
+	case compressionZstd:
+		w.sw.ContentType = "application/zstd"
+		enc, err := zstd.NewWriter(w.sw)
+		if err != nil {
+			return nil, err
+		}
+		w.enc = enc
+	}
+	return w, nil
+}
+
+func (w *compressingWriter) Write(p []byte) (int, error) {
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.sw.Write(p)
+}
+
+// Close flushes the compressor and finishes the upload.
+func (w *compressingWriter) Close() error {
+	if w.enc != nil {
+		if err := w.enc.Close(); err != nil {
+			_ = w.sw.Close()
+			return err
+		}
+	}
+	return w.sw.Close()
+}
+
+// writeMeetingFile stores content exported for the meeting, such as
+// participant lists, as name with COMPRESSION applied.
+func (run *backupRun) writeMeetingFile(ctx context.Context, mtg meeting, name, fileType, contentType string, content []byte) error {
+	w, err := run.compressingWriter(ctx, mtg, run.cfg.compressedName(name, fileType), fileType, contentType)
+	if err != nil {
+		return err
+	}
+	if _, err := bytes.NewReader(content).WriteTo(w); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
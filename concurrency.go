@@ -0,0 +1,25 @@
+package zoombackup
+
+import (
+	"golang.org/x/sync/semaphore"
+)
+
+// stageLimits bounds how much work each stage of the pipeline may have in
+// flight at once. The stages are limited independently so that, for example,
+// many parallel downloads never turn into as many parallel delete calls
+// against the Zoom API.
+type stageLimits struct {
+	api      *semaphore.Weighted
+	download *semaphore.Weighted
+	upload   *semaphore.Weighted
+	delete   *semaphore.Weighted
+}
+
+func newStageLimits(cfg *config) *stageLimits {
+	return &stageLimits{
+		api:      semaphore.NewWeighted(int64(cfg.APIConcurrency)),
+		download: semaphore.NewWeighted(int64(cfg.DownloadConcurrency)),
+		upload:   semaphore.NewWeighted(int64(cfg.UploadConcurrency)),
+		delete:   semaphore.NewWeighted(int64(cfg.DeleteConcurrency)),
+	}
+}
@@ -0,0 +1,1275 @@
+package zoombackup
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gobuffalo/envy"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	defaultIndexFileName = "biga.html"
+	defaultIndexTitle    = "Kitchen Rodeos"
+)
+
+// config holds the settings of a single backup job. The environment provides
+// the defaults and every job listed in JOBS_CONFIG may override any field by
+// its yaml name.
+type config struct {
+	JobName string `yaml:"name"`
+
+	ZoomAPIKey    string `yaml:"zoom_api_key"`
+	ZoomAPISecret string `yaml:"zoom_api_secret"`
+	// ZoomAccountID, ZoomClientID and ZoomClientSecret authenticate through a
+	// Server-to-Server OAuth app instead of a JWT app.
+	ZoomAccountID    string   `yaml:"zoom_account_id"`
+	ZoomClientID     string   `yaml:"zoom_client_id"`
+	ZoomClientSecret string   `yaml:"zoom_client_secret"`
+	ZoomUserID       string   `yaml:"zoom_user_id"`
+	ZoomGroupIDs     []string `yaml:"zoom_group_ids"`
+	// ZoomAPIURL is the base URL of the Zoom API.
+	ZoomAPIURL string `yaml:"zoom_api_url"`
+	// ZoomOAuthURL is where OAuth access tokens are requested.
+	ZoomOAuthURL string `yaml:"zoom_oauth_url"`
+	Bucket       string `yaml:"gstorage_bucket"`
+	Prefix       string `yaml:"gstorage_path"`
+
+	StorageClass   string            `yaml:"storage_class"`
+	ObjectMetadata map[string]string `yaml:"object_metadata"`
+
+	ObjectCustomTime bool `yaml:"object_custom_time"`
+
+	// ContentTypes overrides the Content-Type of archived files by Zoom
+	// file type.
+	ContentTypes map[string]string `yaml:"content_types"`
+	contentTypes map[string]string
+
+	// Compression is none, gzip or zstd and applies to files other than
+	// video and audio.
+	Compression string `yaml:"compression"`
+
+	KMSKeyName    string `yaml:"kms_key_name"`
+	EncryptionKey string `yaml:"encryption_key"`
+	encryptionKey []byte
+
+	NamingTemplate string `yaml:"naming_template"`
+	naming         *template.Template
+	// TimeZone is the IANA time zone in which meeting dates are shown and
+	// folders are named.
+	TimeZone string `yaml:"timezone"`
+	location *time.Location
+
+	TopicAllowedChars    string `yaml:"topic_allowed_chars"`
+	TopicReplacement     string `yaml:"topic_replacement"`
+	TopicMaxLength       int    `yaml:"topic_max_length"`
+	TopicCollisionSuffix bool   `yaml:"topic_collision_suffix"`
+	topicDisallowed      *regexp.Regexp
+
+	InputFile        string   `yaml:"input_file"`
+	RecordingSources []string `yaml:"recording_sources"`
+	// TopicIncludePattern and TopicExcludePattern select the meetings to
+	// archive by topic; the others are left untouched on Zoom.
+	TopicIncludePattern string `yaml:"topic_include_pattern"`
+	TopicExcludePattern string `yaml:"topic_exclude_pattern"`
+	topicInclude        *regexp.Regexp
+	topicExclude        *regexp.Regexp
+	// LookbackDays is how far back recordings are listed.
+	LookbackDays int `yaml:"lookback_days"`
+	// VerifyDays makes every run verify the archive against the recordings
+	// of this many days after archiving, like the verify command.
+	VerifyDays int `yaml:"verify_days"`
+	// policy names the weekday policies applied to the job.
+	policy string
+	// triggerUsers, listFrom and listTo narrow a run started through
+	// Pub/Sub to these users and to recordings of this date range instead of
+	// LookbackDays.
+	triggerUsers     []string
+	listFrom, listTo time.Time
+	// trash lists the recordings in Zoom's trash instead, for the recover
+	// command.
+	trash bool
+	// meetingUUID backs up only this meeting instead of listing the users'
+	// recordings.
+	meetingUUID string
+
+	ExcludeRoleIDs    []string          `yaml:"zoom_exclude_role_ids"`
+	ExcludeAttributes map[string]string `yaml:"zoom_exclude_attributes"`
+	// ExcludeUsers are user IDs or emails and ExcludeGroupIDs group IDs or
+	// names whose members are never backed up.
+	ExcludeUsers    []string `yaml:"zoom_exclude_users"`
+	ExcludeGroupIDs []string `yaml:"zoom_exclude_group_ids"`
+
+	// StatusObject keeps the outcome of the job's last run for /status.
+	StatusObject string `yaml:"status_object"`
+	// RunInterval is how often the job is scheduled, used to tell when the
+	// next run is due.
+	RunInterval time.Duration `yaml:"run_interval"`
+	// ListSinceLastSuccess lists recordings from ListOverlap before the
+	// start of the last run that archived everything it listed, instead of
+	// the last LookbackDays.
+	ListSinceLastSuccess bool          `yaml:"list_since_last_success"`
+	ListOverlap          time.Duration `yaml:"list_overlap"`
+
+	DeleteFromZoom bool   `yaml:"delete_from_zoom"`
+	ControlObject  string `yaml:"control_object"`
+	CanaryMode     string `yaml:"canary_mode"`
+	// DeleteAfterDays keeps recordings on Zoom until their meeting is this
+	// many days old.
+	DeleteAfterDays int `yaml:"delete_after_days"`
+	// DeleteMode is meeting to delete all of a meeting's recordings or files
+	// to delete only the archived ones.
+	DeleteMode string `yaml:"delete_mode"`
+
+	APIConcurrency      int `yaml:"api_concurrency"`
+	DownloadConcurrency int `yaml:"download_concurrency"`
+	UploadConcurrency   int `yaml:"upload_concurrency"`
+	DeleteConcurrency   int `yaml:"delete_concurrency"`
+	// MaxMeetingsPerRun caps how many meetings a run processes, unlimited
+	// when 0.
+	MaxMeetingsPerRun int `yaml:"max_meetings_per_run"`
+
+	DownloadRetries int    `yaml:"download_retries"`
+	DownloadAuth    string `yaml:"download_auth"`
+
+	// CriticalTopicPattern selects meetings whose recordings are verified
+	// with CriticalVerify before they are deleted from Zoom.
+	CriticalTopicPattern string `yaml:"critical_topic_pattern"`
+	criticalTopic        *regexp.Regexp
+	CriticalVerify       string `yaml:"critical_verify"`
+
+	// AudioOnlyTopicPattern selects meetings of which only the audio is
+	// archived.
+	AudioOnlyTopicPattern string `yaml:"audio_only_topic_pattern"`
+	audioOnlyTopic        *regexp.Regexp
+
+	// RecordingViews are the recording types of the MP4 files archived when
+	// Zoom recorded a meeting in several of them.
+	RecordingViews []string `yaml:"recording_views"`
+	// RecordingViewPreference are recording types in order of preference, of
+	// which only the first a meeting was recorded in is archived.
+	RecordingViewPreference []string `yaml:"recording_view_preference"`
+
+	// AllowedHours are HH:MM-HH:MM ranges in TIMEZONE during which transfers
+	// may start.
+	AllowedHours     []string `yaml:"allowed_hours"`
+	allowedHours     []hourWindow
+	CheckpointObject string `yaml:"checkpoint_object"`
+
+	MaxFileSize           int64 `yaml:"max_file_size"`
+	UploadChunkSize       int   `yaml:"upload_chunk_size"`
+	UploadProgressEvery   int64 `yaml:"upload_progress_every"`
+	DownloadMinThroughput int64 `yaml:"download_min_throughput"`
+	// DownloadTimeout is what every download gets on top of the time its
+	// size needs at DownloadMinThroughput.
+	DownloadTimeout time.Duration `yaml:"download_timeout"`
+	// DownloadConnections fetches files larger than DownloadRangeSize in
+	// ranges of that size over this many connections.
+	DownloadConnections int   `yaml:"download_connections"`
+	DownloadRangeSize   int64 `yaml:"download_range_size"`
+	// DownloadProgressInterval is how often the progress of a download is
+	// logged, never when 0.
+	DownloadProgressInterval time.Duration `yaml:"download_progress_interval"`
+	// MemoryLimit overrides the memory limit detected for sizing upload
+	// chunks.
+	MemoryLimit int64 `yaml:"memory_limit"`
+	// uploadChunkAuto is set when UPLOAD_CHUNK_SIZE is auto, so jobs
+	// overriding the memory limit or the upload concurrency size their
+	// chunks again.
+	uploadChunkAuto bool
+
+	ChecksumFiles     string `yaml:"checksum_files"`
+	ChecksumAlgorithm string `yaml:"checksum_algorithm"`
+
+	// DriveFolderID mirrors archived recordings into this Google Drive
+	// folder, authenticated with DriveCredentials, impersonating
+	// DriveSubject through domain-wide delegation when set.
+	DriveFolderID    string `yaml:"drive_folder_id"`
+	DriveCredentials string `yaml:"drive_credentials"`
+	DriveSubject     string `yaml:"drive_subject"`
+	// DropboxFolder mirrors archived recordings into this Dropbox folder,
+	// in the team space of DropboxNamespaceID when set.
+	DropboxFolder       string `yaml:"dropbox_folder"`
+	DropboxAppKey       string `yaml:"dropbox_app_key"`
+	DropboxAppSecret    string `yaml:"dropbox_app_secret"`
+	DropboxRefreshToken string `yaml:"dropbox_refresh_token"`
+	DropboxNamespaceID  string `yaml:"dropbox_namespace_id"`
+	// SFTPHost mirrors archived recordings into SFTPPath on this SSH
+	// server, authenticated with SFTPPrivateKey. SFTPHostKey is the server's
+	// public key as in known_hosts, without the host name.
+	SFTPHost          string `yaml:"sftp_host"`
+	SFTPPort          int    `yaml:"sftp_port"`
+	SFTPUser          string `yaml:"sftp_user"`
+	SFTPPrivateKey    string `yaml:"sftp_private_key"`
+	SFTPKeyPassphrase string `yaml:"sftp_key_passphrase"`
+	SFTPHostKey       string `yaml:"sftp_host_key"`
+	SFTPPath          string `yaml:"sftp_path"`
+	// RcloneDestination mirrors archived recordings to this rclone remote
+	// and path, running RcloneBinary with the rclone.conf contents in
+	// RcloneManagedConfig or else rclone's own configuration.
+	RcloneDestination   string `yaml:"rclone_destination"`
+	RcloneBinary        string `yaml:"rclone_binary"`
+	RcloneManagedConfig string `yaml:"rclone_managed_config"`
+
+	MeetingSidecar     bool   `yaml:"meeting_sidecar"`
+	MeetingSidecarName string `yaml:"meeting_sidecar_name"`
+	// MeetingSidecarPasscode adds the recordings' passcodes to the sidecar.
+	MeetingSidecarPasscode bool `yaml:"meeting_sidecar_passcode"`
+	// Transcode re-encodes MP4 recordings with FFmpegBinary before they are
+	// uploaded, to TranscodeHeight lines at most with TranscodeCodec at
+	// TranscodeCRF quality, or TranscodeVideoBitrate when set.
+	Transcode             bool   `yaml:"transcode"`
+	TranscodeHeight       int    `yaml:"transcode_height"`
+	TranscodeCodec        string `yaml:"transcode_codec"`
+	TranscodeCRF          int    `yaml:"transcode_crf"`
+	TranscodeVideoBitrate string `yaml:"transcode_video_bitrate"`
+	TranscodeAudioBitrate string `yaml:"transcode_audio_bitrate"`
+	FFmpegBinary          string `yaml:"ffmpeg_binary"`
+
+	// MeetingBundle writes MeetingBundleName, a ZIP of everything archived
+	// for the meeting, into its folder.
+	MeetingBundle     bool   `yaml:"meeting_bundle"`
+	MeetingBundleName string `yaml:"meeting_bundle_name"`
+
+	// Thumbnails stores a ThumbnailWidth pixels wide JPEG of the frame
+	// ThumbnailOffset seconds into every archived MP4 next to it, for the
+	// index.
+	Thumbnails      bool `yaml:"thumbnails"`
+	ThumbnailOffset int  `yaml:"thumbnail_offset"`
+	ThumbnailWidth  int  `yaml:"thumbnail_width"`
+
+	// Transcribe generates a transcript of meetings Zoom did not transcribe
+	// with Google Speech-to-Text or a Whisper endpoint.
+	Transcribe         string `yaml:"transcribe"`
+	TranscribeLanguage string `yaml:"transcribe_language"`
+	WhisperURL         string `yaml:"whisper_url"`
+	WhisperAPIKey      string `yaml:"whisper_api_key"`
+	WhisperModel       string `yaml:"whisper_model"`
+
+	RecordingSettings     map[string]string `yaml:"recording_settings"`
+	RecordingSettingsMode string            `yaml:"recording_settings_mode"`
+
+	ParticipantsExport bool `yaml:"participants_export"`
+	WebinarQAExport    bool `yaml:"webinar_qa_export"`
+	WebinarPollsExport bool `yaml:"webinar_polls_export"`
+	// MeetingChatExport stores the in-meeting chat Zoom recorded.
+	MeetingChatExport bool `yaml:"meeting_chat_export"`
+
+	// ChatExport stores the Team Chat messages of ChatLookbackDays below
+	// ChatPrefix.
+	ChatExport       bool   `yaml:"chat_export"`
+	ChatPrefix       string `yaml:"chat_prefix"`
+	ChatLookbackDays int    `yaml:"chat_lookback_days"`
+
+	ExpiryForecastDays   int    `yaml:"expiry_forecast_days"`
+	ExpiryForecastObject string `yaml:"expiry_forecast_object"`
+
+	ManifestEnabled  bool   `yaml:"manifest_enabled"`
+	ManifestFileName string `yaml:"manifest_file_name"`
+
+	RetentionDays        int    `yaml:"retention_days"`
+	RetentionMaxPerTopic int    `yaml:"retention_max_per_topic"`
+	RetentionAction      string `yaml:"retention_action"`
+	RetentionDryRun      bool   `yaml:"retention_dry_run"`
+
+	MetricsEnabled   bool    `yaml:"metrics_enabled"`
+	MetricsObject    string  `yaml:"metrics_object"`
+	SLOReportObject  string  `yaml:"slo_report_object"`
+	SLOWindowDays    int     `yaml:"slo_window_days"`
+	SLOSuccessTarget float64 `yaml:"slo_success_target"`
+
+	// DebugResponses stores sanitized Zoom API responses under
+	// DebugResponsesPrefix for DebugResponsesTTL.
+	DebugResponses       bool          `yaml:"debug_responses"`
+	DebugResponsesPrefix string        `yaml:"debug_responses_prefix"`
+	DebugResponsesTTL    time.Duration `yaml:"debug_responses_ttl"`
+
+	FeedEnabled    bool          `yaml:"feed_enabled"`
+	FeedFileName   string        `yaml:"feed_file_name"`
+	FeedMaxItems   int           `yaml:"feed_max_items"`
+	FeedLinkExpiry time.Duration `yaml:"feed_link_expiry"`
+
+	SigningServiceAccount string `yaml:"signing_service_account"`
+
+	NotifyOn                 string `yaml:"notify_on"`
+	NotifySlackWebhookURL    string `yaml:"notify_slack_webhook_url"`
+	NotifySlackTemplate      string `yaml:"notify_slack_template"`
+	NotifyWebhookURL         string `yaml:"notify_webhook_url"`
+	NotifyWebhookTemplate    string `yaml:"notify_webhook_template"`
+	NotifyWebhookContentType string `yaml:"notify_webhook_content_type"`
+	// Notifiers are further channels run summaries are posted to.
+	Notifiers []notifierConfig `yaml:"notifiers"`
+
+	// HookBeforeRun, HookAfterMeeting and HookAfterRun are commands or URLs
+	// run with the JSON context of the hook, each within HookTimeout.
+	HookBeforeRun    string        `yaml:"hook_before_run"`
+	HookAfterMeeting string        `yaml:"hook_after_meeting"`
+	HookAfterRun     string        `yaml:"hook_after_run"`
+	HookTimeout      time.Duration `yaml:"hook_timeout"`
+
+	// HealthcheckStartURL, HealthcheckURL and HealthcheckFailURL are pinged
+	// when a run starts, succeeds and fails.
+	HealthcheckStartURL string `yaml:"healthcheck_start_url"`
+	HealthcheckURL      string `yaml:"healthcheck_url"`
+	HealthcheckFailURL  string `yaml:"healthcheck_fail_url"`
+
+	// SentryDSN reports failed files and runs to Sentry, tagged with
+	// SentryEnvironment.
+	SentryDSN         string `yaml:"sentry_dsn"`
+	SentryEnvironment string `yaml:"sentry_environment"`
+
+	// BigQueryAuditTable, project.dataset.table, receives one row per
+	// archived file.
+	BigQueryAuditTable string `yaml:"bigquery_audit_table"`
+
+	// QueueMode firestore makes runs queue the meetings they discover in
+	// QueueCollection of QueueProject for queue workers to archive. tasks
+	// creates a Cloud Task in TasksQueue per recording file instead and
+	// tracks the meetings in QueueCollection.
+	QueueMode        string        `yaml:"queue_mode"`
+	QueueProject     string        `yaml:"queue_project"`
+	QueueCollection  string        `yaml:"queue_collection"`
+	QueueBatch       int           `yaml:"queue_batch"`
+	QueueLease       time.Duration `yaml:"queue_lease"`
+	QueueWorkTime    time.Duration `yaml:"queue_work_time"`
+	QueueMaxAttempts int           `yaml:"queue_max_attempts"`
+	// queueWorker makes the run archive meetings leased from the queue
+	// instead of discovering them.
+	queueWorker bool
+
+	// TasksQueue is projects/PROJECT/locations/LOCATION/queues/QUEUE and
+	// TasksURL the URL of the function's /task endpoint its tasks call,
+	// authenticated as TasksServiceAccount when set.
+	TasksQueue          string `yaml:"tasks_queue"`
+	TasksURL            string `yaml:"tasks_url"`
+	TasksServiceAccount string `yaml:"tasks_service_account"`
+	// fileTask makes the run archive the one file of a Cloud Task instead
+	// of discovering meetings.
+	fileTask *fileTask
+
+	IndexEnabled  bool   `yaml:"index_enabled"`
+	IndexFileName string `yaml:"index_file_name"`
+	IndexTitle    string `yaml:"index_title"`
+	IndexTemplate string `yaml:"index_template"`
+	IndexRetries  int    `yaml:"index_retries"`
+	IndexGroupBy  string `yaml:"index_group_by"`
+
+	// ResolveHosts looks up the email and name of every meeting's host.
+	ResolveHosts bool `yaml:"resolve_hosts"`
+
+	// SearchIndex writes SearchIndexName, an inverted index of the archived
+	// transcripts, next to the index and adds a search box to it.
+	SearchIndex     bool   `yaml:"search_index"`
+	SearchIndexName string `yaml:"search_index_name"`
+
+	// Dialer is process wide and therefore cannot be overridden per job.
+	Dialer     dialerConfig `yaml:"-"`
+	EventsOut  string       `yaml:"-"`
+	GRPCAddr   string       `yaml:"-"`
+	JobsConfig string       `yaml:"-"`
+
+	// httpClient dials with Dialer. loadConfig builds it once for the
+	// invocation, and the jobs cloned from the config share it.
+	httpClient *http.Client
+}
+
+// client is the HTTP client of the config's Dialer, or the default one for
+// configs that were not loaded, e.g. in tests.
+func (cfg *config) client() *http.Client {
+	if cfg.httpClient != nil {
+		return cfg.httpClient
+	}
+	return defaultHTTPClient
+}
+
+func loadConfig() (*config, error) {
+	cfg := &config{
+		ZoomAPIKey:       envy.Get("ZOOM_API_KEY", ""),
+		ZoomAPISecret:    envy.Get("ZOOM_API_SECRET", ""),
+		ZoomAccountID:    envy.Get("ZOOM_ACCOUNT_ID", ""),
+		ZoomClientID:     envy.Get("ZOOM_CLIENT_ID", ""),
+		ZoomClientSecret: envy.Get("ZOOM_CLIENT_SECRET", ""),
+		ZoomUserID:       envy.Get("ZOOM_USER_ID", ""),
+		ZoomGroupIDs:     envList("ZOOM_GROUP_IDS"),
+		ZoomAPIURL:       strings.TrimSuffix(envy.Get("ZOOM_API_URL", defaultZoomAPIURL), "/"),
+		ZoomOAuthURL:     envy.Get("ZOOM_OAUTH_URL", defaultZoomOAuthURL),
+		Bucket:           envy.Get("GSTORAGE_BUCKET", ""),
+		Prefix:           strings.Trim(envy.Get("GSTORAGE_PATH", ""), "/"),
+		InputFile:        envy.Get("INPUT_FILE", ""),
+		StorageClass:     strings.ToUpper(envy.Get("STORAGE_CLASS", "")),
+		Compression:      strings.ToLower(envy.Get("COMPRESSION", compressionNone)),
+		KMSKeyName:       envy.Get("KMS_KEY_NAME", ""),
+		EncryptionKey:    envy.Get("ENCRYPTION_KEY", ""),
+
+		NamingTemplate:    envy.Get("NAMING_TEMPLATE", defaultNamingTemplate),
+		TimeZone:          envy.Get("TIMEZONE", "UTC"),
+		TopicAllowedChars: envy.Get("TOPIC_ALLOWED_CHARS", defaultTopicAllowedChars),
+		TopicReplacement:  envy.Get("TOPIC_REPLACEMENT", "_"),
+		IndexFileName:     envy.Get("INDEX_FILE_NAME", defaultIndexFileName),
+		IndexTitle:        envy.Get("INDEX_TITLE", defaultIndexTitle),
+		IndexTemplate:     envy.Get("INDEX_TEMPLATE", ""),
+		IndexGroupBy:      strings.ToLower(envy.Get("INDEX_GROUP_BY", "")),
+
+		SearchIndexName: envy.Get("SEARCH_INDEX_NAME", "search.json"),
+
+		DownloadAuth: strings.ToLower(envy.Get("DOWNLOAD_AUTH", downloadAuthHeader)),
+
+		ChatPrefix: strings.Trim(envy.Get("CHAT_PREFIX", "chat"), "/"),
+
+		MeetingSidecarName: envy.Get("MEETING_SIDECAR_NAME", "meeting.json"),
+		MeetingBundleName:  envy.Get("MEETING_BUNDLE_NAME", "meeting.zip"),
+		ChecksumFiles:      envy.Get("CHECKSUM_FILES", ""),
+		RetentionAction:    envy.Get("RETENTION_ACTION", retentionActionDelete),
+		DeleteMode:         envy.Get("DELETE_MODE", deleteModeMeeting),
+		ChecksumAlgorithm:  strings.ToLower(envy.Get("CHECKSUM_ALGORITHM", "sha256")),
+		ManifestFileName:   envy.Get("MANIFEST_FILE_NAME", "index.json"),
+		MetricsObject:      envy.Get("METRICS_OBJECT", "metrics/transfers.json"),
+		SLOReportObject:    envy.Get("SLO_REPORT_OBJECT", "metrics/slo-report.json"),
+
+		DriveFolderID:       envy.Get("DRIVE_FOLDER_ID", ""),
+		DriveCredentials:    envy.Get("DRIVE_CREDENTIALS", ""),
+		DriveSubject:        envy.Get("DRIVE_SUBJECT", ""),
+		DropboxFolder:       envy.Get("DROPBOX_FOLDER", ""),
+		DropboxAppKey:       envy.Get("DROPBOX_APP_KEY", ""),
+		DropboxAppSecret:    envy.Get("DROPBOX_APP_SECRET", ""),
+		DropboxRefreshToken: envy.Get("DROPBOX_REFRESH_TOKEN", ""),
+		DropboxNamespaceID:  envy.Get("DROPBOX_NAMESPACE_ID", ""),
+		SFTPHost:            envy.Get("SFTP_HOST", ""),
+		SFTPUser:            envy.Get("SFTP_USER", ""),
+		SFTPPrivateKey:      envy.Get("SFTP_PRIVATE_KEY", ""),
+		SFTPKeyPassphrase:   envy.Get("SFTP_KEY_PASSPHRASE", ""),
+		SFTPHostKey:         envy.Get("SFTP_HOST_KEY", ""),
+		SFTPPath:            envy.Get("SFTP_PATH", "."),
+		RcloneDestination:   envy.Get("RCLONE_DESTINATION", ""),
+		RcloneBinary:        envy.Get("RCLONE_BINARY", "rclone"),
+		RcloneManagedConfig: envy.Get("RCLONE_MANAGED_CONFIG", ""),
+
+		TranscodeCodec:        envy.Get("TRANSCODE_CODEC", "libx265"),
+		TranscodeVideoBitrate: envy.Get("TRANSCODE_VIDEO_BITRATE", ""),
+		TranscodeAudioBitrate: envy.Get("TRANSCODE_AUDIO_BITRATE", "96k"),
+		FFmpegBinary:          envy.Get("FFMPEG_BINARY", "ffmpeg"),
+		AudioOnlyTopicPattern: envy.Get("AUDIO_ONLY_TOPIC_PATTERN", ""),
+
+		Transcribe:         envy.Get("TRANSCRIBE", ""),
+		TranscribeLanguage: envy.Get("TRANSCRIBE_LANGUAGE", "en-US"),
+		WhisperURL:         envy.Get("WHISPER_URL", defaultWhisperURL),
+		WhisperAPIKey:      envy.Get("WHISPER_API_KEY", ""),
+		WhisperModel:       envy.Get("WHISPER_MODEL", "whisper-1"),
+
+		FeedFileName:          envy.Get("FEED_FILE_NAME", "feed.xml"),
+		SigningServiceAccount: envy.Get("SIGNING_SERVICE_ACCOUNT", ""),
+
+		ControlObject: envy.Get("CONTROL_OBJECT", "control.json"),
+		StatusObject:  envy.Get("STATUS_OBJECT", "status.json"),
+
+		ExpiryForecastObject: envy.Get("EXPIRY_FORECAST_OBJECT", "reports/expiry-forecast.json"),
+
+		DebugResponsesPrefix: envy.Get("DEBUG_RESPONSES_PREFIX", "debug/"),
+
+		NotifyOn:                 envy.Get("NOTIFY_ON", notifyOnErrors),
+		NotifySlackWebhookURL:    envy.Get("NOTIFY_SLACK_WEBHOOK_URL", ""),
+		NotifySlackTemplate:      envy.Get("NOTIFY_SLACK_TEMPLATE", ""),
+		NotifyWebhookURL:         envy.Get("NOTIFY_WEBHOOK_URL", ""),
+		NotifyWebhookTemplate:    envy.Get("NOTIFY_WEBHOOK_TEMPLATE", ""),
+		NotifyWebhookContentType: envy.Get("NOTIFY_WEBHOOK_CONTENT_TYPE", "application/json"),
+		HookBeforeRun:            envy.Get("HOOK_BEFORE_RUN", ""),
+		HookAfterMeeting:         envy.Get("HOOK_AFTER_MEETING", ""),
+		HookAfterRun:             envy.Get("HOOK_AFTER_RUN", ""),
+
+		HealthcheckStartURL: envy.Get("HEALTHCHECK_START_URL", ""),
+		HealthcheckURL:      envy.Get("HEALTHCHECK_URL", ""),
+		HealthcheckFailURL:  envy.Get("HEALTHCHECK_FAIL_URL", ""),
+
+		SentryDSN:         envy.Get("SENTRY_DSN", ""),
+		SentryEnvironment: envy.Get("SENTRY_ENVIRONMENT", ""),
+
+		BigQueryAuditTable: envy.Get("BIGQUERY_AUDIT_TABLE", ""),
+
+		QueueMode:       strings.ToLower(envy.Get("QUEUE_MODE", "")),
+		QueueProject:    envy.Get("QUEUE_PROJECT", ""),
+		QueueCollection: envy.Get("QUEUE_COLLECTION", "zoom-backup-queue"),
+
+		TasksQueue:          envy.Get("TASKS_QUEUE", ""),
+		TasksURL:            envy.Get("TASKS_URL", ""),
+		TasksServiceAccount: envy.Get("TASKS_SERVICE_ACCOUNT", ""),
+
+		AllowedHours:         envList("ALLOWED_HOURS"),
+		CheckpointObject:     envy.Get("CHECKPOINT_OBJECT", "checkpoint.json"),
+		CanaryMode:           envy.Get("CANARY_MODE", ""),
+		CriticalTopicPattern: envy.Get("CRITICAL_TOPIC_PATTERN", ""),
+		TopicIncludePattern:  envy.Get("TOPIC_INCLUDE_PATTERN", ""),
+		TopicExcludePattern:  envy.Get("TOPIC_EXCLUDE_PATTERN", ""),
+		CriticalVerify:       envy.Get("CRITICAL_VERIFY", criticalVerifyReread),
+		JobsConfig:           envy.Get("JOBS_CONFIG", ""),
+		EventsOut:            envy.Get("EVENTS_OUT", ""),
+		GRPCAddr:             envy.Get("GRPC_ADDR", ""),
+	}
+
+	var err error
+	cfg.RecordingSources = envList("RECORDING_SOURCES")
+	if len(cfg.RecordingSources) == 0 {
+		cfg.RecordingSources = []string{recordingSourceMeeting}
+	}
+	cfg.RecordingViews = envList("RECORDING_VIEWS")
+	cfg.RecordingViewPreference = envList("RECORDING_VIEW_PREFERENCE")
+	cfg.ExcludeRoleIDs = envList("ZOOM_EXCLUDE_ROLE_IDS")
+	cfg.ExcludeUsers = envList("ZOOM_EXCLUDE_USERS")
+	cfg.ExcludeGroupIDs = envList("ZOOM_EXCLUDE_GROUP_IDS")
+	cfg.ExcludeAttributes, err = envMap("ZOOM_EXCLUDE_ATTRIBUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Notifiers, err = envNotifiers("NOTIFIERS"); err != nil {
+		return nil, err
+	}
+	if cfg.HookTimeout, err = envDuration("HOOK_TIMEOUT", time.Minute); err != nil {
+		return nil, err
+	}
+	cfg.ObjectMetadata, err = envMap("OBJECT_METADATA")
+	if err != nil {
+		return nil, err
+	}
+	cfg.ContentTypes, err = envMap("CONTENT_TYPES")
+	if err != nil {
+		return nil, err
+	}
+	cfg.ObjectCustomTime, err = envBool("OBJECT_CUSTOM_TIME", true)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RecordingSettings, err = envMap("RECORDING_SETTINGS")
+	if err != nil {
+		return nil, err
+	}
+	cfg.RecordingSettingsMode = envy.Get("RECORDING_SETTINGS_MODE", settingsModeCheck)
+
+	cfg.DeleteFromZoom, err = envBool("DELETE_FROM_ZOOM", true)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DeleteAfterDays, err = envInt("DELETE_AFTER_DAYS", 0); err != nil {
+		return nil, err
+	}
+
+	cfg.IndexEnabled, err = envBool("INDEX_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.IndexRetries, err = envInt("INDEX_RETRIES", 2); err != nil {
+		return nil, err
+	}
+	if cfg.ResolveHosts, err = envBool("RESOLVE_HOSTS", false); err != nil {
+		return nil, err
+	}
+	cfg.SearchIndex, err = envBool("SEARCH_INDEX", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ParticipantsExport, err = envBool("PARTICIPANTS_EXPORT", false)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ChatExport, err = envBool("CHAT_EXPORT", false); err != nil {
+		return nil, err
+	}
+	if cfg.ChatLookbackDays, err = envInt("CHAT_LOOKBACK_DAYS", 2); err != nil {
+		return nil, err
+	}
+	if cfg.MaxFileSize, err = envBytes("MAX_FILE_SIZE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.MemoryLimit, err = envBytes("MEMORY_LIMIT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.UploadProgressEvery, err = envBytes("UPLOAD_PROGRESS_EVERY", 0); err != nil {
+		return nil, err
+	}
+	if cfg.DownloadProgressInterval, err = envDuration("DOWNLOAD_PROGRESS_INTERVAL", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.DownloadMinThroughput, err = envBytes("DOWNLOAD_MIN_THROUGHPUT", 512<<10); err != nil {
+		return nil, err
+	}
+	if cfg.DownloadTimeout, err = envDuration("DOWNLOAD_TIMEOUT", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.DownloadConnections, err = envInt("DOWNLOAD_CONNECTIONS", 1); err != nil {
+		return nil, err
+	}
+	if cfg.DownloadRangeSize, err = envBytes("DOWNLOAD_RANGE_SIZE", 32<<20); err != nil {
+		return nil, err
+	}
+
+	cfg.TopicMaxLength, err = envInt("TOPIC_MAX_LENGTH", 80)
+	if err != nil {
+		return nil, err
+	}
+	cfg.TopicCollisionSuffix, err = envBool("TOPIC_COLLISION_SUFFIX", true)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WebinarQAExport, err = envBool("WEBINAR_QA_EXPORT", false)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WebinarPollsExport, err = envBool("WEBINAR_POLLS_EXPORT", false)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MeetingChatExport, err = envBool("MEETING_CHAT_EXPORT", false); err != nil {
+		return nil, err
+	}
+	cfg.MeetingSidecar, err = envBool("MEETING_SIDECAR", true)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MeetingSidecarPasscode, err = envBool("MEETING_SIDECAR_PASSCODE", false); err != nil {
+		return nil, err
+	}
+	cfg.MeetingBundle, err = envBool("MEETING_BUNDLE", false)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Transcode, err = envBool("TRANSCODE", false)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ManifestEnabled, err = envBool("MANIFEST_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	cfg.MetricsEnabled, err = envBool("METRICS_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SLOWindowDays, err = envInt("SLO_WINDOW_DAYS", 28); err != nil {
+		return nil, err
+	}
+	if cfg.SLOSuccessTarget, err = envFloat("SLO_SUCCESS_TARGET", 0.99); err != nil {
+		return nil, err
+	}
+	if cfg.DownloadRetries, err = envInt("DOWNLOAD_RETRIES", 2); err != nil {
+		return nil, err
+	}
+
+	cfg.Dialer.Network = envy.Get("DIAL_NETWORK", dialNetworkAny)
+	cfg.Dialer.Resolver = envy.Get("DNS_RESOLVER", "")
+	if cfg.Dialer.Timeout, err = envDuration("DIAL_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.Dialer.FallbackDelay, err = envDuration("DIAL_FALLBACK_DELAY", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Dialer.APITimeout, err = envDuration("API_TIMEOUT", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.Dialer.TLSHandshakeTimeout, err = envDuration("TLS_HANDSHAKE_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.Dialer.ResponseHeaderTimeout, err = envDuration("RESPONSE_HEADER_TIMEOUT", 0); err != nil {
+		return nil, err
+	}
+	if err := cfg.Dialer.validate(); err != nil {
+		return nil, err
+	}
+	cfg.httpClient = newHTTPClient(&cfg.Dialer)
+
+	if cfg.ExpiryForecastDays, err = envInt("EXPIRY_FORECAST_DAYS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.LookbackDays, err = envInt("LOOKBACK_DAYS", 30); err != nil {
+		return nil, err
+	}
+	if cfg.VerifyDays, err = envInt("VERIFY_DAYS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.RetentionDays, err = envInt("RETENTION_DAYS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.RetentionMaxPerTopic, err = envInt("RETENTION_MAX_PER_TOPIC", 0); err != nil {
+		return nil, err
+	}
+	if cfg.RetentionDryRun, err = envBool("RETENTION_DRY_RUN", false); err != nil {
+		return nil, err
+	}
+	if cfg.DebugResponses, err = envBool("DEBUG_RESPONSES", false); err != nil {
+		return nil, err
+	}
+	if cfg.DebugResponsesTTL, err = envDuration("DEBUG_RESPONSES_TTL", 7*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.FeedEnabled, err = envBool("FEED_ENABLED", false); err != nil {
+		return nil, err
+	}
+	if cfg.FeedMaxItems, err = envInt("FEED_MAX_ITEMS", 50); err != nil {
+		return nil, err
+	}
+	if cfg.FeedLinkExpiry, err = envDuration("FEED_LINK_EXPIRY", 7*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.QueueBatch, err = envInt("QUEUE_BATCH", 5); err != nil {
+		return nil, err
+	}
+	if cfg.RunInterval, err = envDuration("RUN_INTERVAL", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ListSinceLastSuccess, err = envBool("LIST_SINCE_LAST_SUCCESS", false); err != nil {
+		return nil, err
+	}
+	if cfg.ListOverlap, err = envDuration("LIST_OVERLAP", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.QueueLease, err = envDuration("QUEUE_LEASE", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.QueueWorkTime, err = envDuration("QUEUE_WORK_TIME", 8*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.QueueMaxAttempts, err = envInt("QUEUE_MAX_ATTEMPTS", 5); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxMeetingsPerRun, err = envInt("MAX_MEETINGS_PER_RUN", 0); err != nil {
+		return nil, err
+	}
+	if cfg.SFTPPort, err = envInt("SFTP_PORT", 22); err != nil {
+		return nil, err
+	}
+	if cfg.TranscodeHeight, err = envInt("TRANSCODE_HEIGHT", 720); err != nil {
+		return nil, err
+	}
+	if cfg.TranscodeCRF, err = envInt("TRANSCODE_CRF", 28); err != nil {
+		return nil, err
+	}
+	cfg.Thumbnails, err = envBool("THUMBNAILS", false)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ThumbnailOffset, err = envInt("THUMBNAIL_OFFSET", 10); err != nil {
+		return nil, err
+	}
+	if cfg.ThumbnailWidth, err = envInt("THUMBNAIL_WIDTH", 320); err != nil {
+		return nil, err
+	}
+
+	for key, dst := range map[string]*int{
+		"API_CONCURRENCY":      &cfg.APIConcurrency,
+		"DOWNLOAD_CONCURRENCY": &cfg.DownloadConcurrency,
+		"UPLOAD_CONCURRENCY":   &cfg.UploadConcurrency,
+		"DELETE_CONCURRENCY":   &cfg.DeleteConcurrency,
+	} {
+		if *dst, err = envInt(key, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	// Upload chunks are sized once the number of concurrent uploads is
+	// known.
+	if chunk := strings.TrimSpace(envy.Get("UPLOAD_CHUNK_SIZE", "")); chunk == "" || strings.EqualFold(chunk, uploadChunkAuto) {
+		cfg.UploadChunkSize = autoUploadChunkSize(memoryLimit(cfg.MemoryLimit), cfg.UploadConcurrency)
+		cfg.uploadChunkAuto = true
+	} else {
+		chunkSize, err := envBytes("UPLOAD_CHUNK_SIZE", googleapi.DefaultUploadChunkSize)
+		if err != nil {
+			return nil, err
+		}
+		cfg.UploadChunkSize = int(chunkSize)
+	}
+
+	return cfg, nil
+}
+
+// validate checks that the job has everything it needs to run.
+func (cfg *config) validate() error {
+	if cfg.usesOAuth() {
+		if cfg.ZoomAccountID == "" || cfg.ZoomClientSecret == "" {
+			return errors.New("Please set ZOOM_ACCOUNT_ID, ZOOM_CLIENT_ID and ZOOM_CLIENT_SECRET to access the zoom API through OAuth.")
+		}
+	} else {
+		if cfg.ZoomAPIKey == "" {
+			return errors.New("Please set ZOOM_API_KEY to access the zoom API.")
+		}
+		if cfg.ZoomAPISecret == "" {
+			return errors.New("Please set ZOOM_API_SECRET to access the zoom API.")
+		}
+	}
+	if cfg.ZoomUserID == "" && len(cfg.ZoomGroupIDs) == 0 && cfg.InputFile == "" {
+		return errors.New("Please set ZOOM_USER_ID or ZOOM_GROUP_IDS from which to retreive recording.")
+	}
+	if cfg.Bucket == "" {
+		return errors.New("Please set GSTORAGE_BUCKET with a bucket as a backup destination")
+	}
+	if cfg.IndexEnabled && cfg.IndexFileName == "" {
+		return errors.New("INDEX_FILE_NAME cannot be empty while INDEX_ENABLED is set")
+	}
+	if cfg.IndexGroupBy != "" && cfg.IndexGroupBy != indexGroupByHost {
+		return fmt.Errorf("INDEX_GROUP_BY must be empty or %s", indexGroupByHost)
+	}
+	if cfg.RunInterval < 0 {
+		return errors.New("RUN_INTERVAL cannot be negative")
+	}
+	if cfg.ListSinceLastSuccess && cfg.StatusObject == "" {
+		return errors.New("LIST_SINCE_LAST_SUCCESS needs a STATUS_OBJECT")
+	}
+	if cfg.ExpiryForecastDays > 0 && cfg.ExpiryForecastObject == "" {
+		return errors.New("EXPIRY_FORECAST_OBJECT cannot be empty while EXPIRY_FORECAST_DAYS is set")
+	}
+	if cfg.ListOverlap < 0 {
+		return errors.New("LIST_OVERLAP cannot be negative")
+	}
+	if cfg.SentryDSN != "" {
+		if _, _, err := parseSentryDSN(cfg.SentryDSN); err != nil {
+			return err
+		}
+	}
+	if cfg.BigQueryAuditTable != "" {
+		if _, _, _, err := parseAuditTable(cfg.BigQueryAuditTable); err != nil {
+			return err
+		}
+	}
+	switch cfg.QueueMode {
+	case "":
+	case queueModeFirestore:
+		if cfg.QueueProject == "" || cfg.QueueCollection == "" {
+			return errors.New("Please set QUEUE_PROJECT and QUEUE_COLLECTION for QUEUE_MODE firestore")
+		}
+		if cfg.QueueBatch < 1 || cfg.QueueMaxAttempts < 1 || cfg.QueueLease <= 0 || cfg.QueueWorkTime <= 0 {
+			return errors.New("QUEUE_BATCH, QUEUE_LEASE, QUEUE_WORK_TIME and QUEUE_MAX_ATTEMPTS must be positive")
+		}
+	case queueModeTasks:
+		if cfg.QueueProject == "" || cfg.QueueCollection == "" || cfg.TasksQueue == "" || cfg.TasksURL == "" {
+			return errors.New("Please set QUEUE_PROJECT, QUEUE_COLLECTION, TASKS_QUEUE and TASKS_URL for QUEUE_MODE tasks")
+		}
+		if cfg.QueueLease <= 0 {
+			return errors.New("QUEUE_LEASE must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid QUEUE_MODE %q, must be %q or %q", cfg.QueueMode, queueModeFirestore, queueModeTasks)
+	}
+	if cfg.ChatExport && (cfg.ChatPrefix == "" || cfg.ChatLookbackDays < 1) {
+		return errors.New("CHAT_EXPORT needs a CHAT_PREFIX and a CHAT_LOOKBACK_DAYS of at least 1")
+	}
+	if cfg.SearchIndex && cfg.SearchIndexName == "" {
+		return errors.New("SEARCH_INDEX_NAME cannot be empty while SEARCH_INDEX is set")
+	}
+
+	naming, err := parseNamingTemplate(cfg.NamingTemplate)
+	if err != nil {
+		return err
+	}
+	cfg.naming = naming
+
+	location, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		return fmt.Errorf("invalid TIMEZONE: %w", err)
+	}
+	cfg.location = location
+
+	allowedHours, err := parseHourWindows(cfg.AllowedHours)
+	if err != nil {
+		return err
+	}
+	if len(allowedHours) > 0 && cfg.CheckpointObject == "" {
+		return errors.New("CHECKPOINT_OBJECT cannot be empty while ALLOWED_HOURS is set")
+	}
+	cfg.allowedHours = allowedHours
+
+	topicDisallowed, err := compileTopicCharset(cfg.TopicAllowedChars)
+	if err != nil {
+		return err
+	}
+	if topicDisallowed.MatchString(cfg.TopicReplacement) {
+		return errors.New("TOPIC_REPLACEMENT may only use characters allowed by TOPIC_ALLOWED_CHARS")
+	}
+	if cfg.UploadChunkSize < 0 || cfg.UploadChunkSize%googleapi.MinUploadChunkSize != 0 {
+		return errors.New("UPLOAD_CHUNK_SIZE must be 0 or a multiple of 256KiB")
+	}
+	if cfg.MaxFileSize < 0 || cfg.DownloadMinThroughput < 0 || cfg.UploadProgressEvery < 0 || cfg.DownloadProgressInterval < 0 {
+		return errors.New("MAX_FILE_SIZE, DOWNLOAD_MIN_THROUGHPUT, UPLOAD_PROGRESS_EVERY and DOWNLOAD_PROGRESS_INTERVAL cannot be negative")
+	}
+	if cfg.DownloadAuth != downloadAuthHeader && cfg.DownloadAuth != downloadAuthQuery {
+		return fmt.Errorf("DOWNLOAD_AUTH must be %s or %s", downloadAuthHeader, downloadAuthQuery)
+	}
+	if cfg.DownloadTimeout <= 0 {
+		return errors.New("DOWNLOAD_TIMEOUT must be positive")
+	}
+	if cfg.DownloadConnections < 1 || cfg.DownloadRangeSize < 1 {
+		return errors.New("DOWNLOAD_CONNECTIONS and DOWNLOAD_RANGE_SIZE must be positive")
+	}
+	if cfg.TopicMaxLength < 1 {
+		return errors.New("TOPIC_MAX_LENGTH must be at least 1")
+	}
+	cfg.topicDisallowed = topicDisallowed
+
+	for key, pattern := range map[string]struct {
+		source string
+		dst    **regexp.Regexp
+	}{
+		"TOPIC_INCLUDE_PATTERN": {cfg.TopicIncludePattern, &cfg.topicInclude},
+		"TOPIC_EXCLUDE_PATTERN": {cfg.TopicExcludePattern, &cfg.topicExclude},
+	} {
+		*pattern.dst = nil
+		if pattern.source == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern.source)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*pattern.dst = re
+	}
+	if cfg.CriticalTopicPattern != "" {
+		criticalTopic, err := regexp.Compile(cfg.CriticalTopicPattern)
+		if err != nil {
+			return fmt.Errorf("invalid CRITICAL_TOPIC_PATTERN: %w", err)
+		}
+		cfg.criticalTopic = criticalTopic
+	}
+	if cfg.AudioOnlyTopicPattern != "" {
+		audioOnlyTopic, err := regexp.Compile(cfg.AudioOnlyTopicPattern)
+		if err != nil {
+			return fmt.Errorf("invalid AUDIO_ONLY_TOPIC_PATTERN: %w", err)
+		}
+		cfg.audioOnlyTopic = audioOnlyTopic
+	}
+	switch cfg.CriticalVerify {
+	case criticalVerifyReread, criticalVerifyDownload:
+	default:
+		return fmt.Errorf("CRITICAL_VERIFY must be %q or %q", criticalVerifyReread, criticalVerifyDownload)
+	}
+
+	if len(cfg.RecordingSources) == 0 {
+		return errors.New("RECORDING_SOURCES cannot be empty")
+	}
+	for _, source := range cfg.RecordingSources {
+		switch source {
+		case recordingSourceMeeting, recordingSourceWebinar:
+		default:
+			return fmt.Errorf("RECORDING_SOURCES may only list %q and %q, not %q", recordingSourceMeeting, recordingSourceWebinar, source)
+		}
+	}
+
+	if cfg.KMSKeyName != "" && cfg.EncryptionKey != "" {
+		return errors.New("KMS_KEY_NAME and ENCRYPTION_KEY cannot be used together")
+	}
+	cfg.encryptionKey = nil
+	if cfg.EncryptionKey != "" {
+		key, err := parseEncryptionKey(cfg.EncryptionKey)
+		if err != nil {
+			return err
+		}
+		cfg.encryptionKey = key
+	}
+
+	switch cfg.StorageClass {
+	case "", "STANDARD", "NEARLINE", "COLDLINE", "ARCHIVE":
+	default:
+		return fmt.Errorf("STORAGE_CLASS must be STANDARD, NEARLINE, COLDLINE or ARCHIVE, not %q", cfg.StorageClass)
+	}
+
+	cfg.contentTypes = map[string]string{}
+	for fileType, contentType := range defaultContentTypes {
+		cfg.contentTypes[fileType] = contentType
+	}
+	for fileType, contentType := range cfg.ContentTypes {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("invalid CONTENT_TYPES entry for %s: %w", fileType, err)
+		}
+		cfg.contentTypes[strings.ToUpper(fileType)] = contentType
+	}
+
+	switch cfg.NotifyOn {
+	case notifyOnAlways, notifyOnErrors:
+	default:
+		return fmt.Errorf("NOTIFY_ON must be %q or %q", notifyOnAlways, notifyOnErrors)
+	}
+	for _, n := range cfg.Notifiers {
+		if _, ok := notifierTypes[n.Type]; !ok {
+			return fmt.Errorf("NOTIFIERS type must be one of %s, not %q", strings.Join(notifierTypeNames(), ", "), n.Type)
+		}
+		if n.URL == "" {
+			return fmt.Errorf("NOTIFIERS %s entry needs a URL", n.Type)
+		}
+	}
+	if (cfg.HookBeforeRun != "" || cfg.HookAfterMeeting != "" || cfg.HookAfterRun != "") && cfg.HookTimeout <= 0 {
+		return errors.New("HOOK_TIMEOUT must be positive")
+	}
+
+	switch cfg.Compression {
+	case compressionNone, compressionGzip, compressionZstd:
+	default:
+		return fmt.Errorf("COMPRESSION must be %q, %q or %q, not %q", compressionNone, compressionGzip, compressionZstd, cfg.Compression)
+	}
+
+	switch cfg.RecordingSettingsMode {
+	case settingsModeCheck, settingsModeEnforce:
+	default:
+		return fmt.Errorf("RECORDING_SETTINGS_MODE must be %q or %q", settingsModeCheck, settingsModeEnforce)
+	}
+
+	switch cfg.CanaryMode {
+	case "", canaryModeMeeting, canaryModeSynthetic:
+	default:
+		return fmt.Errorf("CANARY_MODE must be %q or %q", canaryModeMeeting, canaryModeSynthetic)
+	}
+	if cfg.FeedEnabled && !cfg.ManifestEnabled {
+		return errors.New("FEED_ENABLED requires MANIFEST_ENABLED")
+	}
+	if cfg.FeedLinkExpiry <= 0 || cfg.FeedLinkExpiry > 7*24*time.Hour {
+		return errors.New("FEED_LINK_EXPIRY must be between 0 and 168h")
+	}
+
+	if cfg.DebugResponses && (cfg.DebugResponsesPrefix == "" || cfg.DebugResponsesTTL <= 0) {
+		return errors.New("DEBUG_RESPONSES requires a DEBUG_RESPONSES_PREFIX and a positive DEBUG_RESPONSES_TTL")
+	}
+	switch cfg.ChecksumFiles {
+	case "", checksumFilesSidecar, checksumFilesSums:
+	default:
+		return fmt.Errorf("CHECKSUM_FILES must be %q or %q, not %q", checksumFilesSidecar, checksumFilesSums, cfg.ChecksumFiles)
+	}
+	if _, ok := checksumAlgorithms[cfg.ChecksumAlgorithm]; !ok {
+		return fmt.Errorf("CHECKSUM_ALGORITHM must be md5, sha1, sha256 or sha512, not %q", cfg.ChecksumAlgorithm)
+	}
+	if cfg.DriveSubject != "" && cfg.DriveCredentials == "" {
+		return errors.New("DRIVE_SUBJECT requires DRIVE_CREDENTIALS with a service account key")
+	}
+	if cfg.DropboxFolder != "" && (cfg.DropboxAppKey == "" || cfg.DropboxAppSecret == "" || cfg.DropboxRefreshToken == "") {
+		return errors.New("DROPBOX_FOLDER requires DROPBOX_APP_KEY, DROPBOX_APP_SECRET and DROPBOX_REFRESH_TOKEN")
+	}
+	if cfg.SFTPHost != "" && (cfg.SFTPUser == "" || cfg.SFTPPrivateKey == "" || cfg.SFTPHostKey == "") {
+		return errors.New("SFTP_HOST requires SFTP_USER, SFTP_PRIVATE_KEY and SFTP_HOST_KEY")
+	}
+	if cfg.MeetingBundle && cfg.MeetingBundleName == "" {
+		return errors.New("MEETING_BUNDLE_NAME cannot be empty while MEETING_BUNDLE is set")
+	}
+	if cfg.Transcode && cfg.TranscodeHeight < 1 {
+		return errors.New("TRANSCODE_HEIGHT must be at least 1")
+	}
+	if cfg.Thumbnails && cfg.ThumbnailOffset < 0 {
+		return errors.New("THUMBNAIL_OFFSET cannot be negative")
+	}
+	if cfg.Thumbnails && cfg.ThumbnailWidth < 1 {
+		return errors.New("THUMBNAIL_WIDTH must be at least 1")
+	}
+	switch cfg.Transcribe {
+	case "", transcribeGoogle, transcribeWhisper:
+	default:
+		return fmt.Errorf("TRANSCRIBE must be empty, %q or %q", transcribeGoogle, transcribeWhisper)
+	}
+	if cfg.Transcribe == transcribeGoogle && cfg.TranscribeLanguage == "" {
+		return errors.New("TRANSCRIBE_LANGUAGE cannot be empty with TRANSCRIBE=google")
+	}
+	if cfg.LookbackDays < 1 {
+		return errors.New("LOOKBACK_DAYS must be at least 1")
+	}
+	if cfg.DeleteMode != deleteModeMeeting && cfg.DeleteMode != deleteModeFiles {
+		return fmt.Errorf("DELETE_MODE must be %q or %q", deleteModeMeeting, deleteModeFiles)
+	}
+	if cfg.DeleteAfterDays < 0 {
+		return errors.New("DELETE_AFTER_DAYS must not be negative")
+	}
+	if cfg.DeleteFromZoom && cfg.DeleteAfterDays >= cfg.LookbackDays {
+		// Meetings would drop out of the listing before they are old
+		// enough to be deleted.
+		return errors.New("DELETE_AFTER_DAYS must be less than LOOKBACK_DAYS")
+	}
+	if cfg.VerifyDays < 0 {
+		return errors.New("VERIFY_DAYS must not be negative")
+	}
+	if cfg.RetentionDays < 0 || cfg.RetentionMaxPerTopic < 0 {
+		return errors.New("RETENTION_DAYS and RETENTION_MAX_PER_TOPIC must not be negative")
+	}
+	if cfg.RetentionAction != retentionActionDelete && cfg.RetentionAction != retentionActionArchive {
+		return fmt.Errorf("RETENTION_ACTION must be %q or %q", retentionActionDelete, retentionActionArchive)
+	}
+	if cfg.retentionEnabled() && !cfg.ManifestEnabled {
+		return errors.New("RETENTION_DAYS and RETENTION_MAX_PER_TOPIC require MANIFEST_ENABLED")
+	}
+	if cfg.IndexRetries < 0 {
+		return errors.New("INDEX_RETRIES must not be negative")
+	}
+	if cfg.MaxMeetingsPerRun < 0 {
+		return errors.New("MAX_MEETINGS_PER_RUN must not be negative")
+	}
+	for key, value := range map[string]int{
+		"API_CONCURRENCY":      cfg.APIConcurrency,
+		"DOWNLOAD_CONCURRENCY": cfg.DownloadConcurrency,
+		"UPLOAD_CONCURRENCY":   cfg.UploadConcurrency,
+		"DELETE_CONCURRENCY":   cfg.DeleteConcurrency,
+	} {
+		if value < 1 {
+			return fmt.Errorf("%s must be at least 1", key)
+		}
+	}
+
+	return nil
+}
+
+// clone returns a copy of the config that shares no maps or slices with the
+// original, so overriding a job never leaks into the defaults.
+func (cfg *config) clone() *config {
+	c := *cfg
+	c.ZoomGroupIDs = append([]string(nil), cfg.ZoomGroupIDs...)
+	c.RecordingSources = append([]string(nil), cfg.RecordingSources...)
+	c.RecordingViews = append([]string(nil), cfg.RecordingViews...)
+	c.RecordingViewPreference = append([]string(nil), cfg.RecordingViewPreference...)
+	c.AllowedHours = append([]string(nil), cfg.AllowedHours...)
+	c.Notifiers = append([]notifierConfig(nil), cfg.Notifiers...)
+	c.ExcludeRoleIDs = append([]string(nil), cfg.ExcludeRoleIDs...)
+	c.ExcludeUsers = append([]string(nil), cfg.ExcludeUsers...)
+	c.ExcludeGroupIDs = append([]string(nil), cfg.ExcludeGroupIDs...)
+	c.ExcludeAttributes = map[string]string{}
+	for k, v := range cfg.ExcludeAttributes {
+		c.ExcludeAttributes[k] = v
+	}
+	c.ObjectMetadata = map[string]string{}
+	for k, v := range cfg.ObjectMetadata {
+		c.ObjectMetadata[k] = v
+	}
+	c.ContentTypes = map[string]string{}
+	for k, v := range cfg.ContentTypes {
+		c.ContentTypes[k] = v
+	}
+	c.RecordingSettings = map[string]string{}
+	for k, v := range cfg.RecordingSettings {
+		c.RecordingSettings[k] = v
+	}
+	return &c
+}
+
+// objectName places name under the configured GSTORAGE_PATH prefix.
+func (cfg *config) objectName(name string) string {
+	if cfg.Prefix == "" {
+		return name
+	}
+	return path.Join(cfg.Prefix, name)
+}
+
+func envBool(key string, fallback bool) (bool, error) {
+	value := envy.Get(key, "")
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean for %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func envInt(key string, fallback int) (int, error) {
+	value := envy.Get(key, "")
+	if value == "" {
+		return fallback, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer for %s: %w", key, err)
+	}
+	return i, nil
+}
+
+// envBytes parses a byte size given as a plain number or with a binary
+// suffix: K, M, G or T, optionally followed by iB or B, e.g. 512K or 2GiB.
+func envBytes(key string, fallback int64) (int64, error) {
+	value := strings.TrimSpace(envy.Get(key, ""))
+	if value == "" {
+		return fallback, nil
+	}
+	upper := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(value), "B"), "I")
+	shift := uint(0)
+	if n := len(upper); n > 0 {
+		if i := strings.IndexByte("KMGT", upper[n-1]); i >= 0 {
+			shift = 10 * uint(i+1)
+			upper = upper[:n-1]
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(upper), 10, 64)
+	if err != nil || n > math.MaxInt64>>shift {
+		return 0, fmt.Errorf("invalid byte size for %s: %q", key, value)
+	}
+	return n << shift, nil
+}
+
+func envFloat(key string, fallback float64) (float64, error) {
+	value := envy.Get(key, "")
+	if value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number for %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func envDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := envy.Get(key, "")
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration for %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// envList reads a comma separated list, ignoring surrounding whitespace and
+// empty entries.
+func envList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(envy.Get(key, ""), ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// envMap reads a comma separated list of key=value pairs.
+func envMap(key string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, pair := range envList(key) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected key=value", key, pair)
+		}
+		m[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return m, nil
+}
+
+// envNotifiers reads a comma separated list of type=url pairs.
+func envNotifiers(key string) ([]notifierConfig, error) {
+	var notifiers []notifierConfig
+	for _, pair := range envList(key) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected type=url", key, pair)
+		}
+		notifiers = append(notifiers, notifierConfig{Type: strings.TrimSpace(parts[0]), URL: strings.TrimSpace(parts[1])})
+	}
+	return notifiers, nil
+}
+
+// listRange returns the date range whose recordings a run lists.
+func (cfg *config) listRange() (time.Time, time.Time) {
+	if !cfg.listFrom.IsZero() {
+		return cfg.listFrom, cfg.listTo
+	}
+	now := time.Now()
+	return now.AddDate(0, 0, -cfg.LookbackDays), now
+}
@@ -0,0 +1,34 @@
+package zoombackup
+
+import (
+	"mime"
+	"strings"
+)
+
+// defaultContentTypes maps the file types Zoom reports to the Content-Type
+// archived files are stored with, so browsers play recordings linked from the
+// index instead of downloading them.
+var defaultContentTypes = map[string]string{
+	"MP4":        "video/mp4",
+	"M4A":        "audio/m4a",
+	"TRANSCRIPT": "text/vtt; charset=utf-8",
+	"CC":         "text/vtt; charset=utf-8",
+	"VTT":        "text/vtt; charset=utf-8",
+	"CHAT":       "text/plain; charset=utf-8",
+	"TXT":        "text/plain; charset=utf-8",
+	"TIMELINE":   "application/json",
+	"JSON":       "application/json",
+	"CSV":        "text/csv; charset=utf-8",
+}
+
+// contentType is the Content-Type of an archived file of the Zoom file type,
+// taken from CONTENT_TYPES, the defaults or the file type as an extension.
+func (cfg *config) contentType(fileType string) string {
+	if contentType, ok := cfg.contentTypes[strings.ToUpper(fileType)]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension("." + strings.ToLower(fileType)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,134 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// control lets operators steer a deployed backup without redeploying or
+// touching environment variables. It is stored as a JSON object in the bucket
+// and read at the start of each run and again before every deletion.
+type control struct {
+	// Pause skips the job entirely while set.
+	Pause bool `json:"pause"`
+	// DeletionEnabled set to false archives as usual but never deletes
+	// recordings from Zoom.
+	DeletionEnabled bool      `json:"deletion_enabled"`
+	Reason          string    `json:"reason,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at,omitempty"`
+}
+
+func defaultControl() *control {
+	return &control{DeletionEnabled: true}
+}
+
+// loadControl reads the job's control object. A missing object means the job
+// runs normally.
+func loadControl(ctx context.Context, storageClient *storage.Client, cfg *config) (*control, error) {
+	c := defaultControl()
+	if cfg.ControlObject == "" {
+		return c, nil
+	}
+
+	r, err := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.ControlObject)).NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open control object: %w", err)
+	}
+	defer r.Close()
+
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read control object: %w", err)
+	}
+	if err := json.Unmarshal(raw, c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal control object: %w", err)
+	}
+	return c, nil
+}
+
+// deletionAllowed re-reads the control object so that disabling deletions
+// takes effect in the middle of a run. When the control object cannot be read
+// deletion is refused rather than risked.
+func (run *backupRun) deletionAllowed(ctx context.Context) bool {
+	c, err := loadControl(ctx, run.storageClient, run.cfg)
+	if err != nil {
+		log.Println("Not deleting,", err)
+		return false
+	}
+	if c.Pause || !c.DeletionEnabled {
+		log.Println("Not deleting, deletions are disabled by the control object", c.Reason)
+		return false
+	}
+	return true
+}
+
+// serveControl exposes the control object over HTTP: GET returns it and
+// POST/PUT merges the JSON body into it. The job is selected with ?job=name
+// when JOBS_CONFIG lists several.
+func serveControl(w http.ResponseWriter, r *http.Request, storageClient *storage.Client, jobs []*config) {
+	ctx := r.Context()
+
+	cfg := jobs[0]
+	if name := r.URL.Query().Get("job"); name != "" {
+		cfg = nil
+		for _, job := range jobs {
+			if job.JobName == name {
+				cfg = job
+			}
+		}
+		if cfg == nil {
+			http.Error(w, fmt.Sprintf("unknown job %q", name), http.StatusNotFound)
+			return
+		}
+	}
+	if cfg.ControlObject == "" {
+		http.Error(w, "CONTROL_OBJECT is not configured", http.StatusNotFound)
+		return
+	}
+
+	c, err := loadControl(ctx, storageClient, cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+			http.Error(w, "invalid control body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.UpdatedAt = time.Now().UTC()
+		obj := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.ControlObject))
+		if err := writeJSONObject(ctx, obj, c); err != nil {
+			http.Error(w, "failed to write control object: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Control for job %s updated: pause=%t deletion_enabled=%t %s", cfg.JobName, c.Pause, c.DeletionEnabled, c.Reason)
+	default:
+		w.Header().Set("Allow", "GET, POST, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		log.Println("Could not write response:", err)
+	}
+}
+
+func isControlRequest(r *http.Request) bool {
+	return strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/control")
+}
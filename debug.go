@@ -0,0 +1,111 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// redacted replaces secrets in debug responses.
+const redacted = "REDACTED"
+
+// sensitiveKeys are response fields that never end up in debug responses.
+var sensitiveKeys = map[string]bool{
+	"password":                true,
+	"passcode":                true,
+	"encrypted_password":      true,
+	"recording_play_passcode": true,
+	"access_token":            true,
+	"download_access_token":   true,
+	"token":                   true,
+}
+
+// saveDebugResponse stores a Zoom API response under DEBUG_RESPONSES_PREFIX
+// when DEBUG_RESPONSES is enabled, so what Zoom reported can be compared with
+// what was archived later on. Each run gets its own folder. Failures are
+// logged and never fail the run.
+func (run *backupRun) saveDebugResponse(ctx context.Context, name string, body []byte) {
+	if !run.cfg.DebugResponses {
+		return
+	}
+	sanitized, err := sanitizeResponse(body)
+	if err != nil {
+		log.Printf("Could not sanitize debug response %s: %v", name, err)
+		return
+	}
+	objectName := run.cfg.objectName(run.cfg.DebugResponsesPrefix + run.report.StartedAt.Format("20060102T150405Z") + "/" + name)
+	wc := run.storageClient.Bucket(run.cfg.Bucket).Object(objectName).NewWriter(ctx)
+	if err := writeJSON(wc, sanitized); err != nil {
+		log.Printf("Could not write debug response %s: %v", objectName, err)
+	}
+}
+
+// pruneDebugResponses deletes debug responses older than
+// DEBUG_RESPONSES_TTL.
+func (run *backupRun) pruneDebugResponses(ctx context.Context) error {
+	bucket := run.storageClient.Bucket(run.cfg.Bucket)
+	cutoff := time.Now().Add(-run.cfg.DebugResponsesTTL)
+	it := bucket.Objects(ctx, &storage.Query{Prefix: run.cfg.objectName(run.cfg.DebugResponsesPrefix)})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list debug responses: %w", err)
+		}
+		if attrs.Created.After(cutoff) {
+			continue
+		}
+		if err := bucket.Object(attrs.Name).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+			return fmt.Errorf("failed to delete debug response %s: %w", attrs.Name, err)
+		}
+	}
+}
+
+// isDebugResponse reports whether the object was written by
+// saveDebugResponse.
+func isDebugResponse(cfg *config, name string) bool {
+	return cfg.DebugResponsesPrefix != "" && strings.HasPrefix(name, cfg.objectName(cfg.DebugResponsesPrefix))
+}
+
+// sanitizeResponse decodes a JSON response with passcodes and tokens
+// redacted and query strings, which may carry access tokens, removed from
+// URLs.
+func sanitizeResponse(body []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	return sanitizeValue(v), nil
+}
+
+func sanitizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if sensitiveKeys[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = sanitizeValue(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = sanitizeValue(value)
+		}
+	case string:
+		if u, err := url.Parse(v); err == nil && u.Scheme != "" && u.Host != "" && u.RawQuery != "" {
+			u.RawQuery = ""
+			return u.String()
+		}
+	}
+	return v
+}
@@ -0,0 +1,139 @@
+package zoombackup
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiDeprecation is what Zoom announced about an endpoint through the
+// Deprecation and Sunset response headers (RFC 8594).
+type apiDeprecation struct {
+	// Endpoint is the method and path with IDs replaced, e.g.
+	// GET /v2/users/{id}/recordings.
+	Endpoint string `json:"endpoint"`
+	// Deprecation is when the endpoint was deprecated in RFC 3339, or "true"
+	// when Zoom did not say.
+	Deprecation string `json:"deprecation,omitempty"`
+	// Sunset is when the endpoint will stop working in RFC 3339.
+	Sunset    string    `json:"sunset,omitempty"`
+	Link      string    `json:"link,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Requests  int       `json:"requests"`
+}
+
+// deprecationTracker collects the deprecations announced by any response,
+// so each run can report the ones its requests ran into.
+type deprecationTracker struct {
+	mu      sync.Mutex
+	notices map[string]*apiDeprecation
+}
+
+var zoomDeprecations = &deprecationTracker{notices: map[string]*apiDeprecation{}}
+
+// deprecationTransport records deprecation headers of every response.
+type deprecationTransport struct {
+	base    http.RoundTripper
+	tracker *deprecationTracker
+}
+
+func (t *deprecationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.tracker.observe(req, resp)
+	}
+	return resp, err
+}
+
+var linkRelPattern = regexp.MustCompile(`<([^>]*)>\s*;[^,]*rel="?(deprecation|sunset)"?`)
+
+// observe records the deprecation announced by resp, if any, and logs the
+// first sighting of each endpoint.
+func (t *deprecationTracker) observe(req *http.Request, resp *http.Response) {
+	deprecation := resp.Header.Get("Deprecation")
+	sunset := resp.Header.Get("Sunset")
+	if deprecation == "" && sunset == "" {
+		return
+	}
+
+	endpoint := req.Method + " " + endpointPath(req.URL.Path)
+	now := time.Now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	notice, ok := t.notices[endpoint]
+	if !ok {
+		notice = &apiDeprecation{Endpoint: endpoint, FirstSeen: now}
+		t.notices[endpoint] = notice
+	}
+	notice.LastSeen = now
+	notice.Requests++
+	if deprecation != "" {
+		notice.Deprecation = parseDeprecationDate(deprecation)
+	}
+	if sunset != "" {
+		notice.Sunset = parseDeprecationDate(sunset)
+	}
+	for _, link := range resp.Header["Link"] {
+		if m := linkRelPattern.FindStringSubmatch(link); m != nil {
+			notice.Link = m[1]
+		}
+	}
+	if !ok {
+		log.Printf("WARNING Zoom API deprecation: endpoint=%q deprecation=%q sunset=%q link=%q",
+			notice.Endpoint, notice.Deprecation, notice.Sunset, notice.Link)
+	}
+}
+
+// since returns the deprecations seen at or after t, soonest sunset first.
+func (t *deprecationTracker) since(from time.Time) []apiDeprecation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var notices []apiDeprecation
+	for _, notice := range t.notices {
+		if !notice.LastSeen.Before(from) {
+			notices = append(notices, *notice)
+		}
+	}
+	sort.Slice(notices, func(a, b int) bool {
+		if notices[a].Sunset != notices[b].Sunset {
+			// Unknown sunsets go last.
+			return notices[b].Sunset == "" || (notices[a].Sunset != "" && notices[a].Sunset < notices[b].Sunset)
+		}
+		return notices[a].Endpoint < notices[b].Endpoint
+	})
+	return notices
+}
+
+// endpointPath replaces the IDs in a Zoom API path. Zoom paths alternate
+// between collections and IDs after the version, as in
+// /v2/users/{id}/recordings.
+func endpointPath(p string) string {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	for i := range parts {
+		if i > 0 && i%2 == 0 {
+			parts[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// parseDeprecationDate normalizes an HTTP date, or a Unix time written as
+// @1688169599, to RFC 3339. Other values, such as "true", are kept.
+func parseDeprecationDate(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "@") {
+		if sec, err := strconv.ParseInt(value[1:], 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC().Format(time.RFC3339)
+		}
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return value
+}
@@ -0,0 +1,144 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const driveFolderMimeType = "application/vnd.google-apps.folder"
+
+// driveMirror copies archived recordings into a Google Drive folder, usually
+// in a shared drive, below the same folders they have in the bucket.
+type driveMirror struct {
+	service *drive.Service
+	root    string
+	// mu serializes looking up and creating folders, so meetings archived
+	// concurrently do not create the same folder twice.
+	mu      sync.Mutex
+	folders map[string]string
+}
+
+// newDriveMirror authenticates with DRIVE_CREDENTIALS, a service account key
+// or OAuth authorized user JSON, or else the default credentials of the
+// environment. DRIVE_SUBJECT makes a service account act as that user.
+func newDriveMirror(ctx context.Context, cfg *config) (*driveMirror, error) {
+	var opt option.ClientOption
+	switch {
+	case cfg.DriveSubject != "":
+		conf, err := google.JWTConfigFromJSON([]byte(cfg.DriveCredentials), drive.DriveScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DRIVE_CREDENTIALS: %w", err)
+		}
+		conf.Subject = cfg.DriveSubject
+		opt = option.WithTokenSource(conf.TokenSource(ctx))
+	case cfg.DriveCredentials != "":
+		creds, err := google.CredentialsFromJSON(ctx, []byte(cfg.DriveCredentials), drive.DriveScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DRIVE_CREDENTIALS: %w", err)
+		}
+		opt = option.WithCredentials(creds)
+	default:
+		opt = option.WithScopes(drive.DriveScope)
+	}
+	service, err := drive.NewService(ctx, opt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Drive client: %w", err)
+	}
+	return &driveMirror{service: service, root: cfg.DriveFolderID, folders: map[string]string{}}, nil
+}
+
+func (d *driveMirror) String() string {
+	return "Drive"
+}
+
+func (d *driveMirror) exists(ctx context.Context, name string, size int64) (bool, error) {
+	folderID, err := d.folder(ctx, path.Dir(name))
+	if err != nil {
+		return false, err
+	}
+	existing, err := d.find(ctx, folderID, path.Base(name), "")
+	if err != nil {
+		return false, err
+	}
+	for _, f := range existing {
+		if f.Size == size {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *driveMirror) upload(ctx context.Context, name string, size int64, contentType string, r io.Reader) error {
+	folderID, err := d.folder(ctx, path.Dir(name))
+	if err != nil {
+		return err
+	}
+	_, err = d.service.Files.Create(&drive.File{Name: path.Base(name), Parents: []string{folderID}}).
+		Media(r, googleapi.ContentType(contentType)).
+		SupportsAllDrives(true).Fields("id").Context(ctx).Do()
+	return err
+}
+
+// folder returns the ID of the folder at dir below the root, creating the
+// missing folders along the way.
+func (d *driveMirror) folder(ctx context.Context, dir string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	parent := d.root
+	walked := ""
+	for _, name := range strings.Split(dir, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		walked = path.Join(walked, name)
+		if id, ok := d.folders[walked]; ok {
+			parent = id
+			continue
+		}
+		existing, err := d.find(ctx, parent, name, driveFolderMimeType)
+		if err != nil {
+			return "", err
+		}
+		if len(existing) > 0 {
+			parent = existing[0].Id
+		} else {
+			created, err := d.service.Files.Create(&drive.File{Name: name, MimeType: driveFolderMimeType, Parents: []string{parent}}).
+				SupportsAllDrives(true).Fields("id").Context(ctx).Do()
+			if err != nil {
+				return "", fmt.Errorf("failed to create folder %s: %w", walked, err)
+			}
+			parent = created.Id
+		}
+		d.folders[walked] = parent
+	}
+	return parent, nil
+}
+
+// find lists the files named name in the folder, only those of mimeType
+// when it is set.
+func (d *driveMirror) find(ctx context.Context, folderID, name, mimeType string) ([]*drive.File, error) {
+	q := fmt.Sprintf("name = '%s' and '%s' in parents and trashed = false", driveQuote(name), driveQuote(folderID))
+	if mimeType != "" {
+		q += fmt.Sprintf(" and mimeType = '%s'", mimeType)
+	}
+	list, err := d.service.Files.List().Q(q).Fields("files(id, size)").
+		SupportsAllDrives(true).IncludeItemsFromAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	return list.Files, nil
+}
+
+// driveQuote escapes s for a string literal of a Drive query.
+func driveQuote(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
@@ -0,0 +1,252 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	dropboxTokenURL   = "https://api.dropboxapi.com/oauth2/token"
+	dropboxAPIURL     = "https://api.dropboxapi.com/2/"
+	dropboxContentURL = "https://content.dropboxapi.com/2/"
+	// dropboxChunkSize is how much of a recording is sent per request of an
+	// upload session, and held in memory while it is sent.
+	dropboxChunkSize = 16 << 20
+)
+
+// dropboxMirror copies archived recordings into a Dropbox folder through
+// upload sessions, authenticated with a long-lived refresh token of the app.
+type dropboxMirror struct {
+	folder       string
+	appKey       string
+	appSecret    string
+	refreshToken string
+	// pathRoot selects a team space's namespace, so paths are relative to a
+	// team folder rather than the member's folder.
+	pathRoot   string
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+func newDropboxMirror(cfg *config) *dropboxMirror {
+	m := &dropboxMirror{
+		folder:       cfg.DropboxFolder,
+		appKey:       cfg.DropboxAppKey,
+		appSecret:    cfg.DropboxAppSecret,
+		refreshToken: cfg.DropboxRefreshToken,
+		httpClient:   cfg.client(),
+	}
+	if cfg.DropboxNamespaceID != "" {
+		m.pathRoot = dropboxArg(map[string]string{".tag": "namespace_id", "namespace_id": cfg.DropboxNamespaceID})
+	}
+	return m
+}
+
+func (m *dropboxMirror) String() string {
+	return "Dropbox"
+}
+
+func (m *dropboxMirror) exists(ctx context.Context, name string, size int64) (bool, error) {
+	var metadata struct {
+		Tag  string `json:".tag"`
+		Size int64  `json:"size"`
+	}
+	err := m.call(ctx, dropboxAPIURL+"files/get_metadata", map[string]string{"path": m.path(name)}, nil, &metadata)
+	var dbxErr *dropboxError
+	if errors.As(err, &dbxErr) && strings.HasPrefix(dbxErr.Summary, "path/not_found") {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return metadata.Tag == "file" && metadata.Size == size, nil
+}
+
+// upload sends the recording in chunks of an upload session, which has no
+// size limit, and commits it to name, replacing an earlier copy.
+func (m *dropboxMirror) upload(ctx context.Context, name string, size int64, contentType string, r io.Reader) error {
+	var session struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := m.call(ctx, dropboxContentURL+"files/upload_session/start", map[string]bool{"close": false}, bytes.NewReader(nil), &session); err != nil {
+		return fmt.Errorf("failed to start upload session: %w", err)
+	}
+	type cursor struct {
+		SessionID string `json:"session_id"`
+		Offset    int64  `json:"offset"`
+	}
+	offset := int64(0)
+	buf := make([]byte, dropboxChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			commit := map[string]interface{}{
+				"cursor": cursor{session.SessionID, offset},
+				"commit": map[string]interface{}{"path": m.path(name), "mode": "overwrite", "mute": true},
+			}
+			if err := m.call(ctx, dropboxContentURL+"files/upload_session/finish", commit, bytes.NewReader(buf[:n]), nil); err != nil {
+				return fmt.Errorf("failed to finish upload session: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		arg := map[string]interface{}{"cursor": cursor{session.SessionID, offset}}
+		if err := m.call(ctx, dropboxContentURL+"files/upload_session/append_v2", arg, bytes.NewReader(buf[:n]), nil); err != nil {
+			return fmt.Errorf("failed to upload at offset %d: %w", offset, err)
+		}
+		offset += int64(n)
+	}
+}
+
+func (m *dropboxMirror) path(name string) string {
+	return path.Join("/", m.folder, name)
+}
+
+// dropboxError is an error response of the Dropbox API.
+type dropboxError struct {
+	StatusCode int
+	Summary    string
+}
+
+func (e *dropboxError) Error() string {
+	return fmt.Sprintf("invalid response code: %d -- %s", e.StatusCode, e.Summary)
+}
+
+// call performs an RPC with arg as its JSON body, or with content a content
+// upload passing arg in the Dropbox-API-Arg header. The JSON response is
+// decoded into out when set.
+func (m *dropboxMirror) call(ctx context.Context, endpoint string, arg interface{}, content io.Reader, out interface{}) error {
+	token, err := m.token(ctx)
+	if err != nil {
+		return err
+	}
+	var req *http.Request
+	if content != nil {
+		req, err = http.NewRequestWithContext(ctx, "POST", endpoint, content)
+		if err == nil {
+			req.Header.Set("Content-Type", "application/octet-stream")
+			req.Header.Set("Dropbox-API-Arg", dropboxArg(arg))
+		}
+	} else {
+		payload, marshalErr := json.Marshal(arg)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		req, err = http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if m.pathRoot != "" {
+		req.Header.Set("Dropbox-API-Path-Root", m.pathRoot)
+	}
+	// Chunks can take longer than the client's timeout to send.
+	client := *m.httpClient
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform request: %w", err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		dbxErr := &dropboxError{StatusCode: resp.StatusCode, Summary: string(body)}
+		var apiErr struct {
+			ErrorSummary string `json:"error_summary"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.ErrorSummary != "" {
+			dbxErr.Summary = apiErr.ErrorSummary
+		}
+		return dbxErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+// token returns an access token, exchanging the refresh token for a new one
+// when the last one is about to expire.
+func (m *dropboxMirror) token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.accessToken != "" && time.Until(m.expiry) > 5*time.Minute {
+		return m.accessToken, nil
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {m.refreshToken},
+		"client_id":     {m.appKey},
+		"client_secret": {m.appSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", dropboxTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to perform Dropbox token request: %w", err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read Dropbox token response body: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("invalid Dropbox token response code: %d -- %s", resp.StatusCode, body)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Dropbox token response: %w", err)
+	}
+	m.accessToken = token.AccessToken
+	m.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return m.accessToken, nil
+}
+
+// dropboxArg encodes v for the Dropbox-API-Arg header, which only allows
+// ASCII, so other characters are escaped as in JSON strings.
+func dropboxArg(v interface{}) string {
+	raw, _ := json.Marshal(v)
+	var b strings.Builder
+	for _, r := range string(raw) {
+		switch {
+		case r < 0x80:
+			b.WriteRune(r)
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, `\u%04x\u%04x`, r1, r2)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// contentObject is the handle of an object holding meeting content, carrying
+// the customer-supplied ENCRYPTION_KEY when one is configured.
+func (run *backupRun) contentObject(name string) *storage.ObjectHandle {
+	obj := run.storageClient.Bucket(run.cfg.Bucket).Object(name)
+	if run.cfg.encryptionKey != nil {
+		obj = obj.Key(run.cfg.encryptionKey)
+	}
+	return obj
+}
+
+// contentWriter writes meeting content: recordings and the exports stored
+// next to them. Such objects are encrypted with KMS_KEY_NAME or
+// ENCRYPTION_KEY, while bookkeeping such as the manifest and the index keeps
+// the bucket's default encryption so it stays readable by anyone with access
+// to the bucket.
+func (run *backupRun) contentWriter(ctx context.Context, name string) *storage.Writer {
+	wc := run.contentObject(name).NewWriter(ctx)
+	wc.KMSKeyName = run.cfg.KMSKeyName
+	return wc
+}
+
+// meetingWriter writes content belonging to the meeting. With
+// OBJECT_CUSTOM_TIME the object's custom time is the meeting's start, so
+// lifecycle rules on customTime age recordings out by when the meeting
+// happened rather than when it was archived.
+func (run *backupRun) meetingWriter(ctx context.Context, mtg meeting, name string) *storage.Writer {
+	return run.meetingObjectWriter(ctx, mtg, run.contentObject(name))
+}
+
+// meetingObjectWriter is meetingWriter for obj, a handle of contentObject
+// that may carry preconditions.
+func (run *backupRun) meetingObjectWriter(ctx context.Context, mtg meeting, obj *storage.ObjectHandle) *storage.Writer {
+	wc := obj.NewWriter(ctx)
+	wc.KMSKeyName = run.cfg.KMSKeyName
+	if run.cfg.ObjectCustomTime {
+		if start, err := time.Parse(time.RFC3339, mtg.StartTime); err == nil {
+			wc.CustomTime = start
+		}
+	}
+	return wc
+}
+
+// parseEncryptionKey decodes a base64 encoded AES-256 key.
+func parseEncryptionKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("ENCRYPTION_KEY must be a base64 encoded 256 bit key")
+	}
+	return key, nil
+}
@@ -0,0 +1,110 @@
+package zoombackup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	eventDiscovered  = "discovered"
+	eventDownloading = "downloading"
+	eventDownloaded  = "downloaded"
+	eventUploaded    = "uploaded"
+	eventVerified    = "verified"
+	eventDeleted     = "deleted"
+	eventFailed      = "failed"
+)
+
+// event is one line of the NDJSON stream written to EVENTS_OUT so external
+// orchestrators can follow the pipeline as it happens.
+type event struct {
+	Time        time.Time `json:"time"`
+	Job         string    `json:"job,omitempty"`
+	Action      string    `json:"action"`
+	MeetingUUID string    `json:"meeting_uuid,omitempty"`
+	Topic       string    `json:"topic,omitempty"`
+	File        string    `json:"file,omitempty"`
+	FileID      string    `json:"file_id,omitempty"`
+	Object      string    `json:"object,omitempty"`
+	Bytes       int64     `json:"bytes,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// eventStream serializes events from concurrent workers. A nil stream drops
+// every event.
+type eventStream struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	c   io.Closer
+	// notify and parent are set on streams returned by with.
+	notify func(event)
+	parent *eventStream
+}
+
+// openEventStream writes events to stdout for "-", appends them to the file at
+// dest otherwise and returns nil when dest is empty.
+func openEventStream(dest string) (*eventStream, error) {
+	switch dest {
+	case "":
+		return nil, nil
+	case "-":
+		return &eventStream{enc: json.NewEncoder(os.Stdout)}, nil
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open events output: %w", err)
+	}
+	return &eventStream{enc: json.NewEncoder(f), c: f}, nil
+}
+
+// with returns a stream that passes every event to notify before writing it
+// to s.
+func (s *eventStream) with(notify func(event)) *eventStream {
+	return &eventStream{notify: notify, parent: s}
+}
+
+func (s *eventStream) emit(e event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if s.notify != nil {
+		s.notify(e)
+		s.parent.emit(e)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(e); err != nil {
+		log.Println("Could not write event:", err)
+	}
+}
+
+func (s *eventStream) Close() error {
+	if s == nil || s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
+
+// emit adds the job and meeting to e, records it in the meeting's timeline
+// and writes it to the run's event stream.
+func (run *backupRun) emit(action string, mtg meeting, e event) {
+	e.Time = time.Now().UTC()
+	e.Action = action
+	e.Job = run.cfg.JobName
+	e.MeetingUUID = mtg.ID
+	e.Topic = mtg.Topic
+	run.report.recordTimeline(e)
+	run.events.emit(e)
+	if action == eventUploaded {
+		run.notifyFileArchived(e)
+	}
+}
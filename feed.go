@@ -0,0 +1,128 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// generateFeed writes an RSS feed of the most recently archived files, taken
+// from the manifest. Links are signed when SIGNING_SERVICE_ACCOUNT is set and
+// are regenerated on every run, so they stay valid as long as the backup runs
+// more often than FEED_LINK_EXPIRY.
+func generateFeed(ctx context.Context, storageClient *storage.Client, cfg *config) error {
+	m, err := loadManifest(ctx, storageClient, cfg)
+	if err != nil {
+		return err
+	}
+	signer, err := newURLSigner(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	type feedFile struct {
+		meeting manifestMeeting
+		file    manifestFile
+	}
+	var files []feedFile
+	for _, mtg := range m.Meetings {
+		for _, file := range mtg.Files {
+			files = append(files, feedFile{meeting: mtg, file: file})
+		}
+	}
+	sort.SliceStable(files, func(a, b int) bool {
+		return files[a].file.ArchivedAt.After(files[b].file.ArchivedAt)
+	})
+	if len(files) > cfg.FeedMaxItems {
+		files = files[:cfg.FeedMaxItems]
+	}
+
+	indexURL, err := signer.objectURL(cfg.Bucket, cfg.objectName(cfg.IndexFileName), cfg.FeedLinkExpiry)
+	if err != nil {
+		return fmt.Errorf("failed to sign index URL: %w", err)
+	}
+	feed := rss{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         cfg.IndexTitle,
+			Link:          indexURL,
+			Description:   "Recently archived Zoom recordings",
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, f := range files {
+		link, err := signer.objectURL(cfg.Bucket, f.file.Object, cfg.FeedLinkExpiry)
+		if err != nil {
+			return fmt.Errorf("failed to sign URL for %s: %w", f.file.Object, err)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       fmt.Sprintf("%s (%s)", f.meeting.Topic, f.file.RecordingType),
+			Link:        link,
+			Description: fmt.Sprintf("%s recording of %s started %s, %s", f.file.FileType, f.meeting.Topic, f.file.RecordingStart, humanBytes(f.file.Size)),
+			GUID:        rssGUID{Value: f.file.URL},
+			PubDate:     f.file.ArchivedAt.Format(time.RFC1123Z),
+			Enclosure: rssEnclosure{
+				URL:    link,
+				Length: f.file.Size,
+				Type:   cfg.contentType(f.file.FileType),
+			},
+		})
+	}
+
+	buf := bytes.NewBufferString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return fmt.Errorf("failed to encode feed: %w", err)
+	}
+
+	wc := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.FeedFileName)).NewWriter(ctx)
+	wc.ContentType = "application/rss+xml; charset=utf-8"
+	if _, err := buf.WriteTo(wc); err != nil {
+		_ = wc.Close()
+		return fmt.Errorf("Could not write feed: %v", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("error closing feed: %w", err)
+	}
+	return nil
+}
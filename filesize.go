@@ -0,0 +1,69 @@
+package zoombackup
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// gcsMaxObjectSize is the largest object Cloud Storage accepts.
+const gcsMaxObjectSize = 5 << 40
+
+// checkFileSize rejects recordings that are known to be too large to archive
+// before any of their bytes are transferred.
+func checkFileSize(cfg *config, recording recordingFile) error {
+	if recording.FileSize > gcsMaxObjectSize {
+		return fmt.Errorf("%s is %s, more than Cloud Storage accepts in one object", recording.FileName(), humanBytes(recording.FileSize))
+	}
+	if cfg.MaxFileSize > 0 && recording.FileSize > cfg.MaxFileSize {
+		return fmt.Errorf("%s is %s, more than MAX_FILE_SIZE %s", recording.FileName(), humanBytes(recording.FileSize), humanBytes(cfg.MaxFileSize))
+	}
+	return nil
+}
+
+// uploadChunkSize is the resumable upload chunk for a file of the given size.
+// Each upload buffers one chunk in memory, so files known to be smaller than
+// UPLOAD_CHUNK_SIZE get a chunk just large enough to hold them and memory
+// stays bounded by UPLOAD_CHUNK_SIZE times UPLOAD_CONCURRENCY however large
+// the recordings are. An UPLOAD_CHUNK_SIZE of 0 uploads every file in a
+// single request that buffers nothing but cannot resume.
+func uploadChunkSize(cfg *config, size int64) int {
+	if cfg.UploadChunkSize == 0 || size <= 0 || size >= int64(cfg.UploadChunkSize) {
+		return cfg.UploadChunkSize
+	}
+	chunks := (size + googleapi.MinUploadChunkSize - 1) / googleapi.MinUploadChunkSize
+	return int(chunks) * googleapi.MinUploadChunkSize
+}
+
+// downloadTimeout gives large files the time they need at
+// DOWNLOAD_MIN_THROUGHPUT instead of one deadline for every file, so a slow
+// transfer fails the file rather than hanging the run.
+func downloadTimeout(cfg *config, size int64) time.Duration {
+	if size <= 0 || cfg.DownloadMinThroughput <= 0 {
+		return cfg.DownloadTimeout
+	}
+	return cfg.DownloadTimeout + time.Duration(size/cfg.DownloadMinThroughput)*time.Second
+}
+
+// uploadProgress logs the progress of an upload every UPLOAD_PROGRESS_EVERY
+// bytes. Resumable uploads report progress once per chunk, so the interval
+// cannot be finer than UPLOAD_CHUNK_SIZE.
+func uploadProgress(cfg *config, name string, size int64) func(int64) {
+	if cfg.UploadProgressEvery <= 0 {
+		return nil
+	}
+	var next int64
+	return func(written int64) {
+		if written < next {
+			return
+		}
+		next = written + cfg.UploadProgressEvery
+		if size > 0 {
+			log.Printf("Uploaded %s of %s of %s", humanBytes(written), humanBytes(size), name)
+		} else {
+			log.Printf("Uploaded %s of %s", humanBytes(written), name)
+		}
+	}
+}
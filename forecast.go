@@ -0,0 +1,103 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const zoomUserSettingsPath = "/users/%s/settings"
+
+type userRecordingSettings struct {
+	Recording struct {
+		AutoDeleteCMR     bool `json:"auto_delete_cmr"`
+		AutoDeleteCMRDays int  `json:"auto_delete_cmr_days"`
+	} `json:"recording"`
+}
+
+// expiryForecast lists recordings Zoom will auto-delete soon that are not in
+// the archive yet, most urgent first.
+type expiryForecast struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	WithinDays  int              `json:"within_days"`
+	Recordings  []expiringRecord `json:"recordings"`
+}
+
+type expiringRecord struct {
+	UserID      string    `json:"user_id"`
+	MeetingUUID string    `json:"meeting_uuid"`
+	Topic       string    `json:"topic"`
+	StartTime   string    `json:"start_time"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	DaysLeft    int       `json:"days_left"`
+	Files       int       `json:"files"`
+	Bytes       int64     `json:"bytes"`
+}
+
+// forecastExpiry checks each user's cloud recording retention setting and
+// writes a report of listed meetings that Zoom will delete within
+// EXPIRY_FORECAST_DAYS but that the manifest does not know about yet.
+func (run *backupRun) forecastExpiry(ctx context.Context, meetings []meeting) error {
+	cfg := run.cfg
+	m, err := loadManifest(ctx, run.storageClient, cfg)
+	if err != nil {
+		return err
+	}
+
+	retention := map[string]int{}
+	for _, mtg := range meetings {
+		if _, ok := retention[mtg.UserID]; ok {
+			continue
+		}
+		settings := &userRecordingSettings{}
+		if err := run.zoom.getJSON(ctx, fmt.Sprintf(zoomUserSettingsPath, url.PathEscape(mtg.UserID)), settings); err != nil {
+			return fmt.Errorf("failed to fetch recording settings of %s: %w", mtg.UserID, err)
+		}
+		retention[mtg.UserID] = 0
+		if settings.Recording.AutoDeleteCMR {
+			retention[mtg.UserID] = settings.Recording.AutoDeleteCMRDays
+		}
+	}
+
+	now := time.Now().UTC()
+	horizon := now.AddDate(0, 0, cfg.ExpiryForecastDays)
+	forecast := expiryForecast{GeneratedAt: now, WithinDays: cfg.ExpiryForecastDays, Recordings: []expiringRecord{}}
+	for _, mtg := range meetings {
+		days := retention[mtg.UserID]
+		if days <= 0 || m.meetingIndex(mtg.ID) >= 0 {
+			continue
+		}
+		start, err := time.Parse(time.RFC3339, mtg.StartTime)
+		if err != nil {
+			continue
+		}
+		expiresAt := start.AddDate(0, 0, days)
+		if expiresAt.After(horizon) {
+			continue
+		}
+		forecast.Recordings = append(forecast.Recordings, expiringRecord{
+			UserID:      mtg.UserID,
+			MeetingUUID: mtg.ID,
+			Topic:       mtg.Topic,
+			StartTime:   mtg.StartTime,
+			ExpiresAt:   expiresAt,
+			DaysLeft:    int(expiresAt.Sub(now).Hours() / 24),
+			Files:       len(mtg.Files),
+			Bytes:       meetingSize(mtg),
+		})
+	}
+	sort.SliceStable(forecast.Recordings, func(a, b int) bool {
+		return forecast.Recordings[a].ExpiresAt.Before(forecast.Recordings[b].ExpiresAt)
+	})
+
+	if len(forecast.Recordings) > 0 {
+		log.Printf("%d unarchived meetings will be auto-deleted by Zoom within %d days, the first on %s",
+			len(forecast.Recordings), cfg.ExpiryForecastDays, forecast.Recordings[0].ExpiresAt.Format(ymdFormat))
+	}
+
+	obj := run.storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.ExpiryForecastObject))
+	return writeJSONObject(ctx, obj, forecast)
+}
@@ -1,327 +1,1196 @@
 package zoombackup
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log"
-	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/storage"
-	"github.com/dgrijalva/jwt-go"
-	"github.com/gobuffalo/envy"
-	"google.golang.org/api/iterator"
 )
 
 const (
-	zoomRecordingsURL       = "https://api.zoom.us/v2/users/%s/recordings?from=%s"
-	zoomDeleteRecordingsURL = "https://api.zoom.us/v2/meetings/%s/recordings"
-	ymdFormat               = "2006-01-02"
-	tokenExpiresIn          = 35 * time.Minute
-	dateFormatFrom          = "2006-01-02"
-	dateFormatTo            = "01-02-2006" // MM-DD-YYYY
-	dateLength              = 10
+	zoomRecordingsPath        = "/users/%s/recordings?page_size=300&from=%s&to=%s"
+	zoomMeetingRecordingsPath = "/meetings/%s/recordings"
+	// zoomDeleteRecordingFilePath deletes one file of a meeting's recordings.
+	zoomDeleteRecordingFilePath = "/meetings/%s/recordings/%s"
+	ymdFormat                   = "2006-01-02"
+	tokenExpiresIn              = 35 * time.Minute
+	dateFormatFrom              = "2006-01-02"
+	dateFormatTo                = "01-02-2006" // MM-DD-YYYY
+	dateLength                  = 10
+	downloadRetryBackoff        = 5 * time.Second
+	// shutdownGracePeriod is how long a cancelled run may take to record
+	// what it archived.
+	shutdownGracePeriod = 30 * time.Second
 )
 
-type recordingListResponse struct {
-	Meetings []struct {
-		ID             string          `json:"uuid"`
-		Topic          string          `json:"topic"`
-		StartTime      string          `json:"start_time"`
-		RecordingFiles []recordingFile `json:"recording_files"`
-	} `json:"meetings"`
+// listedMeeting is a meeting as Zoom lists it among its recordings.
+type listedMeeting struct {
+	ID             string          `json:"uuid"`
+	Type           int             `json:"type"`
+	Topic          string          `json:"topic"`
+	StartTime      string          `json:"start_time"`
+	Duration       int             `json:"duration"`
+	HostID         string          `json:"host_id"`
+	HostEmail      string          `json:"host_email"`
+	ShareURL       string          `json:"share_url"`
+	Password       string          `json:"password"`
+	PlayPasscode   string          `json:"recording_play_passcode"`
+	RecordingFiles []recordingFile `json:"recording_files"`
 }
 
 type meeting struct {
 	ID        string          `json:"id"`
+	Type      int             `json:"type"`
 	Topic     string          `json:"topic"`
 	StartTime string          `json:"start_time"`
+	Duration  int             `json:"duration"`
+	HostID    string          `json:"host_id"`
+	HostEmail string          `json:"host_email"`
+	ShareURL  string          `json:"share_url"`
+	UserID    string          `json:"user_id"`
 	Files     []recordingFile `json:"files"`
+	// Passcode is the passcode viewers of the recordings enter, and
+	// PlayPasscode the encoded form Zoom accepts in play URLs.
+	Passcode     string `json:"passcode,omitempty"`
+	PlayPasscode string `json:"recording_play_passcode,omitempty"`
+	// HostName is looked up with RESOLVE_HOSTS.
+	HostName string `json:"host_name,omitempty"`
+	// Audio are the meeting's M4A audio recordings, archived instead of
+	// Files with AUDIO_ONLY_TOPIC_PATTERN.
+	Audio []recordingFile `json:"audio,omitempty"`
+	// Chat are the meeting's in-meeting chat TXT files, exported with
+	// MEETING_CHAT_EXPORT.
+	Chat []recordingFile `json:"chat,omitempty"`
+	// HasTranscript is set when Zoom transcribed the meeting.
+	HasTranscript bool `json:"has_transcript,omitempty"`
+	// Processing is set while Zoom is still processing any of the
+	// meeting's recording files.
+	Processing bool `json:"processing,omitempty"`
+	// Zoom is the meeting exactly as returned by the recordings API.
+	Zoom json.RawMessage `json:"zoom,omitempty"`
 }
 
 type recordingFile struct {
+	ID             string `json:"id"`
 	RecordingStart string `json:"recording_start"`
 	FileType       string `json:"file_type"`
 	DownloadURL    string `json:"download_url"`
 	RecordingType  string `json:"recording_type"`
 	Status         string `json:"status"`
+	FileSize       int64  `json:"file_size"`
+	// PlayURL opens the file in Zoom's web player.
+	PlayURL string `json:"play_url,omitempty"`
+	// Segment numbers the files of a meeting that share start time, type
+	// and file type, from 2 for the second one, so they get names of their
+	// own. It is 0 for every other file.
+	Segment int `json:"segment,omitempty"`
+	// extractAudio archives only the audio track of this MP4 file.
+	extractAudio bool
 }
 
 func ZoomBackup(w http.ResponseWriter, r *http.Request) {
-	zoomAPIKey := envy.Get("ZOOM_API_KEY", "")
-	if zoomAPIKey == "" {
-		log.Fatal("Please set ZOOM_API_KEY to access the zoom API.")
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	events, err := openEventStream(cfg.EventsOut)
+	if err != nil {
+		log.Fatal(err)
 	}
+	defer events.Close()
+
+	// The request's context ends when the function times out or the caller
+	// goes away.
+	ctx := r.Context()
 
-	zoomAPISecret := envy.Get("ZOOM_API_SECRET", "")
-	if zoomAPISecret == "" {
-		log.Fatal("Please set ZOOM_API_SECRET to access the zoom API.")
+	storageClient, err := storage.NewClient(ctx)
+	if err != nil {
+		log.Fatal("Error creating new storage client ", err)
 	}
 
-	zoomJWT, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(tokenExpiresIn).Unix(),
-		Issuer:    zoomAPIKey,
-	}).SignedString([]byte(zoomAPISecret))
+	jobs, err := loadJobs(ctx, storageClient, cfg)
 	if err != nil {
-		err = fmt.Errorf("failed to sign JWT: %w", err)
 		log.Fatal(err)
 	}
 
-	zoomUserID := envy.Get("ZOOM_USER_ID", "")
-	if zoomUserID == "" {
-		log.Fatal("Please set ZOOM_USER_ID from which to retreive recording.")
+	if isControlRequest(r) {
+		serveControl(w, r, storageClient, jobs)
+		return
 	}
+	if isStatusRequest(r) {
+		serveStatus(w, r, storageClient, jobs)
+		return
+	}
+	if isTaskRequest(r) {
+		serveTask(w, r, storageClient, jobs, events)
+		return
+	}
+	// ?queue=work archives meetings queued in QUEUE_MODE.
+	if r.URL.Query().Get("queue") == queueWork {
+		for _, job := range jobs {
+			job.queueWorker = true
+		}
+	}
+	// ?meeting=UUID backs up that meeting right away, with ?job=NAME when
+	// there are several jobs.
+	if uuid := r.URL.Query().Get("meeting"); uuid != "" {
+		job, err := selectJob(jobs, r.URL.Query().Get("job"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job.meetingUUID = uuid
+		jobs = []*config{job}
+	}
+
+	reports := runJobs(ctx, storageClient, jobs, events)
 
-	bucket := envy.Get("GSTORAGE_BUCKET", "")
-	if bucket == "" {
-		log.Fatal("Please set GSTORAGE_BUCKET with a bucket as a backup destination")
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		log.Println("Could not write response:", err)
 	}
+}
 
-	ctx := context.Background()
+// runJobs runs the jobs one after another and returns their reports.
+func runJobs(ctx context.Context, storageClient *storage.Client, jobs []*config, events *eventStream) []*runReport {
+	var reports []*runReport
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			log.Println("Not starting job", job.JobName, "because the run was cancelled")
+			break
+		}
+		pingHealthcheck(job.client(), job.HealthcheckStartURL, "")
+		report := runBackup(ctx, storageClient, job, events)
+		report.log()
+		notifyRun(storageClient, job, report)
+		runAfterRunHook(job, report)
+		recordStatus(storageClient, job, report)
+		pingRunHealthcheck(job, report)
+		reports = append(reports, report)
+	}
+	return reports
+}
 
-	storageClient, err := storage.NewClient(ctx)
+// backupRun holds the state shared by everything a single job does during a
+// run.
+type backupRun struct {
+	cfg           *config
+	storageClient *storage.Client
+	zoom          *zoomClient
+	limits        *stageLimits
+	metrics       *transferMetrics
+	archived      *manifestRecorder
+	report        *runReport
+	events        *eventStream
+	sentry        *sentryClient
+	audit         *auditLog
+	window        *windowState
+	// queue holds the meetings of QUEUE_MODE, nil without it.
+	queue *meetingQueue
+	// tasks receives the files of QUEUE_MODE tasks, nil without it.
+	tasks *taskQueue
+	// mirrors receive copies of the archived recordings.
+	mirrors []mirror
+	// canaryFailed withholds every deletion of the run. It is only written
+	// before meetings are processed concurrently.
+	canaryFailed bool
+	// checksumsMu serializes updates of the SUMS files of CHECKSUM_FILES.
+	checksumsMu sync.Mutex
+}
+
+// runBackup archives the recordings of every user selected by the job and
+// returns the job's report.
+func runBackup(ctx context.Context, storageClient *storage.Client, cfg *config, events *eventStream) *runReport {
+	report := newRunReport(cfg.JobName)
+	report.Policy = cfg.policy
+	defer report.finish()
+	sentry := newSentryClient(cfg)
+	defer sentry.flush()
+	abort := func(err error) {
+		log.Println(err)
+		report.abort(err)
+		sentry.captureFatal(err)
+	}
+
+	ctrl, err := loadControl(ctx, storageClient, cfg)
 	if err != nil {
-		log.Fatal("Error creating new storage client ", err)
+		err = fmt.Errorf("failed to load control object: %w", err)
+		abort(err)
+		return report
+	}
+	if ctrl.Pause {
+		log.Println("Job", cfg.JobName, "is paused by the control object", ctrl.Reason)
+		report.Paused = true
+		return report
+	}
+	if !cfg.withinAllowedHours(time.Now()) {
+		log.Println("Job", cfg.JobName, "is outside ALLOWED_HOURS")
+		report.OutsideWindow = true
+		return report
+	}
+	notifyStart(storageClient, cfg, report)
+	// The files of QUEUE_MODE tasks are part of the run that fanned them out,
+	// which ran the hook.
+	if cfg.HookBeforeRun != "" && cfg.fileTask == nil {
+		if err := runHook(ctx, cfg, hookBeforeRun, cfg.HookBeforeRun, hookContext{}); err != nil {
+			abort(fmt.Errorf("failed to run the before run hook: %w", err))
+			return report
+		}
 	}
 
-	meetings, err := fetchRecordings(zoomJWT, zoomUserID)
+	zoom, err := newZoomClient(ctx, cfg)
 	if err != nil {
-		err = fmt.Errorf("failed to fetch recordings: %w", err)
-		log.Fatal(err)
+		abort(err)
+		return report
 	}
+	// Every request of the run to the Zoom API shares API_CONCURRENCY.
+	limits := newStageLimits(cfg)
+	zoom.api = limits.api
 
-	for _, meeting := range meetings {
-		for _, recording := range meeting.Files {
-			fileName := recording.FileName()
-			log.Println("Requesting", fileName)
-			body, err := requestRecordingFile(recording.DownloadURL, zoomJWT)
-			if err != nil {
-				err = fmt.Errorf("failed to request download file: %w", err)
-				log.Println(err)
-				continue
-			}
+	run := &backupRun{
+		cfg:           cfg,
+		storageClient: storageClient,
+		zoom:          zoom,
+		limits:        limits,
+		metrics:       &transferMetrics{},
+		archived:      &manifestRecorder{},
+		report:        report,
+		events:        events,
+		sentry:        sentry,
+		audit:         newAuditLog(cfg),
+		window:        &windowState{resume: &checkpoint{}, deferred: map[string][]string{}},
+	}
+	if run.mirrors, err = newMirrors(ctx, cfg); err != nil {
+		abort(err)
+		return report
+	}
+	defer run.closeMirrors()
+	if len(cfg.allowedHours) > 0 {
+		if run.window.resume, err = loadCheckpoint(ctx, storageClient, cfg); err != nil {
+			abort(err)
+			return report
+		}
+	}
 
-			defer body.Close()
+	if cfg.QueueMode != "" {
+		if run.queue, err = newMeetingQueue(ctx, cfg); err != nil {
+			abort(err)
+			return report
+		}
+	}
+	if cfg.QueueMode == queueModeTasks {
+		if run.tasks, err = newTaskQueue(ctx); err != nil {
+			abort(err)
+			return report
+		}
+	}
 
-			fileSaveName, err := getFileSaveName(meeting.StartTime, meeting.Topic, recording.FileName())
-			if err != nil {
-				err = fmt.Errorf("failed to get file save name: %w", err)
-				log.Println(err)
-				continue
-			}
-			log.Println("Getting writer", fileSaveName)
+	if cfg.DebugResponses {
+		if err := run.pruneDebugResponses(ctx); err != nil {
+			log.Println("Could not prune debug responses:", err)
+		}
+	}
 
-			sw := storageWriter(ctx, storageClient, bucket, fileSaveName)
-			log.Println("Copying", fileName)
-			if _, err := io.Copy(sw, body); err != nil {
-				err = fmt.Errorf("Could not write file: %v", err)
-				log.Println(err)
-				continue
-			}
+	if cfg.queueWorker {
+		if cfg.QueueMode != queueModeFirestore {
+			abort(errors.New("QUEUE_MODE must be firestore to work the queue"))
+			return report
+		}
+		if err := run.workQueue(ctx); err != nil {
+			abort(err)
+		}
+	} else if cfg.fileTask != nil {
+		if err := run.runFileTask(ctx); err != nil {
+			abort(err)
+		}
+	} else if !run.discoverAndArchive(ctx, abort) {
+		return report
+	}
 
-			log.Println("Closing", fileName)
-			if err := sw.Close(); err != nil {
-				err = fmt.Errorf("Could not put file: %v", err)
-				log.Println(err)
-				continue
-			}
-			log.Println("Finished", recording.FileName())
+	if cfg.ChatExport && cfg.InputFile == "" && !cfg.queueWorker && cfg.fileTask == nil && !cfg.trash && ctx.Err() == nil {
+		if err := run.exportChats(ctx); err != nil {
+			err = fmt.Errorf("Could not export chats: %v", err)
+			log.Println(err)
+			report.fail(err)
 		}
+	}
 
-		log.Println("Deleting recordings for", meeting.ID)
-		err = deleteMeetingRecordings(zoomJWT, meeting.ID)
+	if ctx.Err() != nil {
+		log.Println("Job", cfg.JobName, "was cancelled, saving what was archived")
+		report.cancelled()
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), shutdownGracePeriod)
+		defer cancel()
+	}
+
+	if err := run.audit.flush(ctx); err != nil {
+		err = fmt.Errorf("Could not write audit log: %v", err)
+		log.Println(err)
+		report.fail(err)
+	}
+
+	if len(cfg.allowedHours) > 0 {
+		if err := run.saveCheckpoint(ctx); err != nil {
+			err = fmt.Errorf("Could not save checkpoint: %v", err)
+			log.Println(err)
+			report.fail(err)
+		}
+	}
+
+	if cfg.ManifestEnabled {
+		if err := updateManifest(ctx, storageClient, cfg, run.archived); err != nil {
+			err = fmt.Errorf("Could not update manifest: %v", err)
+			log.Println(err)
+			report.fail(err)
+		}
+	}
+
+	if cfg.MetricsEnabled {
+		if err := saveTransferMetrics(ctx, storageClient, cfg, run.metrics); err != nil {
+			err = fmt.Errorf("Could not save transfer metrics: %v", err)
+			log.Println(err)
+			report.fail(err)
+		}
+	}
+
+	// Cloud Tasks and queue workers each archive a share of the job, many at
+	// once, so verifying, retention and the feed and index are left to the
+	// runs listing the job.
+	if cfg.queueWorker || cfg.fileTask != nil {
+		return report
+	}
+
+	if cfg.VerifyDays > 0 && ctx.Err() == nil {
+		problems, err := verifyArchive(ctx, storageClient, cfg, verifyOptions{days: cfg.VerifyDays, out: log.Writer()})
 		if err != nil {
+			err = fmt.Errorf("Could not verify the archive: %v", err)
 			log.Println(err)
+			report.fail(err)
 		}
+		report.verified(problems)
 	}
-	if err := generateURLSListHTML(ctx, storageClient, bucket); err != nil {
-		err = fmt.Errorf("Could not generate html file: %v", err)
-		log.Println(err)
+
+	if cfg.retentionEnabled() && ctx.Err() == nil {
+		pruned, err := applyRetention(ctx, storageClient, cfg, cfg.RetentionDryRun)
+		if err != nil {
+			err = fmt.Errorf("Could not apply retention: %v", err)
+			log.Println(err)
+			report.fail(err)
+		}
+		report.objectsPruned(pruned)
 	}
-}
 
-func (f recordingFile) FileName() string {
-	return fmt.Sprintf(
-		"%s-%s.%s",
-		f.RecordingStart,
-		f.RecordingType,
-		strings.ToLower(f.FileType),
-	)
+	if cfg.FeedEnabled {
+		if err := generateFeed(ctx, storageClient, cfg); err != nil {
+			err = fmt.Errorf("Could not generate feed: %v", err)
+			log.Println(err)
+			report.fail(err)
+		}
+	}
+
+	if cfg.IndexEnabled {
+		if err := generateURLSListHTML(ctx, storageClient, cfg); err != nil {
+			err = fmt.Errorf("Could not generate html file: %v", err)
+			log.Println(err)
+			report.indexFailed(err)
+		}
+	}
+
+	return report
 }
 
-func requestRecordingFile(fileURL, zoomJWT string) (io.ReadCloser, error) {
-	recURL := fileURL + "?access_token=" + zoomJWT
-	req, err := http.NewRequest("GET", recURL, nil)
+// discoverMeetings lists the recordings of every user selected by the job, or
+// replays the meetings from INPUT_FILE instead of asking Zoom, and keeps the
+// kinds of recordings selected by RECORDING_SOURCES and the topics selected
+// by TOPIC_INCLUDE_PATTERN and TOPIC_EXCLUDE_PATTERN, at most
+// MAX_MEETINGS_PER_RUN of them, reduced to the RECORDING_VIEWS and to their
+// audio with AUDIO_ONLY_TOPIC_PATTERN.
+func (run *backupRun) discoverMeetings(ctx context.Context) ([]meeting, error) {
+	meetings, err := run.listMeetings(ctx)
 	if err != nil {
-		err = fmt.Errorf("failed to create new HTTP request for recording download: %w", err)
 		return nil, err
 	}
-	req.Header.Add("Accept", "application/json")
-	resp, err := defaultHTTPClient.Do(req)
+	meetings, postponed := capMeetings(selectAudio(run.cfg, selectViews(run.cfg, filterTopics(run.cfg, filterSources(meetings, run.cfg.RecordingSources)))), run.cfg.MaxMeetingsPerRun)
+	if len(postponed) > 0 {
+		log.Printf("Postponing %d meetings to later runs (MAX_MEETINGS_PER_RUN=%d)", len(postponed), run.cfg.MaxMeetingsPerRun)
+		run.report.meetingsPostponed(postponed)
+	}
+	if run.cfg.ResolveHosts {
+		run.resolveHosts(ctx, meetings)
+	}
+	return meetings, nil
+}
+
+func (run *backupRun) listMeetings(ctx context.Context) ([]meeting, error) {
+	if run.cfg.InputFile != "" {
+		return loadInputMeetings(ctx, run.storageClient, run.cfg.InputFile)
+	}
+	if run.cfg.meetingUUID != "" {
+		mtg, body, err := run.zoom.getMeetingRecordings(ctx, run.cfg.meetingUUID)
+		if body != nil {
+			run.saveDebugResponse(ctx, "recordings/"+url.PathEscape(run.cfg.meetingUUID)+".json", body)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch recordings of meeting %s: %w", run.cfg.meetingUUID, err)
+		}
+		run.report.Users = 1
+		return []meeting{mtg}, nil
+	}
+
+	userIDs, err := resolveUserIDs(ctx, run.zoom, run.cfg)
 	if err != nil {
-		err = fmt.Errorf("failed to perform request to download recording: %w", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to resolve users: %w", err)
 	}
+	run.report.Users = len(userIDs)
 
-	if resp.StatusCode/200 != 1 {
-		err = fmt.Errorf("invalid recording download response code: %d", resp.StatusCode)
-		return nil, err
+	if len(run.cfg.RecordingSettings) > 0 {
+		run.checkRecordingSettings(ctx, userIDs)
+	}
+
+	oldest, newest := run.cfg.listRange()
+	// Runs narrowed to a range, the trash or some users leave the rest of
+	// the window unlisted.
+	if run.cfg.listFrom.IsZero() && !run.cfg.trash && len(run.cfg.triggerUsers) == 0 {
+		if run.cfg.ListSinceLastSuccess {
+			oldest = run.listSince(ctx, oldest)
+		}
+		run.report.windowListed()
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var meetings []meeting
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			var raw func(from time.Time, page int, body []byte)
+			if run.cfg.DebugResponses {
+				raw = func(from time.Time, page int, body []byte) {
+					name := "recordings/" + url.PathEscape(userID) + "-" + from.Format(ymdFormat)
+					if page > 0 {
+						name += fmt.Sprintf("-%d", page+1)
+					}
+					run.saveDebugResponse(ctx, name+".json", body)
+				}
+			}
+			userMeetings, err := run.zoom.listRecordingsBetween(ctx, userID, run.cfg.trash, oldest, newest, raw)
+			if err != nil {
+				err = fmt.Errorf("failed to fetch recordings for %s: %w", userID, err)
+				log.Println(err)
+				run.report.fail(err)
+				return
+			}
+			for i := range userMeetings {
+				userMeetings[i].UserID = userID
+			}
+			mu.Lock()
+			meetings = append(meetings, userMeetings...)
+			mu.Unlock()
+		}(userID)
 	}
+	wg.Wait()
 
-	return resp.Body, nil
+	return meetings, nil
 }
 
-func fetchRecordings(zoomJWT, zoomUserID string) ([]meeting, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf(zoomRecordingsURL, zoomUserID, time.Now().AddDate(0, -1, 0).Format(ymdFormat)), nil)
+// discoverAndArchive lists the meetings of the job and archives them, or
+// queues them in QUEUE_MODE. It reports false if the run could not go on.
+func (run *backupRun) discoverAndArchive(ctx context.Context, abort func(error)) bool {
+	meetings, err := run.discoverMeetings(ctx)
 	if err != nil {
-		err = fmt.Errorf("failed to create new HTTP request for recordings: %w", err)
-		return nil, err
+		abort(err)
+		return false
 	}
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Authorization", "Bearer "+zoomJWT)
-	resp, err := defaultHTTPClient.Do(req)
-	if err != nil {
-		err = fmt.Errorf("failed to perform request for recordings: %w", err)
-		return nil, err
+	run.report.Meetings = len(meetings)
+	for _, m := range meetings {
+		run.emit(eventDiscovered, m, event{Bytes: meetingSize(m)})
 	}
 
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(resp.Body)
-	_ = resp.Body.Close()
-	if err != nil {
-		err = fmt.Errorf("failed to read recordings response body: %w", err)
-		return nil, err
+	if run.cfg.ExpiryForecastDays > 0 {
+		if err := run.forecastExpiry(ctx, meetings); err != nil {
+			err = fmt.Errorf("Could not forecast recording expiry: %v", err)
+			log.Println(err)
+			run.report.fail(err)
+		}
 	}
 
-	if resp.StatusCode/200 != 1 {
-		err = fmt.Errorf("invalid recordings response code: %s", buf.String())
-		return nil, err
+	if run.tasks != nil {
+		if err := run.fanOutMeetings(ctx, meetings); err != nil {
+			abort(err)
+			return false
+		}
+		run.report.meetingsQueued(meetings)
+		return true
+	}
+	if run.queue != nil {
+		if err := run.enqueueMeetings(ctx, meetings); err != nil {
+			abort(err)
+			return false
+		}
+		run.report.meetingsQueued(meetings)
+		return true
 	}
 
-	response := &recordingListResponse{}
-	err = json.Unmarshal(buf.Bytes(), response)
-	if err != nil {
-		err = fmt.Errorf("failed to unmarshal recordings response: %w", err)
-		return nil, err
+	meetings = run.runCanary(ctx, meetings)
+
+	var wg sync.WaitGroup
+	for _, m := range meetings {
+		wg.Add(1)
+		go func(m meeting) {
+			defer wg.Done()
+			run.processMeeting(ctx, m)
+		}(m)
 	}
+	wg.Wait()
+	return true
+}
 
-	meetings := make([]meeting, len(response.Meetings))
-	for i, meeting := range response.Meetings {
-		meetings[i].ID = meeting.ID
-		meetings[i].Topic = meeting.Topic
-		meetings[i].StartTime = meeting.StartTime
-		for _, file := range meeting.RecordingFiles {
-			if file.Status == "completed" && file.FileType == "MP4" {
-				meetings[i].Files = append(meetings[i].Files, file)
-			}
+// processMeeting streams every recording file of the meeting into the bucket
+// and then, unless disabled, deletes the meeting's recordings from Zoom. It
+// reports false if the meeting needs another attempt.
+func (run *backupRun) processMeeting(ctx context.Context, meeting meeting) bool {
+	if len(meeting.Zoom) > 0 {
+		run.saveDebugResponse(ctx, "meetings/"+url.PathEscape(meeting.ID)+".json", meeting.Zoom)
+	}
+	files, complete := run.archiveMeeting(ctx, meeting)
+	return run.settleMeeting(ctx, meeting, files, complete)
+}
 
+// settleMeeting deletes the archived meeting's recordings from Zoom unless
+// something speaks against it and records the meeting in the audit log. It
+// reports false if the meeting needs another attempt.
+func (run *backupRun) settleMeeting(ctx context.Context, meeting meeting, files []archivedFile, complete bool) bool {
+	done, _ := run.settle(ctx, meeting, files, complete)
+	return done
+}
+
+// settle is settleMeeting that also returns why the deletion was withheld or
+// failed, for the canary, which only passes once nothing stood in the way.
+func (run *backupRun) settle(ctx context.Context, meeting meeting, files []archivedFile, complete bool) (bool, error) {
+	var deletedAt time.Time
+	defer func() {
+		run.audit.recordMeeting(run.cfg, meeting, files, deletedAt)
+		// The hook also runs for meetings of cancelled runs.
+		run.runAfterMeetingHook(context.Background(), meeting, files, complete, !deletedAt.IsZero())
+	}()
+	if meeting.Processing {
+		run.report.meetingProcessing()
+	}
+	if ctx.Err() != nil {
+		return false, run.withholdDeletion(meeting, "the run was cancelled")
+	}
+	if run.isDeferred(meeting.ID) {
+		return false, run.withholdDeletion(meeting, "ALLOWED_HOURS ended before it was archived")
+	}
+	if run.canaryFailed {
+		return false, run.withholdDeletion(meeting, "the canary failed")
+	}
+	if !complete {
+		return false, run.withholdDeletion(meeting, "it was not archived completely")
+	}
+	if minAge := time.Duration(run.cfg.DeleteAfterDays) * 24 * time.Hour; time.Since(meetingStart(meeting)) < minAge {
+		log.Println("Not deleting recordings for", meeting.ID, "because it is not", run.cfg.DeleteAfterDays, "days old yet")
+		return true, nil
+	}
+	if meeting.Processing && run.cfg.DeleteMode == deleteModeMeeting {
+		// Deleting would destroy the files still being processed, so the
+		// meeting is left for a later run to archive completely.
+		log.Println("Not deleting recordings for", meeting.ID, "because Zoom is still processing some of them")
+		return true, nil
+	}
+	if run.cfg.isCritical(meeting) && run.cfg.DeleteFromZoom {
+		if err := run.verifyMeeting(ctx, meeting, files); err != nil {
+			err = fmt.Errorf("Not deleting recordings for critical meeting %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.deletionWithheld()
+			run.report.fail(err)
+			run.emit(eventFailed, meeting, event{Error: err.Error()})
+			return false, err
 		}
 	}
-
-	return meetings, nil
+	deleted, err := run.deleteMeeting(ctx, meeting, files)
+	if deleted {
+		deletedAt = time.Now()
+	}
+	return err == nil, err
 }
 
-func deleteMeetingRecordings(zoomJWT, meetingID string) error {
- 	req, err := http.NewRequest("DELETE", fmt.Sprintf(zoomDeleteRecordingsURL, meetingID), nil)
- 	if err != nil {
- 		err = fmt.Errorf("failed to create new HTTP request to delete recordings: %w", err)
- 		return err
- 	}
- 	req.Header.Add("Accept", "application/json")
- 	req.Header.Add("Authorization", "Bearer "+zoomJWT)
- 	resp, err := defaultHTTPClient.Do(req)
- 	if err != nil {
- 		err = fmt.Errorf("failed to delete recordings: %w", err)
- 		return err
- 	}
-
- 	if resp.StatusCode != http.StatusNoContent {
- 		buf := new(bytes.Buffer)
- 		_, err = buf.ReadFrom(resp.Body)
- 		_ = resp.Body.Close()
- 		if err != nil {
- 			err = fmt.Errorf("failed to read delete recordings response body: %w", err)
- 			return err
- 		}
- 		err = fmt.Errorf("invalid delete recordings response code: %d -- %s", resp.StatusCode, buf.String())
- 		return err
- 	}
-
- 	return nil
+// withholdDeletion logs why the meeting's recordings stay on Zoom and returns
+// it as an error. The run then leaves LIST_SINCE_LAST_SUCCESS's window where
+// it was, so the meeting is listed and deleted again later.
+func (run *backupRun) withholdDeletion(meeting meeting, reason string) error {
+	run.report.deletionWithheld()
+	log.Println("Not deleting recordings for", meeting.ID, "because", reason)
+	return fmt.Errorf("deletion of %s withheld because %s", meeting.ID, reason)
 }
 
-var defaultHTTPClient = &http.Client{
-	Timeout: time.Second * 15 * 60,
-	Transport: &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: time.Second * 10,
-		}).Dial,
-		TLSHandshakeTimeout: time.Second * 10,
-	},
+// archivedFile is a recording file that made it into the bucket.
+type archivedFile struct {
+	recording recordingFile
+	attrs     *storage.ObjectAttrs
+	// sha256 is the digest of the download, only kept for critical
+	// meetings.
+	sha256 []byte
+	// checksum is the CHECKSUM_ALGORITHM digest of the download, only kept
+	// with CHECKSUM_FILES.
+	checksum []byte
 }
 
-func storageWriter(ctx context.Context, storageClient *storage.Client, bucket, filename string) *storage.Writer {
-	return storageClient.Bucket(bucket).Object(filename).NewWriter(ctx)
+// archiveMeeting streams every recording file of the meeting into the bucket.
+// complete is false when any file failed.
+func (run *backupRun) archiveMeeting(ctx context.Context, meeting meeting) (files []archivedFile, complete bool) {
+	complete = true
+	for i, recording := range meeting.Files {
+		file, err := run.archiveFile(ctx, meeting, recording)
+		switch {
+		case err == errWindowClosed:
+			run.deferMeeting(meeting, files)
+			run.report.filesSkipped(meeting, meeting.Files[i:])
+			return files, false
+		case ctx.Err() != nil:
+			log.Println("Stopped archiving", meeting.ID, err)
+			run.report.filesSkipped(meeting, meeting.Files[i:])
+			return files, false
+		case err != nil:
+			complete = false
+		default:
+			files = append(files, file)
+		}
+	}
+
+	return files, run.exportMeeting(ctx, meeting, files, complete)
 }
 
-func getFileSaveName(startTime, Topic, recordingFileName string) (string, error) {
-	var folderName string
-	meetingDate, err := time.Parse(dateFormatFrom, startTime[:dateLength])
-	if err != nil {
-		return "", fmt.Errorf("failed to parse date: %w", err)
+// exportMeeting writes what goes with the archived files of the meeting, such
+// as participants, transcripts, sidecars and mirrors. complete is false when
+// any file or anything that has to be in place before the meeting is deleted
+// failed.
+func (run *backupRun) exportMeeting(ctx context.Context, meeting meeting, files []archivedFile, complete bool) bool {
+	cfg := run.cfg
+	if cfg.ParticipantsExport {
+		if err := run.exportParticipants(ctx, meeting); err != nil {
+			err = fmt.Errorf("Could not export participants for %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.fail(err)
+		}
+	}
+
+	if cfg.MeetingChatExport {
+		if err := run.exportMeetingChat(ctx, meeting); err != nil {
+			err = fmt.Errorf("Could not export the chat of %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.fail(err)
+			// Deleting the meeting would delete the chat with it.
+			complete = false
+		}
+	}
+
+	if meeting.isWebinar() && (cfg.WebinarQAExport || cfg.WebinarPollsExport) {
+		if err := run.exportWebinarReports(ctx, meeting); err != nil {
+			err = fmt.Errorf("Could not export webinar reports for %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.fail(err)
+		}
 	}
 
-	if Topic != "" {
-		folderName = fmt.Sprintf("%s-", Topic)
+	if cfg.Thumbnails {
+		run.writeThumbnails(ctx, meeting, files)
 	}
-	folderName += fmt.Sprintf("%s", meetingDate.Format(dateFormatTo))
-	fileSaveName := folderName + "/" + recordingFileName
-	return fileSaveName, nil
+
+	if cfg.Transcribe != "" && !meeting.HasTranscript {
+		if err := run.transcribeMeeting(ctx, meeting, files); err != nil {
+			err = fmt.Errorf("Could not transcribe %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.fail(err)
+		}
+	}
+
+	if cfg.ChecksumFiles == checksumFilesSums {
+		if err := run.writeChecksumSums(ctx, meeting, files); err != nil {
+			err = fmt.Errorf("Could not write checksums for %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.fail(err)
+			complete = false
+		}
+	}
+
+	if len(run.mirrors) > 0 {
+		if err := run.mirrorMeeting(ctx, meeting, files); err != nil {
+			err = fmt.Errorf("Could not mirror %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.fail(err)
+			complete = false
+		}
+	}
+
+	if cfg.MeetingSidecar {
+		if err := run.writeMeetingSidecar(ctx, meeting, files); err != nil {
+			err = fmt.Errorf("Could not write meeting sidecar for %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.fail(err)
+			complete = false
+		}
+	}
+
+	if cfg.MeetingBundle && complete {
+		if err := run.writeMeetingBundle(ctx, meeting, files); err != nil {
+			err = fmt.Errorf("Could not write meeting bundle for %s: %v", meeting.ID, err)
+			log.Println(err)
+			run.report.fail(err)
+			complete = false
+		}
+	}
+
+	return complete
 }
 
-func generateURLSListHTML(ctx context.Context, storageClient *storage.Client, bucket string) error {
-	html := openHTML()
-	it := storageClient.Bucket(bucket).Objects(ctx, nil)
+// errWindowClosed stops a meeting when ALLOWED_HOURS end before one of its
+// files was started.
+var errWindowClosed = errors.New("ALLOWED_HOURS ended")
+
+// archiveFile streams one recording file of the meeting into the bucket. The
+// download and upload slots, the response body and the upload are released
+// when it returns, so nothing is held open for the rest of the run. Failures
+// of the file are recorded in the run's metrics, report and events before
+// they are returned.
+func (run *backupRun) archiveFile(ctx context.Context, meeting meeting, recording recordingFile) (archivedFile, error) {
+	cfg := run.cfg
+	fileName := recording.FileName()
+	if err := run.limits.download.Acquire(ctx, 1); err != nil {
+		return archivedFile{}, err
+	}
+	// The download slot is given back as soon as the body was read, while
+	// the upload is still being finalized.
+	downloading := true
+	releaseDownload := func() {
+		if downloading {
+			run.limits.download.Release(1)
+			downloading = false
+		}
+	}
+	defer releaseDownload()
+	if run.windowClosed() {
+		return archivedFile{}, errWindowClosed
+	}
+
+	started := time.Now()
+	transfer := transferRecord{Time: started, File: fileName}
+	fail := func(err error) (archivedFile, error) {
+		transfer.Duration = time.Since(started)
+		transfer.Error = err.Error()
+		run.recordTransfer(transfer)
+		run.report.fileFailed(meeting, fileName, err)
+		run.sentry.captureFile(err, meeting, fileName)
+		run.emit(eventFailed, meeting, event{File: fileName, FileID: recording.ID, Error: err.Error()})
+		log.Println(err)
+		return archivedFile{}, err
+	}
+
+	if err := checkFileSize(cfg, recording); err != nil {
+		return fail(fmt.Errorf("skipping oversized file: %w", err))
+	}
+
+	fileSaveName, err := getFileSaveName(cfg, meeting, recording)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get file save name: %w", err))
+	}
+	objectName := cfg.compressedName(cfg.objectName(fileSaveName), recording.FileType)
+	if run.archivedBefore(meeting.ID, objectName) {
+		if attrs, err := run.storageClient.Bucket(cfg.Bucket).Object(objectName).Attrs(ctx); err == nil {
+			log.Println("Already archived", fileName, "before ALLOWED_HOURS ended")
+			run.report.fileArchivedBefore(meeting, fileName, attrs.Name, attrs.Size)
+			return archivedFile{recording: recording, attrs: attrs}, nil
+		}
+	}
+
+	log.Println("Requesting", fileName)
+	run.emit(eventDownloading, meeting, event{File: fileName, FileID: recording.ID})
+	var body io.ReadCloser
+	var size int64
 	for {
-		attrs, err := it.Next()
-		if err == iterator.Done {
+		body, size, err = run.downloadRecording(ctx, recording)
+		if err == nil || transfer.Retries >= cfg.DownloadRetries {
 			break
 		}
+		wait, retry := downloadRetryDelay(err, transfer.Retries+1)
+		if !retry {
+			break
+		}
+		transfer.Retries++
+		log.Println("Retrying", fileName, "in", wait, "after", err)
+		select {
+		case <-ctx.Done():
+			return archivedFile{}, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	if err != nil {
+		return fail(fmt.Errorf("failed to request download file: %w", err))
+	}
+	defer body.Close()
+	var content io.Reader = body
+	transcoded := cfg.transcodes(meeting, recording)
+	if recording.extractAudio {
+		log.Println("Extracting the audio of", fileName)
+		result, resultSize, err := run.extractAudio(ctx, body)
+		if err != nil {
+			return fail(fmt.Errorf("failed to extract the audio of %s: %w", fileName, err))
+		}
+		defer result.Close()
+		content, size, transcoded = result, resultSize, true
+	} else if transcoded {
+		log.Println("Transcoding", fileName)
+		result, resultSize, err := run.transcode(ctx, body)
 		if err != nil {
-			return fmt.Errorf("Bucket(%q).Objects: %v", bucket, err)
+			return fail(fmt.Errorf("failed to transcode %s: %w", fileName, err))
 		}
-		html += addLinkHTML(bucket, attrs.Name)
+		defer result.Close()
+		log.Printf("Transcoded %s from %s to %s", fileName, humanBytes(recording.FileSize), humanBytes(resultSize))
+		content, size = result, resultSize
 	}
 
-	html += closeHTML()
-
-	htmlFileName := "biga.html"
+	if err := run.limits.upload.Acquire(ctx, 1); err != nil {
+		return archivedFile{}, err
+	}
+	defer run.limits.upload.Release(1)
+	log.Println("Getting writer", fileSaveName)
 
-	obj := storageClient.Bucket(bucket).Object(htmlFileName)
-	wc := obj.NewWriter(ctx)
-	if _, err := io.Copy(wc, bytes.NewBuffer([]byte(html))); err != nil {
-		return fmt.Errorf("Could not write file: %v", err)
+	// Cancelling the upload's context abandons it without committing a
+	// partial object, so every return before Close succeeded does.
+	uploadCtx, cancelUpload := context.WithCancel(ctx)
+	defer cancelUpload()
+	wc, err := run.compressingWriter(uploadCtx, meeting, objectName, recording.FileType, cfg.contentType(recording.FileType))
+	if err != nil {
+		return fail(fmt.Errorf("Could not create writer: %v", err))
+	}
+	sw := wc.sw
+	sw.ChunkSize = uploadChunkSize(cfg, recording.FileSize)
+	sw.ProgressFunc = uploadProgress(cfg, objectName, recording.FileSize)
+	sw.StorageClass = cfg.StorageClass
+	sw.Metadata = recordingMetadata(cfg, meeting, recording)
+	if transcoded {
+		sw.Metadata["transcoded"] = "true"
+	}
+	log.Println("Copying", fileName)
+	var digest hash.Hash
+	if size < 0 {
+		size = recording.FileSize
+	}
+	var src io.Reader = newProgressReader(content, fileName, size, cfg.DownloadProgressInterval)
+	if cfg.isCritical(meeting) {
+		digest = sha256.New()
+		src = io.TeeReader(src, digest)
+	}
+	var checksum hash.Hash
+	if cfg.ChecksumFiles != "" {
+		checksum = checksumAlgorithms[cfg.ChecksumAlgorithm]()
+		src = io.TeeReader(src, checksum)
 	}
+	transfer.Bytes, err = io.Copy(wc, src)
+	releaseDownload()
+	if err != nil {
+		return fail(fmt.Errorf("Could not write file: %v", err))
+	}
+	run.emit(eventDownloaded, meeting, event{File: fileName, FileID: recording.ID, Bytes: transfer.Bytes})
+
+	log.Println("Closing", fileName)
 	if err := wc.Close(); err != nil {
-		return fmt.Errorf("error closing: %w", err)
+		return fail(fmt.Errorf("Could not put file: %v", err))
 	}
-	return nil
+	transfer.Success = true
+	transfer.Duration = time.Since(started)
+	run.recordTransfer(transfer)
+	run.report.fileArchived(meeting, fileName, sw.Attrs().Name, transfer.Bytes)
+	run.archived.record(meeting, recording, sw.Attrs())
+	run.emit(eventUploaded, meeting, event{File: fileName, FileID: recording.ID, Object: sw.Attrs().Name, Bytes: sw.Attrs().Size})
+	file := archivedFile{recording: recording, attrs: sw.Attrs()}
+	if transcoded {
+		// Mirrors compare what they hold with the archived size.
+		file.recording.FileSize = transfer.Bytes
+	}
+	if digest != nil {
+		file.sha256 = digest.Sum(nil)
+	}
+	if checksum != nil {
+		file.checksum = checksum.Sum(nil)
+		if cfg.ChecksumFiles == checksumFilesSidecar {
+			if err := run.writeChecksumSidecar(ctx, meeting, file); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	log.Println("Finished", fileName)
+	return file, nil
 }
 
-func openHTML() string {
-	return "<html><body><h2>Kitchen Rodeos</h2><ul>"
+// deleteMeeting deletes the meeting's recordings from Zoom unless deletions
+// are disabled by the job or the control object. With DELETE_MODE=files only
+// the archived files are deleted, so the files that were not archived, such as
+// transcripts and chats, stay on Zoom. It reports whether the recordings were
+// deleted.
+func (run *backupRun) deleteMeeting(ctx context.Context, meeting meeting, files []archivedFile) (bool, error) {
+	if !run.cfg.DeleteFromZoom {
+		return false, nil
+	}
+	if !run.deletionAllowed(ctx) {
+		run.report.deletionWithheld()
+		return false, nil
+	}
+
+	if err := run.limits.delete.Acquire(ctx, 1); err != nil {
+		log.Println(err)
+		return false, err
+	}
+	defer run.limits.delete.Release(1)
+	if run.cfg.DeleteMode == deleteModeFiles {
+		for _, file := range files {
+			if file.recording.ID == "" {
+				log.Println("Not deleting", file.recording.FileName(), "of", meeting.ID, "because Zoom did not report its ID")
+				continue
+			}
+			log.Println("Deleting", file.recording.FileName(), "of", meeting.ID)
+			err := run.zoom.deleteRecordingFile(ctx, meeting.ID, file.recording.ID)
+			if errors.Is(err, errZoomNotFound) {
+				log.Println(file.recording.FileName(), "of", meeting.ID, "is already gone from Zoom")
+				continue
+			}
+			if err != nil {
+				log.Println(err)
+				run.report.fail(err)
+				run.emit(eventFailed, meeting, event{File: file.recording.FileName(), FileID: file.recording.ID, Error: err.Error()})
+				return false, err
+			}
+		}
+	} else {
+		log.Println("Deleting recordings for", meeting.ID)
+		err := run.zoom.deleteRecordings(ctx, meeting.ID)
+		if errors.Is(err, errZoomNotFound) {
+			log.Println("Recordings for", meeting.ID, "are already gone from Zoom")
+		} else if err != nil {
+			log.Println(err)
+			run.report.fail(err)
+			run.emit(eventFailed, meeting, event{Error: err.Error()})
+			return false, err
+		}
+	}
+	run.report.meetingDeleted()
+	run.emit(eventDeleted, meeting, event{})
+	return true, nil
 }
 
-func closeHTML() string {
-	return "</ul></body></html>"
+// recordingMetadata is the custom metadata of an archived recording file:
+// OBJECT_METADATA and what the index and later tooling need to know about the
+// meeting.
+func recordingMetadata(cfg *config, meeting meeting, recording recordingFile) map[string]string {
+	metadata := map[string]string{}
+	for k, v := range cfg.ObjectMetadata {
+		metadata[k] = v
+	}
+	for k, v := range map[string]string{
+		"topic":          meeting.Topic,
+		"start_time":     meeting.StartTime,
+		"duration":       strconv.Itoa(meeting.Duration),
+		"recording_type": recording.RecordingType,
+		"recording_id":   recording.ID,
+		"source":         meeting.source(),
+		"meeting_uuid":   meeting.ID,
+		"host_id":        meeting.HostID,
+		"host_email":     meeting.HostEmail,
+		"host_name":      meeting.HostName,
+		"user_id":        meeting.UserID,
+	} {
+		if v != "" {
+			metadata[k] = v
+		}
+	}
+	return metadata
 }
 
-func addLinkHTML(bucket, fileName string) string {
-	link := fmt.Sprintf("http://%s/%s", bucket, fileName)
-	return fmt.Sprintf("<li><a href=\"%s\">%s</a></li>", link, fileName)
+func (f recordingFile) FileName() string {
+	return fmt.Sprintf(
+		"%s-%s%s.%s",
+		f.RecordingStart,
+		f.RecordingType,
+		f.segmentSuffix(),
+		strings.ToLower(f.FileType),
+	)
 }
+
+// segmentSuffix is "-N" for the Nth segment of a recording and empty for the
+// first one and files without segments.
+func (f recordingFile) segmentSuffix() string {
+	if f.Segment < 2 {
+		return ""
+	}
+	return fmt.Sprintf("-%d", f.Segment)
+}
+
+// numberSegments sets the Segment of every file that shares its start time,
+// type and file type with an earlier file of the list. Zoom lists the
+// segments of a recording in order, so numbering is the same in every run.
+func numberSegments(files []recordingFile) {
+	seen := map[string]int{}
+	for i := range files {
+		files[i].Segment = 0
+		key := files[i].FileName()
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			files[i].Segment = n
+		}
+	}
+}
+
+// parseRecordingList decodes a Zoom list recordings response into meetings,
+// keeping only the completed MP4 files and marking the meetings with files
+// that are still processing. The meetings are decoded one at a time as the
+// response is read, so only one of them is held twice in memory. The
+// response's next_page_token is returned along with them.
+func parseRecordingList(r io.Reader) ([]meeting, string, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+	}
+	var meetings []meeting
+	nextPageToken := ""
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+		}
+		if key == "next_page_token" {
+			if err := dec.Decode(&nextPageToken); err != nil {
+				return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+			}
+			continue
+		}
+		if key != "meetings" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+			}
+			continue
+		}
+		if tok, err := dec.Token(); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+		} else if tok == nil {
+			continue
+		} else if tok != json.Delim('[') {
+			return nil, "", fmt.Errorf("failed to unmarshal recordings response: meetings is not an array")
+		}
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+			}
+			mtg, err := parseListedMeeting(raw)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+			}
+			meetings = append(meetings, mtg)
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal recordings response: %w", err)
+	}
+	return meetings, nextPageToken, nil
+}
+
+// expectDelim reads the next token, which has to be delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// parseListedMeeting turns one entry of the recordings list into a meeting,
+// keeping raw as what Zoom returned.
+func parseListedMeeting(raw json.RawMessage) (meeting, error) {
+	listed := listedMeeting{}
+	if err := json.Unmarshal(raw, &listed); err != nil {
+		return meeting{}, err
+	}
+	mtg := meeting{
+		ID:        listed.ID,
+		Type:      listed.Type,
+		Topic:     listed.Topic,
+		StartTime: listed.StartTime,
+		Duration:  listed.Duration,
+		HostID:    listed.HostID,
+		HostEmail: listed.HostEmail,
+		ShareURL:  listed.ShareURL,
+		Zoom:      raw,
+
+		Passcode:     listed.Password,
+		PlayPasscode: listed.PlayPasscode,
+	}
+	seen := map[string]bool{}
+	for _, file := range listed.RecordingFiles {
+		// Zoom has been seen to list a file twice; its ID tells.
+		if file.ID != "" {
+			if seen[file.ID] {
+				continue
+			}
+			seen[file.ID] = true
+		}
+		if file.Status == "completed" && file.FileType == "MP4" {
+			mtg.Files = append(mtg.Files, file)
+		}
+		if file.Status == "completed" && file.FileType == "M4A" {
+			mtg.Audio = append(mtg.Audio, file)
+		}
+		if file.Status == "completed" && file.FileType == "CHAT" {
+			mtg.Chat = append(mtg.Chat, file)
+		}
+		if file.FileType == "TRANSCRIPT" {
+			mtg.HasTranscript = true
+		}
+		if file.Status != "" && file.Status != "completed" {
+			mtg.Processing = true
+		}
+	}
+	numberSegments(mtg.Files)
+	numberSegments(mtg.Audio)
+	return mtg, nil
+}
+
+const (
+	// deleteModeMeeting deletes all recordings of an archived meeting.
+	deleteModeMeeting = "meeting"
+	// deleteModeFiles deletes only the archived recording files.
+	deleteModeFiles = "files"
+)
+
+// meetingPathID encodes a meeting UUID for the path of a per-meeting
+// endpoint. Zoom decodes the path once before routing, so UUIDs starting with
+// "/" or containing "//" have to be encoded twice to reach it intact.
+func meetingPathID(uuid string) string {
+	if strings.HasPrefix(uuid, "/") || strings.Contains(uuid, "//") {
+		return url.PathEscape(url.PathEscape(uuid))
+	}
+	return url.PathEscape(uuid)
+}
+
+// defaultHTTPClient is used by configs that were not loaded with their
+// Dialer and is never replaced, as concurrent invocations share it.
+var defaultHTTPClient = newHTTPClient(&dialerConfig{Timeout: time.Second * 10, APITimeout: 15 * time.Minute, TLSHandshakeTimeout: 10 * time.Second})
@@ -0,0 +1,134 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCancelStopsDownloadRetryBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api := http.NewServeMux()
+	api.HandleFunc("/users/me/recordings", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"meetings":[{"uuid":"m1","topic":"Standup","start_time":"2026-10-01T10:00:00Z","recording_files":[
+			{"id":"a1","file_type":"MP4","status":"completed","recording_start":"2026-10-01T10:00:00Z","download_url":"http://%s/v2/rec/download/a1"}]}]}`, r.Host)
+	})
+	api.HandleFunc("/rec/download/a1", func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	cfg, storageClient, _ := newTestBackup(t, api)
+	cfg.DownloadRetries = 1
+
+	started := time.Now()
+	report := runBackup(ctx, storageClient, cfg, nil)
+	if elapsed := time.Since(started); elapsed >= downloadRetryBackoff {
+		t.Errorf("the run took %v after it was cancelled, want it to stop waiting for the retry", elapsed)
+	}
+	if report.FilesSkipped != 1 || report.FilesFailed != 0 {
+		t.Errorf("got %d files skipped and %d failed, want the file skipped", report.FilesSkipped, report.FilesFailed)
+	}
+}
+
+func TestRunBackupReportsOutcomes(t *testing.T) {
+	start := time.Now().AddDate(0, 0, -3).UTC().Format(time.RFC3339)
+	meetings := map[string]string{
+		"done":       `{"uuid":"done","topic":"Done","start_time":%q,"recording_files":[%s,%s]}`,
+		"broken":     `{"uuid":"broken","topic":"Broken","start_time":%q,"recording_files":[%s,%s]}`,
+		"processing": `{"uuid":"processing","topic":"Processing","start_time":%q,"recording_files":[%s,%s]}`,
+	}
+	files := map[string][2][3]string{
+		"done":       {{"d1", "MP4", "completed"}, {"d2", "MP4", "completed"}},
+		"broken":     {{"b1", "MP4", "completed"}, {"b2", "MP4", "completed"}},
+		"processing": {{"p1", "MP4", "completed"}, {"p2", "MP4", "processing"}},
+	}
+	tests := []struct {
+		name             string
+		meetings         []string
+		wantArchived     int
+		wantFailed       int
+		wantOutcomes     string
+		wantDeleted      string
+		wantListedWindow bool
+	}{
+		{
+			name:             "complete",
+			meetings:         []string{"done"},
+			wantArchived:     2,
+			wantOutcomes:     "[done:deleted]",
+			wantDeleted:      "[done]",
+			wantListedWindow: true,
+		},
+		{
+			name:         "partial",
+			meetings:     []string{"done", "broken", "processing"},
+			wantArchived: 4,
+			wantFailed:   1,
+			wantOutcomes: "[broken:failed done:deleted processing:archived]",
+			wantDeleted:  "[done]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := http.NewServeMux()
+			api.HandleFunc("/users/me/recordings", func(w http.ResponseWriter, r *http.Request) {
+				var listed []string
+				for _, uuid := range tt.meetings {
+					var recordings []interface{}
+					for _, f := range files[uuid] {
+						recordings = append(recordings, fmt.Sprintf(`{"id":%q,"file_type":%q,"status":%q,"recording_start":%q,"recording_type":"shared_screen","download_url":"http://%s/v2/rec/download/%s"}`, f[0], f[1], f[2], start, r.Host, f[0]))
+					}
+					listed = append(listed, fmt.Sprintf(meetings[uuid], append([]interface{}{start}, recordings...)...))
+				}
+				fmt.Fprintf(w, `{"meetings":[%s]}`, strings.Join(listed, ","))
+			})
+			api.HandleFunc("/rec/download/", func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/rec/download/b2" {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				fmt.Fprint(w, "recording")
+			})
+			var mu sync.Mutex
+			var deleted []string
+			api.HandleFunc("/meetings/", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				deleted = append(deleted, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/meetings/"), "/recordings"))
+				mu.Unlock()
+				w.WriteHeader(http.StatusNoContent)
+			})
+			cfg, storageClient, _ := newTestBackup(t, api)
+			cfg.DeleteFromZoom = true
+			cfg.DeleteMode = deleteModeMeeting
+			cfg.DeleteAfterDays = 0
+			cfg.DownloadRetries = 0
+
+			report := runBackup(context.Background(), storageClient, cfg, nil)
+			if report.FilesArchived != tt.wantArchived || report.FilesFailed != tt.wantFailed {
+				t.Errorf("got %d files archived and %d failed, want %d and %d", report.FilesArchived, report.FilesFailed, tt.wantArchived, tt.wantFailed)
+			}
+			var outcomes []string
+			for _, timeline := range report.Timelines {
+				outcomes = append(outcomes, timeline.MeetingUUID+":"+timeline.Outcome)
+			}
+			sort.Strings(outcomes)
+			if fmt.Sprint(outcomes) != tt.wantOutcomes {
+				t.Errorf("got outcomes %v, want %s", outcomes, tt.wantOutcomes)
+			}
+			if fmt.Sprint(deleted) != tt.wantDeleted {
+				t.Errorf("got deleted meetings %v, want %s", deleted, tt.wantDeleted)
+			}
+			report.mu.Lock()
+			defer report.mu.Unlock()
+			if got := report.listedCompletely(); got != tt.wantListedWindow {
+				t.Errorf("got listed completely %t, want %t", got, tt.wantListedWindow)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// fakeGCS is an in-memory bucket store serving the parts of the Cloud Storage
+// JSON and XML APIs the backup uses: reads, uploads with generation
+// preconditions, metadata, listing and deletes.
+type fakeGCS struct {
+	mu         sync.Mutex
+	objects    map[string]fakeObject
+	generation int64
+	// failDeletes makes deleting these objects fail.
+	failDeletes map[string]bool
+	deleted     []string
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	generation  int64
+}
+
+// newTestStorage starts a fake Cloud Storage and returns a client talking to
+// it.
+func newTestStorage(t *testing.T) (*storage.Client, *fakeGCS) {
+	t.Helper()
+	gcs := &fakeGCS{objects: map[string]fakeObject{}, failDeletes: map[string]bool{}}
+	srv := httptest.NewTLSServer(gcs)
+	t.Cleanup(srv.Close)
+	client, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(srv.URL+"/storage/v1/"),
+		option.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, gcs
+}
+
+// put stores an object as if a previous run had written it.
+func (g *fakeGCS) put(bucket, name string, data []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.generation++
+	g.objects[bucket+"/"+name] = fakeObject{data: data, generation: g.generation}
+}
+
+// get returns the content of an object and whether it exists.
+func (g *fakeGCS) get(bucket, name string) ([]byte, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	obj, ok := g.objects[bucket+"/"+name]
+	return obj.data, ok
+}
+
+func (g *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/"):
+		g.insert(w, r, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/upload/storage/v1/b/"), "/o"))
+	case strings.HasPrefix(r.URL.Path, "/storage/v1/b/"):
+		rest := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/")
+		if strings.HasSuffix(rest, "/o") {
+			g.list(w, r, strings.TrimSuffix(rest, "/o"))
+			return
+		}
+		parts := strings.SplitN(rest, "/o/", 2)
+		if len(parts) != 2 {
+			gcsError(w, http.StatusBadRequest, "unsupported path "+r.URL.Path)
+			return
+		}
+		g.object(w, r, parts[0], parts[1])
+	default:
+		g.read(w, r, strings.TrimPrefix(r.URL.Path, "/"))
+	}
+}
+
+// read serves the XML API object reads of storage.Reader.
+func (g *fakeGCS) read(w http.ResponseWriter, r *http.Request, key string) {
+	obj, ok := g.objects[key]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", obj.contentType)
+	w.Header().Set("X-Goog-Generation", strconv.FormatInt(obj.generation, 10))
+	w.Header().Set("X-Goog-Metageneration", "1")
+	w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+	_, _ = w.Write(obj.data)
+}
+
+// object serves the metadata of an object and deletes it.
+func (g *fakeGCS) object(w http.ResponseWriter, r *http.Request, bucket, name string) {
+	key := bucket + "/" + name
+	obj, ok := g.objects[key]
+	switch {
+	case r.Method == http.MethodDelete && g.failDeletes[name]:
+		gcsError(w, http.StatusForbidden, "delete refused for "+name)
+	case !ok:
+		gcsError(w, http.StatusNotFound, "no such object "+key)
+	case r.Method == http.MethodDelete:
+		delete(g.objects, key)
+		g.deleted = append(g.deleted, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet:
+		writeGCSObject(w, bucket, name, obj)
+	default:
+		gcsError(w, http.StatusMethodNotAllowed, r.Method+" is not supported")
+	}
+}
+
+// list serves the objects of a bucket under prefix, in a single page.
+func (g *fakeGCS) list(w http.ResponseWriter, r *http.Request, bucket string) {
+	prefix := bucket + "/" + r.URL.Query().Get("prefix")
+	var names []string
+	for key := range g.objects {
+		if strings.HasPrefix(key, prefix) {
+			names = append(names, strings.TrimPrefix(key, bucket+"/"))
+		}
+	}
+	sort.Strings(names)
+	var items []map[string]interface{}
+	for _, name := range names {
+		items = append(items, gcsResource(bucket, name, g.objects[bucket+"/"+name]))
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"kind": "storage#objects", "items": items})
+}
+
+// insert serves the single request multipart uploads storage.Writer uses for
+// small objects, honouring ifGenerationMatch.
+func (g *fakeGCS) insert(w http.ResponseWriter, r *http.Request, bucket string) {
+	if uploadType := r.URL.Query().Get("uploadType"); uploadType != "multipart" {
+		gcsError(w, http.StatusBadRequest, "unsupported upload type "+uploadType)
+		return
+	}
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		gcsError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	var meta struct {
+		Name        string `json:"name"`
+		ContentType string `json:"contentType"`
+	}
+	part, err := mr.NextPart()
+	if err == nil {
+		err = json.NewDecoder(part).Decode(&meta)
+	}
+	if err == nil {
+		part, err = mr.NextPart()
+	}
+	var data []byte
+	if err == nil {
+		data, err = ioutil.ReadAll(part)
+	}
+	if err != nil {
+		gcsError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key := bucket + "/" + meta.Name
+	if match := r.URL.Query().Get("ifGenerationMatch"); match != "" {
+		if match != strconv.FormatInt(g.objects[key].generation, 10) {
+			gcsError(w, http.StatusPreconditionFailed, "generation does not match for "+key)
+			return
+		}
+	}
+	g.generation++
+	obj := fakeObject{data: data, contentType: meta.ContentType, generation: g.generation}
+	g.objects[key] = obj
+	writeGCSObject(w, bucket, meta.Name, obj)
+}
+
+func gcsResource(bucket, name string, obj fakeObject) map[string]interface{} {
+	return map[string]interface{}{
+		"kind":           "storage#object",
+		"bucket":         bucket,
+		"name":           name,
+		"contentType":    obj.contentType,
+		"size":           strconv.Itoa(len(obj.data)),
+		"generation":     strconv.FormatInt(obj.generation, 10),
+		"metageneration": "1",
+		"storageClass":   "STANDARD",
+	}
+}
+
+func writeGCSObject(w http.ResponseWriter, bucket, name string, obj fakeObject) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(gcsResource(bucket, name, obj))
+}
+
+func gcsError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"error":{"code":%d,"message":%q}}`, code, message)
+}
@@ -0,0 +1,76 @@
+package zoombackup
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+)
+
+// Objects such as the manifest, the transfer history and the SUMS files are
+// read, changed and written back by every run, Cloud Task and queue worker.
+// Their writes only succeed if nobody wrote the object since it was read, and
+// start over from a fresh read otherwise.
+const (
+	// conflictRetries is how often an update starts over.
+	conflictRetries = 8
+	// conflictRetryBackoff is multiplied by the attempt between retries.
+	conflictRetryBackoff = 250 * time.Millisecond
+)
+
+// readObject returns the content of obj and its generation, nil and 0 when
+// it does not exist yet.
+func readObject(ctx context.Context, obj *storage.ObjectHandle) ([]byte, int64, error) {
+	r, err := obj.NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Close()
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return raw, r.Attrs.Generation, nil
+}
+
+// ifGeneration makes writes through obj fail unless the object is still at
+// generation, or still does not exist when generation is 0.
+func ifGeneration(obj *storage.ObjectHandle, generation int64) *storage.ObjectHandle {
+	if generation == 0 {
+		return obj.If(storage.Conditions{DoesNotExist: true})
+	}
+	return obj.If(storage.Conditions{GenerationMatch: generation})
+}
+
+// isPreconditionFailed reports whether a write was refused because another
+// writer changed the object first.
+func isPreconditionFailed(err error) bool {
+	var e *googleapi.Error
+	return errors.As(err, &e) && e.Code == http.StatusPreconditionFailed
+}
+
+// retryOnConflict calls update, which reads name and writes it back through
+// ifGeneration, until it is not refused for a conflict, at most
+// conflictRetries more times.
+func retryOnConflict(ctx context.Context, name string, update func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := update()
+		if !isPreconditionFailed(err) || attempt >= conflictRetries || ctx.Err() != nil {
+			return err
+		}
+		log.Println(name, "was changed by another run, updating it again")
+		select {
+		case <-time.After(time.Duration(attempt+1) * conflictRetryBackoff):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
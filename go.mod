@@ -3,9 +3,17 @@ module github.com/codegoalie/zoom-backup
 go 1.14
 
 require (
-	cloud.google.com/go/storage v1.10.0
+	cloud.google.com/go/storage v1.11.0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/gobuffalo/envy v1.9.0
+	github.com/golang/protobuf v1.4.2
+	github.com/klauspost/compress v1.11.0
+	github.com/pkg/sftp v1.12.0
+	golang.org/x/crypto v0.0.0-20200820211705-5c72a883971a
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
+	golang.org/x/sync v0.0.0-20200625203802-6e8e738ad208
 	google.golang.org/api v0.30.0
+	google.golang.org/grpc v1.31.0
+	google.golang.org/protobuf v1.25.0
+	gopkg.in/yaml.v2 v2.3.0
 )
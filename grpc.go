@@ -0,0 +1,426 @@
+package zoombackup
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. backuppb/backup.proto
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/codegoalie/zoom-backup/backuppb"
+	"github.com/golang/protobuf/ptypes"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer implements backuppb.BackupServer on top of the same jobs the
+// CLI and the Cloud Function run.
+type grpcServer struct {
+	// ctx ends when the server shuts down and cancels the active run.
+	ctx           context.Context
+	storageClient *storage.Client
+	jobs          []*config
+	events        *eventStream
+
+	mu     sync.Mutex
+	runs   map[string]*grpcRun
+	active *grpcRun
+}
+
+// grpcRun tracks a run started through StartRun. Every event is kept so
+// streams can catch up at their own pace.
+type grpcRun struct {
+	id        string
+	startedAt time.Time
+
+	mu         sync.Mutex
+	events     []event
+	reports    []*runReport
+	finishedAt time.Time
+	// changed is closed and replaced whenever an event is added.
+	changed chan struct{}
+	done    chan struct{}
+}
+
+// serveGRPC serves the Backup service on addr until the listener fails or ctx
+// ends, which cancels the active run and waits for it to finish.
+func serveGRPC(ctx context.Context, addr string, storageClient *storage.Client, jobs []*config, events *eventStream) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s := grpc.NewServer()
+	backuppb.RegisterBackupServer(s, &grpcServer{
+		ctx:           ctx,
+		storageClient: storageClient,
+		jobs:          jobs,
+		events:        events,
+		runs:          map[string]*grpcRun{},
+	})
+	go func() {
+		<-ctx.Done()
+		s.GracefulStop()
+	}()
+	log.Println("Serving gRPC on", lis.Addr())
+	return s.Serve(lis)
+}
+
+func (s *grpcServer) StartRun(req *backuppb.StartRunRequest, stream backuppb.Backup_StartRunServer) error {
+	jobs, err := s.selectJobs(req.GetJobs())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.active != nil {
+		s.mu.Unlock()
+		return status.Errorf(codes.FailedPrecondition, "run %s is still in progress", s.active.id)
+	}
+	run := &grpcRun{
+		id:        fmt.Sprintf("run-%d", time.Now().UnixNano()),
+		startedAt: time.Now().UTC(),
+		changed:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	s.runs[run.id] = run
+	s.active = run
+	s.mu.Unlock()
+
+	go func() {
+		// The run must not stop when the client that started it goes away,
+		// only when the server shuts down.
+		ctx := s.ctx
+		for _, job := range jobs {
+			pingHealthcheck(job.client(), job.HealthcheckStartURL, "")
+			report := runBackup(ctx, s.storageClient, job, s.events.with(run.add))
+			report.log()
+			notifyRun(s.storageClient, job, report)
+			runAfterRunHook(job, report)
+			recordStatus(s.storageClient, job, report)
+			pingRunHealthcheck(job, report)
+			run.mu.Lock()
+			run.reports = append(run.reports, report)
+			run.mu.Unlock()
+		}
+		run.mu.Lock()
+		run.finishedAt = time.Now().UTC()
+		run.mu.Unlock()
+		s.mu.Lock()
+		s.active = nil
+		s.mu.Unlock()
+		close(run.done)
+	}()
+
+	sent := 0
+	for {
+		run.mu.Lock()
+		pending := run.events[sent:]
+		changed := run.changed
+		finished := !run.finishedAt.IsZero()
+		run.mu.Unlock()
+
+		for _, e := range pending {
+			if err := stream.Send(&backuppb.RunProgress{
+				RunId:  run.id,
+				Update: &backuppb.RunProgress_Event{Event: eventProto(e)},
+			}); err != nil {
+				return err
+			}
+		}
+		sent += len(pending)
+
+		if finished {
+			return stream.Send(&backuppb.RunProgress{
+				RunId:  run.id,
+				Update: &backuppb.RunProgress_Status{Status: run.status()},
+			})
+		}
+		if len(pending) > 0 {
+			continue
+		}
+
+		select {
+		case <-changed:
+		case <-run.done:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func (s *grpcServer) GetRunStatus(ctx context.Context, req *backuppb.GetRunStatusRequest) (*backuppb.RunStatus, error) {
+	s.mu.Lock()
+	run, ok := s.runs[req.GetRunId()]
+	s.mu.Unlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "run %q not found", req.GetRunId())
+	}
+	return run.status(), nil
+}
+
+func (s *grpcServer) ListArchivedMeetings(ctx context.Context, req *backuppb.ListArchivedMeetingsRequest) (*backuppb.ListArchivedMeetingsResponse, error) {
+	cfg, err := s.manifestJob(req.GetJob())
+	if err != nil {
+		return nil, err
+	}
+	m, err := loadManifest(ctx, s.storageClient, cfg)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to load manifest: %v", err)
+	}
+
+	resp := &backuppb.ListArchivedMeetingsResponse{}
+	for _, mtg := range m.Meetings {
+		date, err := meetingDate(cfg, mtg.StartTime)
+		if err != nil {
+			continue
+		}
+		day := date.Format(dateFormatFrom)
+		if (req.GetFrom() != "" && day < req.GetFrom()) || (req.GetTo() != "" && day > req.GetTo()) {
+			continue
+		}
+		resp.Meetings = append(resp.Meetings, archivedMeetingProto(mtg))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) RestoreMeeting(ctx context.Context, req *backuppb.RestoreMeetingRequest) (*backuppb.RestoreMeetingResponse, error) {
+	cfg, err := s.manifestJob(req.GetJob())
+	if err != nil {
+		return nil, err
+	}
+	expiry := cfg.FeedLinkExpiry
+	if req.GetLinkExpirySeconds() > 0 {
+		expiry = time.Duration(req.GetLinkExpirySeconds()) * time.Second
+	}
+	if expiry > 7*24*time.Hour {
+		return nil, status.Error(codes.InvalidArgument, "link_expiry_seconds may not exceed a week")
+	}
+
+	m, err := loadManifest(ctx, s.storageClient, cfg)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to load manifest: %v", err)
+	}
+	i := m.meetingIndex(req.GetMeetingUuid())
+	if i < 0 {
+		return nil, status.Errorf(codes.NotFound, "meeting %q is not in the manifest", req.GetMeetingUuid())
+	}
+
+	signer, err := newURLSigner(ctx, cfg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create URL signer: %v", err)
+	}
+	mtg := archivedMeetingProto(m.Meetings[i])
+	for _, file := range mtg.Files {
+		if file.Url, err = signer.objectURL(cfg.Bucket, file.Object, expiry); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to sign URL for %s: %v", file.Object, err)
+		}
+	}
+	return &backuppb.RestoreMeetingResponse{
+		Meeting:       mtg,
+		LinksExpireAt: timestampProto(time.Now().Add(expiry)),
+	}, nil
+}
+
+// selectJobs returns the named jobs in the configured order, or all of them.
+func (s *grpcServer) selectJobs(names []string) ([]*config, error) {
+	if len(names) == 0 {
+		return s.jobs, nil
+	}
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var jobs []*config
+	for _, job := range s.jobs {
+		if wanted[job.JobName] {
+			jobs = append(jobs, job)
+			delete(wanted, job.JobName)
+		}
+	}
+	if len(wanted) > 0 {
+		var unknown []string
+		for name := range wanted {
+			unknown = append(unknown, name)
+		}
+		return nil, status.Errorf(codes.NotFound, "unknown jobs: %s", strings.Join(unknown, ", "))
+	}
+	return jobs, nil
+}
+
+// manifestJob returns the named job, which may be left out when there is
+// only one, and makes sure it keeps a manifest.
+func (s *grpcServer) manifestJob(name string) (*config, error) {
+	var cfg *config
+	if name == "" {
+		if len(s.jobs) != 1 {
+			return nil, status.Error(codes.InvalidArgument, "job is required when more than one job is configured")
+		}
+		cfg = s.jobs[0]
+	} else {
+		jobs, err := s.selectJobs([]string{name})
+		if err != nil {
+			return nil, err
+		}
+		cfg = jobs[0]
+	}
+	if !cfg.ManifestEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "job %s does not keep a manifest", cfg.JobName)
+	}
+	return cfg, nil
+}
+
+func (run *grpcRun) add(e event) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.events = append(run.events, e)
+	close(run.changed)
+	run.changed = make(chan struct{})
+}
+
+func (run *grpcRun) status() *backuppb.RunStatus {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	st := &backuppb.RunStatus{
+		RunId:     run.id,
+		State:     backuppb.RunStatus_RUNNING,
+		StartedAt: timestampProto(run.startedAt),
+	}
+	failed := false
+	for _, report := range run.reports {
+		report.mu.Lock()
+		st.Reports = append(st.Reports, &backuppb.JobReport{
+			Job:               report.Job,
+			StartedAt:         timestampProto(report.StartedAt),
+			FinishedAt:        timestampProto(report.FinishedAt),
+			Paused:            report.Paused,
+			Canary:            report.Canary,
+			Users:             int32(report.Users),
+			Meetings:          int32(report.Meetings),
+			FilesArchived:     int32(report.FilesArchived),
+			FilesFailed:       int32(report.FilesFailed),
+			BytesArchived:     report.BytesArchived,
+			MeetingsDeleted:   int32(report.MeetingsDeleted),
+			Errors:            append([]string(nil), report.Errors...),
+			SettingsDrift:     int32(report.SettingsDrift),
+			OutsideWindow:     report.OutsideWindow,
+			Cancelled:         report.Cancelled,
+			MeetingsDeferred:  int32(report.MeetingsDeferred),
+			MeetingsPostponed: int32(report.MeetingsPostponed),
+			IndexError:        report.IndexError,
+			ObjectsPruned:     int32(report.ObjectsPruned),
+			Policy:            report.Policy,
+			VerifyProblems:    int32(report.VerifyProblems),
+			FilesSkipped:      int32(report.FilesSkipped),
+			Fatal:             report.Fatal,
+		})
+		jr := st.Reports[len(st.Reports)-1]
+		for _, t := range report.Timelines {
+			jr.Timelines = append(jr.Timelines, timelineProto(t))
+		}
+		for _, d := range report.Deprecations {
+			jr.ZoomApiDeprecations = append(jr.ZoomApiDeprecations, &backuppb.ApiDeprecation{
+				Endpoint:    d.Endpoint,
+				Deprecation: d.Deprecation,
+				Sunset:      d.Sunset,
+				Link:        d.Link,
+				FirstSeen:   timestampProto(d.FirstSeen),
+				LastSeen:    timestampProto(d.LastSeen),
+				Requests:    int32(d.Requests),
+			})
+		}
+		failed = failed || len(report.Errors) > 0
+		report.mu.Unlock()
+	}
+	if !run.finishedAt.IsZero() {
+		st.FinishedAt = timestampProto(run.finishedAt)
+		st.State = backuppb.RunStatus_SUCCEEDED
+		if failed {
+			st.State = backuppb.RunStatus_FAILED
+		}
+	}
+	return st
+}
+
+func eventProto(e event) *backuppb.Event {
+	return &backuppb.Event{
+		Time:        timestampProto(e.Time),
+		Job:         e.Job,
+		Action:      e.Action,
+		MeetingUuid: e.MeetingUUID,
+		Topic:       e.Topic,
+		File:        e.File,
+		Object:      e.Object,
+		Bytes:       e.Bytes,
+		Error:       e.Error,
+	}
+}
+
+func archivedMeetingProto(mtg manifestMeeting) *backuppb.ArchivedMeeting {
+	pb := &backuppb.ArchivedMeeting{
+		Uuid:      mtg.UUID,
+		Topic:     mtg.Topic,
+		StartTime: mtg.StartTime,
+		Duration:  int32(mtg.Duration),
+		Folder:    mtg.Folder,
+	}
+	for _, file := range mtg.Files {
+		pb.Files = append(pb.Files, &backuppb.ArchivedFile{
+			Object:         file.Object,
+			RecordingStart: file.RecordingStart,
+			RecordingType:  file.RecordingType,
+			FileType:       file.FileType,
+			Size:           file.Size,
+			Md5:            file.MD5,
+			ArchivedAt:     timestampProto(file.ArchivedAt),
+		})
+	}
+	return pb
+}
+
+func timelineProto(t *meetingTimeline) *backuppb.MeetingTimeline {
+	pb := &backuppb.MeetingTimeline{
+		MeetingUuid: t.MeetingUUID,
+		Topic:       t.Topic,
+		Discovered:  optionalTimestampProto(t.Discovered),
+		Deleted:     optionalTimestampProto(t.Deleted),
+		Error:       t.Error,
+	}
+	for _, f := range t.Files {
+		pb.Files = append(pb.Files, &backuppb.FileTimeline{
+			File:            f.File,
+			DownloadStarted: optionalTimestampProto(f.DownloadStarted),
+			Downloaded:      optionalTimestampProto(f.Downloaded),
+			Uploaded:        optionalTimestampProto(f.Uploaded),
+			Verified:        optionalTimestampProto(f.Verified),
+			Error:           f.Error,
+		})
+	}
+	return pb
+}
+
+func optionalTimestampProto(t *time.Time) *tspb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestampProto(*t)
+}
+
+// timestampProto converts t, leaving zero times unset.
+func timestampProto(t time.Time) *tspb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
@@ -0,0 +1,35 @@
+package zoombackup
+
+import (
+	"context"
+	"log"
+	"net/http"
+)
+
+// pingHealthcheck tells a healthcheck service such as healthchecks.io or
+// Dead Man's Snitch that a run started, succeeded or failed, so schedules
+// that stop running or keep failing raise an alert there. The body is logged
+// by services that keep it. A failed ping is logged and never fails the run.
+// It does not derive from the run's context so cancelled runs are reported
+// too.
+func pingHealthcheck(httpClient *http.Client, url, body string) {
+	if url == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := postNotification(ctx, httpClient, url, "text/plain; charset=utf-8", []byte(body)); err != nil {
+		log.Println("Could not ping healthcheck:", err)
+	}
+}
+
+// pingRunHealthcheck pings HEALTHCHECK_FAIL_URL when the run failed and
+// HEALTHCHECK_URL otherwise, including paused runs and runs outside
+// ALLOWED_HOURS, which did what they were told.
+func pingRunHealthcheck(cfg *config, report *runReport) {
+	if report.failed() {
+		pingHealthcheck(cfg.client(), cfg.HealthcheckFailURL, report.String())
+		return
+	}
+	pingHealthcheck(cfg.client(), cfg.HealthcheckURL, report.String())
+}
@@ -0,0 +1,126 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Hooks, named after the settings that configure them.
+const (
+	hookBeforeRun    = "before_run"
+	hookAfterMeeting = "after_meeting"
+	hookAfterRun     = "after_run"
+)
+
+// hookContext is the JSON a hook gets on stdin, or as the body of its
+// request.
+type hookContext struct {
+	Hook string    `json:"hook"`
+	Job  string    `json:"job"`
+	Time time.Time `json:"time"`
+	// Meeting is set for after_meeting.
+	Meeting *hookMeeting `json:"meeting,omitempty"`
+	// Report is set for after_run.
+	Report *runReport `json:"report,omitempty"`
+}
+
+// hookMeeting is the meeting an after_meeting hook is run for.
+type hookMeeting struct {
+	UUID      string `json:"uuid"`
+	Topic     string `json:"topic"`
+	StartTime string `json:"start_time"`
+	HostEmail string `json:"host_email,omitempty"`
+	// Objects are the recordings archived by the run.
+	Objects []string `json:"objects"`
+	// Complete is set when all of the meeting's files were archived.
+	Complete bool `json:"complete"`
+	Deleted  bool `json:"deleted"`
+}
+
+// runHook runs the hook: URLs get the context POSTed as JSON, anything else
+// is run with sh -c and gets it on stdin. Either has HOOK_TIMEOUT to finish
+// and fails unless it succeeds.
+func runHook(ctx context.Context, cfg *config, hook, command string, hc hookContext) error {
+	hc.Hook = hook
+	hc.Job = cfg.JobName
+	hc.Time = time.Now().UTC()
+	payload, err := json.Marshal(hc)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.HookTimeout)
+	defer cancel()
+
+	if strings.HasPrefix(command, "https://") || strings.HasPrefix(command, "http://") {
+		return postNotification(ctx, cfg.client(), command, "application/json", payload)
+	}
+	// The output goes to a file rather than a pipe, which processes the
+	// command started in the background would hold open past the timeout.
+	output, err := ioutil.TempFile("", "zoom-backup-hook-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "ZOOM_BACKUP_HOOK="+hook, "ZOOM_BACKUP_JOB="+cfg.JobName)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err = cmd.Run()
+	if out, readErr := ioutil.ReadFile(output.Name()); readErr == nil && len(bytes.TrimSpace(out)) > 0 {
+		log.Printf("Hook %s: %s", hook, bytes.TrimSpace(out))
+	}
+	if err != nil {
+		return fmt.Errorf("hook %s: %w", hook, err)
+	}
+	return nil
+}
+
+// runAfterMeetingHook runs HOOK_AFTER_MEETING once a meeting was archived
+// and, if it was, deleted. A failed hook fails the run but changes nothing
+// about the meeting.
+func (run *backupRun) runAfterMeetingHook(ctx context.Context, mtg meeting, files []archivedFile, complete, deleted bool) {
+	if run.cfg.HookAfterMeeting == "" {
+		return
+	}
+	hm := &hookMeeting{
+		UUID:      mtg.ID,
+		Topic:     mtg.Topic,
+		StartTime: mtg.StartTime,
+		HostEmail: mtg.HostEmail,
+		Objects:   []string{},
+		Complete:  complete,
+		Deleted:   deleted,
+	}
+	for _, file := range files {
+		hm.Objects = append(hm.Objects, file.attrs.Name)
+	}
+	if err := runHook(ctx, run.cfg, hookAfterMeeting, run.cfg.HookAfterMeeting, hookContext{Meeting: hm}); err != nil {
+		err = fmt.Errorf("Could not run the after meeting hook for %s: %v", mtg.ID, err)
+		log.Println(err)
+		run.report.fail(err)
+	}
+}
+
+// runAfterRunHook runs HOOK_AFTER_RUN with the finished report of the job.
+// It runs for cancelled runs too, so it does not derive from the run's
+// context. Failures are logged.
+func runAfterRunHook(cfg *config, report *runReport) {
+	if cfg.HookAfterRun == "" {
+		return
+	}
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	if err := runHook(context.Background(), cfg, hookAfterRun, cfg.HookAfterRun, hookContext{Report: report}); err != nil {
+		log.Println("Could not run the after run hook:", err)
+	}
+}
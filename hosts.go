@@ -0,0 +1,50 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// displayName is the name Zoom shows for the user.
+func (u *zoomUser) displayName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// resolveHosts fills in the email and name of the host of every meeting from
+// the Zoom users API for RESOLVE_HOSTS, looking each host up once. Hosts that
+// cannot be looked up keep what the recordings API returned.
+func (run *backupRun) resolveHosts(ctx context.Context, meetings []meeting) {
+	hosts := map[string]*zoomUser{}
+	for i := range meetings {
+		id := meetings[i].HostID
+		if id == "" {
+			continue
+		}
+		user, ok := hosts[id]
+		if !ok {
+			user = &zoomUser{}
+			if err := run.lookupUser(ctx, id, user); err != nil {
+				log.Println("Could not look up host", id, err)
+				user = nil
+			}
+			hosts[id] = user
+		}
+		if user == nil {
+			continue
+		}
+		if meetings[i].HostEmail == "" {
+			meetings[i].HostEmail = user.Email
+		}
+		meetings[i].HostName = user.displayName()
+	}
+}
+
+func (run *backupRun) lookupUser(ctx context.Context, id string, user *zoomUser) error {
+	return run.zoom.getJSON(ctx, fmt.Sprintf(zoomUserPath, url.PathEscape(id)), user)
+}
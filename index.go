@@ -0,0 +1,475 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// indexRetryBackoff is multiplied by the attempt between index retries.
+const indexRetryBackoff = 2 * time.Second
+
+// indexGroupByHost makes INDEX_GROUP_BY list the meetings of every host under
+// a heading of its own.
+const indexGroupByHost = "host"
+
+const defaultIndexTemplate = `<html><head><title>{{.Title}}</title></head><body><h2>{{.Title}}</h2>
+{{- if .SearchIndex}}
+<p><input type="search" placeholder="Search transcripts" oninput="searchTranscripts(this.value)"></p>
+<ul id="search-results"></ul>
+<script>
+var searchIndex = null;
+function searchTranscripts(query) {
+  if (searchIndex === null) {
+    searchIndex = fetch({{.SearchIndex}}).then(function (r) { return r.json(); });
+  }
+  searchIndex.then(function (index) {
+    var results = document.getElementById("search-results");
+    results.innerHTML = "";
+    var words = query.toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(function (w) { return w.length > 1; });
+    if (words.length === 0) {
+      return;
+    }
+    var found = null;
+    words.forEach(function (word) {
+      var docs = {};
+      Object.keys(index.terms).forEach(function (term) {
+        if (term.indexOf(word) === 0) {
+          index.terms[term].forEach(function (i) { docs[i] = true; });
+        }
+      });
+      found = found === null ? docs : Object.keys(found).reduce(function (both, i) {
+        if (docs[i]) { both[i] = true; }
+        return both;
+      }, {});
+    });
+    Object.keys(found).slice(0, 100).forEach(function (i) {
+      var doc = index.docs[i], li = document.createElement("li"), a = document.createElement("a");
+      a.href = doc.url;
+      a.textContent = (doc.topic || doc.name) + (doc.date ? " \u00b7 " + doc.date : "");
+      li.appendChild(a);
+      results.appendChild(li);
+    });
+  });
+}
+</script>
+{{- end}}
+{{- if .Partial}}
+<p><strong>This index is incomplete because the archive could not be listed completely.</strong></p>
+{{- end}}
+{{- if .Hosts}}
+{{- range .Hosts}}
+<h2>{{if .Name}}{{.Name}} &middot; {{end}}{{if .Email}}{{.Email}}{{else}}Unknown host{{end}}</h2>
+{{- template "groups" .Groups}}
+{{- end}}
+{{- else}}
+{{- template "groups" .Groups}}
+{{- end}}
+</body></html>
+{{- define "groups"}}
+{{- range .}}
+<h3>{{if .Topic}}{{.Topic}} &middot; {{end}}{{if not .Date.IsZero}}{{.Date.Format "Mon, Jan 2 2006"}}{{else}}{{.Folder}}{{end}}</h3>
+<ul>
+{{- range .Entries}}
+<li>{{if .Thumbnail}}<a href="{{.URL}}"><img src="{{.Thumbnail}}" alt="" loading="lazy"></a><br>{{end}}<a href="{{.URL}}">{{base .Name}}</a> &ndash; {{if .RecordingType}}{{.RecordingType}}, {{end}}{{bytes .Size}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}`
+
+// indexData is handed to the index template.
+type indexData struct {
+	Title       string
+	Bucket      string
+	GeneratedAt time.Time
+	// Entries lists every object, Groups the same objects grouped by
+	// meeting folder with the newest meetings first.
+	Entries []indexEntry
+	Groups  []indexGroup
+	// Hosts holds the same groups by host with INDEX_GROUP_BY host.
+	Hosts []indexHost
+	// Partial is set when the bucket could not be listed completely, so
+	// Entries lacks some objects.
+	Partial bool
+	// SearchIndex is the URL of the transcript search index with
+	// SEARCH_INDEX.
+	SearchIndex string
+}
+
+// indexGroup collects the objects of one meeting folder.
+type indexGroup struct {
+	Folder   string
+	Topic    string
+	Host     string
+	HostName string
+	Date     time.Time
+	Size     int64
+	Entries  []indexEntry
+}
+
+// indexHost collects the meeting folders of one host, by host email.
+type indexHost struct {
+	Email  string
+	Name   string
+	Size   int64
+	Groups []indexGroup
+}
+
+// indexEntry describes one archived object. Topic, date and duration come
+// from the metadata written at upload time and fall back to what can be
+// parsed from the object name for older uploads.
+type indexEntry struct {
+	Name          string
+	URL           string
+	Topic         string
+	Date          time.Time
+	Duration      time.Duration
+	FileType      string
+	RecordingType string
+	Size          int64
+	// Host and HostName are the email and name of the meeting's host, empty
+	// for uploads that did not record them.
+	Host     string
+	HostName string
+	// Thumbnail is the URL of the recording's thumbnail with THUMBNAILS.
+	Thumbnail string
+}
+
+var indexTemplateFuncs = template.FuncMap{
+	"bytes": humanBytes,
+	"base":  path.Base,
+}
+
+// builtinIndexTemplate renders the index when INDEX_TEMPLATE is unset or
+// cannot be used.
+var builtinIndexTemplate = template.Must(template.New("index").Funcs(indexTemplateFuncs).Parse(defaultIndexTemplate))
+
+// generateURLSListHTML regenerates the index. Listing the bucket and writing
+// the index are retried up to INDEX_RETRIES times. Instead of leaving a stale
+// index behind it degrades: a broken INDEX_TEMPLATE is replaced by the
+// built-in template and a listing that keeps failing is published with the
+// objects listed so far, marked as partial. The returned error describes
+// what went wrong even when an index was written.
+func generateURLSListHTML(ctx context.Context, storageClient *storage.Client, cfg *config) error {
+	var problems []string
+	tmpl, err := loadIndexTemplate(ctx, storageClient, cfg.IndexTemplate)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("%v, used the built-in template", err))
+		tmpl = builtinIndexTemplate
+	}
+
+	data := indexData{
+		Title:       cfg.IndexTitle,
+		Bucket:      cfg.Bucket,
+		GeneratedAt: time.Now().In(cfg.location),
+	}
+	err = retryIndex(ctx, cfg, func() error {
+		data.Entries, err = listIndexEntries(ctx, storageClient, cfg)
+		return err
+	})
+	if err != nil {
+		if len(data.Entries) == 0 {
+			return fmt.Errorf("%s: %w", strings.Join(append(problems, "listing the bucket failed"), "; "), err)
+		}
+		problems = append(problems, fmt.Sprintf("published a partial index of %d objects: %v", len(data.Entries), err))
+		data.Partial = true
+	}
+	data.Groups = groupIndexEntries(data.Entries)
+	if cfg.IndexGroupBy == indexGroupByHost {
+		data.Hosts = groupIndexHosts(data.Groups)
+	}
+
+	if cfg.SearchIndex {
+		err = retryIndex(ctx, cfg, func() error {
+			return writeSearchIndex(ctx, storageClient, cfg)
+		})
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			data.SearchIndex = fmt.Sprintf("http://%s/%s", cfg.Bucket, cfg.objectName(cfg.SearchIndexName))
+		}
+	}
+
+	html := new(bytes.Buffer)
+	if err := tmpl.Execute(html, data); err != nil {
+		if tmpl == builtinIndexTemplate {
+			return fmt.Errorf("failed to render index template: %w", err)
+		}
+		problems = append(problems, fmt.Sprintf("failed to render index template, used the built-in template: %v", err))
+		html.Reset()
+		if err := builtinIndexTemplate.Execute(html, data); err != nil {
+			return fmt.Errorf("failed to render index template: %w", err)
+		}
+	}
+
+	err = retryIndex(ctx, cfg, func() error {
+		return writeIndex(ctx, storageClient, cfg, html.Bytes())
+	})
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// retryIndex calls f until it succeeds, at most INDEX_RETRIES more times.
+func retryIndex(ctx context.Context, cfg *config, f func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= cfg.IndexRetries || ctx.Err() != nil {
+			return err
+		}
+		log.Println("Retrying index after", err)
+		select {
+		case <-time.After(time.Duration(attempt+1) * indexRetryBackoff):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// listIndexEntries lists the archived objects. On failure the entries listed
+// so far are returned along with the error.
+func listIndexEntries(ctx context.Context, storageClient *storage.Client, cfg *config) ([]indexEntry, error) {
+	query := &storage.Query{}
+	if cfg.Prefix != "" {
+		query.Prefix = cfg.Prefix + "/"
+	}
+	var entries []indexEntry
+	thumbnails := map[string]bool{}
+	it := storageClient.Bucket(cfg.Bucket).Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return linkThumbnails(cfg.Bucket, entries, thumbnails), fmt.Errorf("Bucket(%q).Objects: %v", cfg.Bucket, err)
+		}
+		if isThumbnail(attrs.Name) {
+			thumbnails[attrs.Name] = true
+			continue
+		}
+		if isInternalObject(cfg, attrs.Name) {
+			continue
+		}
+		entries = append(entries, newIndexEntry(cfg.Bucket, cfg.location, attrs))
+	}
+	return linkThumbnails(cfg.Bucket, entries, thumbnails), nil
+}
+
+// linkThumbnails points the entries at the thumbnails listed next to them.
+func linkThumbnails(bucket string, entries []indexEntry, thumbnails map[string]bool) []indexEntry {
+	for i, entry := range entries {
+		if name := thumbnailName(entry.Name); thumbnails[name] {
+			entries[i].Thumbnail = fmt.Sprintf("http://%s/%s", bucket, name)
+		}
+	}
+	return entries
+}
+
+func writeIndex(ctx context.Context, storageClient *storage.Client, cfg *config, html []byte) error {
+	obj := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.IndexFileName))
+	wc := obj.NewWriter(ctx)
+	wc.ContentType = "text/html; charset=utf-8"
+	if _, err := io.Copy(wc, bytes.NewReader(html)); err != nil {
+		_ = wc.Close()
+		return fmt.Errorf("Could not write file: %v", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("error closing: %w", err)
+	}
+	return nil
+}
+
+// loadIndexTemplate parses the index template from a local file or a
+// gs://bucket/object URL, falling back to the built-in list when no source is
+// configured.
+func loadIndexTemplate(ctx context.Context, storageClient *storage.Client, source string) (*template.Template, error) {
+	text := defaultIndexTemplate
+	if source != "" {
+		var raw []byte
+		var err error
+		if strings.HasPrefix(source, "gs://") {
+			raw, err = readGCSObject(ctx, storageClient, source)
+		} else {
+			raw, err = ioutil.ReadFile(source)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index template %s: %w", source, err)
+		}
+		text = string(raw)
+	}
+
+	tmpl, err := template.New("index").Funcs(indexTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// readGCSObject reads a whole object addressed as gs://bucket/object.
+func readGCSObject(ctx context.Context, storageClient *storage.Client, gsURL string) ([]byte, error) {
+	parts := strings.SplitN(strings.TrimPrefix(gsURL, "gs://"), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid GCS URL %q, expected gs://bucket/object", gsURL)
+	}
+	r, err := storageClient.Bucket(parts[0]).Object(parts[1]).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// newIndexEntry builds the entry of an archived object with its date in loc.
+func newIndexEntry(bucket string, loc *time.Location, attrs *storage.ObjectAttrs) indexEntry {
+	entry := indexEntry{
+		Name:          attrs.Name,
+		URL:           fmt.Sprintf("http://%s/%s", bucket, attrs.Name),
+		Topic:         attrs.Metadata["topic"],
+		RecordingType: attrs.Metadata["recording_type"],
+		Host:          attrs.Metadata["host_email"],
+		HostName:      attrs.Metadata["host_name"],
+		FileType:      strings.ToUpper(strings.TrimPrefix(path.Ext(attrs.Name), ".")),
+		Size:          attrs.Size,
+	}
+
+	if minutes, err := strconv.Atoi(attrs.Metadata["duration"]); err == nil {
+		entry.Duration = time.Duration(minutes) * time.Minute
+	}
+	if start, err := time.Parse(time.RFC3339, attrs.Metadata["start_time"]); err == nil {
+		entry.Date = start.In(loc)
+	}
+
+	if m := meetingFolderPattern.FindStringSubmatch(path.Base(path.Dir(attrs.Name))); m != nil {
+		if entry.Date.IsZero() {
+			if date, err := time.ParseInLocation(dateFormatTo, m[2], loc); err == nil {
+				entry.Date = date
+			}
+		}
+		if entry.Topic == "" {
+			entry.Topic = m[1]
+		}
+	}
+
+	return entry
+}
+
+// isInternalObject reports whether the object is bookkeeping written by the
+// backup itself rather than an archived recording.
+func isInternalObject(cfg *config, name string) bool {
+	if isJobObject(cfg, name) {
+		return true
+	}
+	switch strings.TrimSuffix(path.Base(name), zstdExt) {
+	case cfg.MeetingSidecarName, cfg.MeetingBundleName, "participants.json", "participants.csv", "qa.json", "polls.json", transcriptName:
+		return true
+	}
+	return strings.HasPrefix(name, cfg.objectName(canaryObjectPrefix)) || strings.HasPrefix(name, cfg.objectName(publishedObjectPrefix)) || isDebugResponse(cfg, name) || isChecksumFile(cfg, name) || isThumbnail(name) || isChatExport(cfg, name)
+}
+
+// isJobObject reports whether the object is one of the job's own files, such
+// as the manifest, the status or the index, rather than belonging to a
+// meeting.
+func isJobObject(cfg *config, name string) bool {
+	for _, internal := range []string{cfg.IndexFileName, cfg.SearchIndexName, cfg.ManifestFileName, cfg.MetricsObject, cfg.SLOReportObject, cfg.ControlObject, cfg.StatusObject, cfg.FeedFileName, cfg.ExpiryForecastObject, cfg.CheckpointObject} {
+		if internal != "" && name == cfg.objectName(internal) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupIndexEntries groups entries by their folder, newest meetings first.
+// Within a group entries are ordered by name, which starts with the recording
+// start time.
+func groupIndexEntries(entries []indexEntry) []indexGroup {
+	var groups []indexGroup
+	byFolder := map[string]int{}
+	for _, entry := range entries {
+		folder := path.Dir(entry.Name)
+		i, ok := byFolder[folder]
+		if !ok {
+			i = len(groups)
+			byFolder[folder] = i
+			groups = append(groups, indexGroup{
+				Folder:   folder,
+				Topic:    entry.Topic,
+				Host:     entry.Host,
+				HostName: entry.HostName,
+				Date:     entry.Date,
+			})
+		}
+		groups[i].Size += entry.Size
+		groups[i].Entries = append(groups[i].Entries, entry)
+	}
+
+	for _, group := range groups {
+		sort.Slice(group.Entries, func(a, b int) bool {
+			return group.Entries[a].Name < group.Entries[b].Name
+		})
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		if !groups[a].Date.Equal(groups[b].Date) {
+			return groups[a].Date.After(groups[b].Date)
+		}
+		return groups[a].Folder < groups[b].Folder
+	})
+	return groups
+}
+
+// groupIndexHosts groups the meeting folders by host, hosts ordered by email
+// with unknown hosts last. Each host keeps the order of groups.
+func groupIndexHosts(groups []indexGroup) []indexHost {
+	var hosts []indexHost
+	byEmail := map[string]int{}
+	for _, group := range groups {
+		i, ok := byEmail[group.Host]
+		if !ok {
+			i = len(hosts)
+			byEmail[group.Host] = i
+			hosts = append(hosts, indexHost{Email: group.Host})
+		}
+		if hosts[i].Name == "" {
+			hosts[i].Name = group.HostName
+		}
+		hosts[i].Size += group.Size
+		hosts[i].Groups = append(hosts[i].Groups, group)
+	}
+	sort.SliceStable(hosts, func(a, b int) bool {
+		if (hosts[a].Email == "") != (hosts[b].Email == "") {
+			return hosts[b].Email == ""
+		}
+		return hosts[a].Email < hosts[b].Email
+	})
+	return hosts
+}
+
+func humanBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,47 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// loadInputMeetings reads a previously saved list recordings response from a
+// local file or gs:// URL instead of asking Zoom which recordings exist. The
+// file may hold the API response itself or just its array of meetings, which
+// allows replaying past discovery output or hand-picking meetings to process
+// again.
+func loadInputMeetings(ctx context.Context, storageClient *storage.Client, source string) ([]meeting, error) {
+	var raw []byte
+	var err error
+	if strings.HasPrefix(source, "gs://") {
+		raw, err = readGCSObject(ctx, storageClient, source)
+	} else {
+		raw, err = ioutil.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input %s: %w", source, err)
+	}
+
+	raw = bytes.TrimSpace(raw)
+	if bytes.HasPrefix(raw, []byte("[")) {
+		raw, err = json.Marshal(map[string]json.RawMessage{"meetings": raw})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	meetings, _, err := parseRecordingList(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input %s: %w", source, err)
+	}
+	for i := range meetings {
+		meetings[i].UserID = meetings[i].HostID
+	}
+	return meetings, nil
+}
@@ -0,0 +1,244 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"gopkg.in/yaml.v2"
+)
+
+// jobsFile is the layout of JOBS_CONFIG. Each job is a partial config using
+// the yaml names of the config fields; anything a job leaves out is taken from
+// the environment. Weekdays holds further overrides for every job by day, see
+// weekdayPolicies.
+type jobsFile struct {
+	Weekdays weekdayPolicies `yaml:"weekdays"`
+	Jobs     []yaml.MapSlice `yaml:"jobs"`
+}
+
+// weekdayPolicies are overrides applied on some days only, keyed by the
+// lower-cased day name, "weekdays" (Monday to Friday) or "weekend". A day's
+// own policy is applied after the weekdays or weekend one.
+type weekdayPolicies map[string]yaml.MapSlice
+
+// forDay returns the names and overrides of the policies that apply on day.
+func (p weekdayPolicies) forDay(day time.Weekday) ([]string, []yaml.MapSlice) {
+	group := "weekdays"
+	if day == time.Saturday || day == time.Sunday {
+		group = "weekend"
+	}
+	var names []string
+	var overrides []yaml.MapSlice
+	for _, name := range []string{group, strings.ToLower(day.String())} {
+		if policy, ok := p[name]; ok {
+			names = append(names, name)
+			overrides = append(overrides, policy)
+		}
+	}
+	return names, overrides
+}
+
+func (p weekdayPolicies) validate() error {
+	for name := range p {
+		switch name {
+		case "weekdays", "weekend", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday":
+		default:
+			return fmt.Errorf("unknown weekday policy %q", name)
+		}
+	}
+	return nil
+}
+
+// loadJobs returns the jobs to run in order, with the weekday policies of
+// today applied. Without JOBS_CONFIG the environment describes the one and
+// only job, and without any listed jobs the environment describes the one job
+// the weekday policies apply to.
+func loadJobs(ctx context.Context, storageClient *storage.Client, base *config) ([]*config, error) {
+	if base.JobsConfig == "" {
+		if err := resolveSecrets(ctx, base); err != nil {
+			return nil, err
+		}
+		if err := base.validate(); err != nil {
+			return nil, err
+		}
+		return []*config{base}, nil
+	}
+
+	var raw []byte
+	var err error
+	if strings.HasPrefix(base.JobsConfig, "gs://") {
+		raw, err = readGCSObject(ctx, storageClient, base.JobsConfig)
+	} else {
+		raw, err = ioutil.ReadFile(base.JobsConfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs config %s: %w", base.JobsConfig, err)
+	}
+
+	file := &jobsFile{}
+	if err := yaml.Unmarshal(raw, file); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs config %s: %w", base.JobsConfig, err)
+	}
+	if len(file.Jobs) == 0 && len(file.Weekdays) == 0 {
+		return nil, fmt.Errorf("jobs config %s does not list any jobs", base.JobsConfig)
+	}
+	if err := file.Weekdays.validate(); err != nil {
+		return nil, fmt.Errorf("jobs config %s: %w", base.JobsConfig, err)
+	}
+	if len(file.Jobs) == 0 {
+		job, err := applyWeekdayPolicies(base, file.Weekdays, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := resolveSecrets(ctx, job); err != nil {
+			return nil, err
+		}
+		if err := job.validate(); err != nil {
+			return nil, err
+		}
+		return []*config{job}, nil
+	}
+
+	var jobs []*config
+	names := map[string]bool{}
+	for i, overrides := range file.Jobs {
+		overrides, jobPolicies, err := splitWeekdayPolicies(overrides)
+		if err != nil {
+			return nil, fmt.Errorf("job %d: %w", i+1, err)
+		}
+		job, err := applyOverrides(base, overrides)
+		if err != nil {
+			return nil, fmt.Errorf("job %d: %w", i+1, err)
+		}
+		if job.JobName == "" || job.JobName == base.JobName {
+			job.JobName = fmt.Sprintf("job-%d", i+1)
+		}
+		if job, err = applyWeekdayPolicies(job, file.Weekdays, jobPolicies); err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.JobName, err)
+		}
+		if names[job.JobName] {
+			return nil, fmt.Errorf("job %d: duplicate job name %q", i+1, job.JobName)
+		}
+		names[job.JobName] = true
+
+		if err := resolveSecrets(ctx, job); err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.JobName, err)
+		}
+		if err := job.validate(); err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.JobName, err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := checkSharedObjects(jobs); err != nil {
+		return nil, fmt.Errorf("jobs config %s: %w", base.JobsConfig, err)
+	}
+	return jobs, nil
+}
+
+// splitWeekdayPolicies takes a job's own weekday policies out of its
+// overrides.
+func splitWeekdayPolicies(overrides yaml.MapSlice) (yaml.MapSlice, weekdayPolicies, error) {
+	var rest yaml.MapSlice
+	var policies weekdayPolicies
+	for _, item := range overrides {
+		if item.Key != "weekdays" {
+			rest = append(rest, item)
+			continue
+		}
+		raw, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := yaml.UnmarshalStrict(raw, &policies); err != nil {
+			return nil, nil, fmt.Errorf("invalid weekdays: %w", err)
+		}
+		if err := policies.validate(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return rest, policies, nil
+}
+
+// applyWeekdayPolicies layers the policies for today in the job's TIMEZONE
+// over the job, those shared by all jobs before the job's own.
+func applyWeekdayPolicies(job *config, shared, own weekdayPolicies) (*config, error) {
+	location, err := time.LoadLocation(job.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TIMEZONE %q: %w", job.TimeZone, err)
+	}
+	day := time.Now().In(location).Weekday()
+	var applied []string
+	for _, policies := range []weekdayPolicies{shared, own} {
+		names, overrides := policies.forDay(day)
+		for i, override := range overrides {
+			if job, err = applyOverrides(job, override); err != nil {
+				return nil, fmt.Errorf("weekday policy %s: %w", names[i], err)
+			}
+		}
+		applied = append(applied, names...)
+	}
+	if len(applied) > 0 {
+		job.policy = strings.Join(applied, ",")
+		log.Printf("Job %s applies the %s weekday policy", job.JobName, job.policy)
+	}
+	return job, nil
+}
+
+// applyOverrides layers a job's yaml settings over a copy of base. Automatic
+// upload chunks are sized again for the job's memory limit and upload
+// concurrency unless the job sets upload_chunk_size.
+func applyOverrides(base *config, overrides yaml.MapSlice) (*config, error) {
+	raw, err := yaml.Marshal(overrides)
+	if err != nil {
+		return nil, err
+	}
+	job := base.clone()
+	if err := yaml.UnmarshalStrict(raw, job); err != nil {
+		return nil, err
+	}
+	for _, item := range overrides {
+		if item.Key == "upload_chunk_size" {
+			job.uploadChunkAuto = false
+		}
+	}
+	if job.uploadChunkAuto {
+		job.UploadChunkSize = autoUploadChunkSize(memoryLimit(job.MemoryLimit), job.UploadConcurrency)
+	}
+	return job, nil
+}
+
+// checkSharedObjects rejects jobs that would keep their status, checkpoint,
+// control object or manifest in the same object, where each would overwrite
+// what the other wrote.
+func checkSharedObjects(jobs []*config) error {
+	owners := map[string]string{}
+	for _, job := range jobs {
+		objects := [][2]string{
+			{"status_object", job.StatusObject},
+			{"control_object", job.ControlObject},
+		}
+		if len(job.allowedHours) > 0 {
+			objects = append(objects, [2]string{"checkpoint_object", job.CheckpointObject})
+		}
+		if job.ManifestEnabled {
+			objects = append(objects, [2]string{"manifest_file_name", job.ManifestFileName})
+		}
+		for _, object := range objects {
+			setting, name := object[0], object[1]
+			if name == "" {
+				continue
+			}
+			url := "gs://" + job.Bucket + "/" + job.objectName(name)
+			if owner, ok := owners[url]; ok && owner != job.JobName {
+				return fmt.Errorf("jobs %s and %s would share %s, set a gstorage_path or %s per job", owner, job.JobName, url, setting)
+			}
+			owners[url] = job.JobName
+		}
+	}
+	return nil
+}
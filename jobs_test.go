@@ -0,0 +1,104 @@
+package zoombackup
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadTestJobs loads the jobs of a JOBS_CONFIG with jobsYAML over a base
+// job archiving user "me" into the bucket "archive".
+func loadTestJobs(t *testing.T, env map[string]string, jobsYAML string) ([]*config, error) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "jobs.yaml")
+	if err := ioutil.WriteFile(path, []byte(jobsYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	base := map[string]string{
+		"ZOOM_ACCOUNT_ID":    "account",
+		"ZOOM_CLIENT_ID":     "client",
+		"ZOOM_CLIENT_SECRET": "secret",
+		"ZOOM_USER_ID":       "me",
+		"GSTORAGE_BUCKET":    "archive",
+		"JOBS_CONFIG":        path,
+	}
+	for key, value := range env {
+		base[key] = value
+	}
+	return loadJobs(context.Background(), nil, loadTestConfig(t, base))
+}
+
+func TestLoadJobsRejectsSharedObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		jobs    string
+		wantErr string
+	}{
+		{
+			name:    "same bucket and path",
+			jobs:    "jobs:\n  - name: sales\n  - name: support\n",
+			wantErr: "jobs sales and support would share gs://archive/status.json",
+		},
+		{
+			name: "own paths",
+			jobs: "jobs:\n  - name: sales\n    gstorage_path: sales\n  - name: support\n    gstorage_path: support\n",
+		},
+		{
+			name: "own buckets",
+			jobs: "jobs:\n  - name: sales\n  - name: support\n    gstorage_bucket: support-archive\n",
+		},
+		{
+			name:    "same manifest",
+			env:     map[string]string{"STATUS_OBJECT": "", "CONTROL_OBJECT": ""},
+			jobs:    "jobs:\n  - name: sales\n  - name: support\n",
+			wantErr: "would share gs://archive/index.json, set a gstorage_path or manifest_file_name per job",
+		},
+		{
+			name: "own objects",
+			env:  map[string]string{"MANIFEST_ENABLED": "false"},
+			jobs: "jobs:\n  - name: sales\n    status_object: sales.json\n    control_object: sales-control.json\n  - name: support\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTestJobs(t, tt.env, tt.jobs)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("got %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadJobsSizesAutomaticUploadChunksPerJob(t *testing.T) {
+	jobs, err := loadTestJobs(t, map[string]string{"MEMORY_LIMIT": "64M"}, `jobs:
+  - name: base
+    gstorage_path: base
+  - name: concurrent
+    gstorage_path: concurrent
+    upload_concurrency: 8
+  - name: fixed
+    gstorage_path: fixed
+    upload_concurrency: 8
+    upload_chunk_size: 262144
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{16 << 20, 2 << 20, 256 << 10} {
+		if got := jobs[i].UploadChunkSize; got != want {
+			t.Errorf("job %s: got chunks of %d, want %d", jobs[i].JobName, got, want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// manifest is the machine readable counterpart of the HTML index. It is kept
+// in the bucket and updated in place by every run, so it describes the whole
+// archive rather than just the latest run.
+type manifest struct {
+	UpdatedAt time.Time         `json:"updated_at"`
+	Bucket    string            `json:"bucket"`
+	Meetings  []manifestMeeting `json:"meetings"`
+}
+
+type manifestMeeting struct {
+	UUID      string `json:"uuid"`
+	Topic     string `json:"topic"`
+	StartTime string `json:"start_time"`
+	Duration  int    `json:"duration"`
+	Folder    string `json:"folder"`
+	// Hold protects every file of the meeting from retention. It is set by
+	// hand and kept when the meeting is archived again.
+	Hold  bool           `json:"hold,omitempty"`
+	Files []manifestFile `json:"files"`
+	// Zoom is the meeting exactly as returned by the recordings API the last
+	// time it was archived.
+	Zoom json.RawMessage `json:"zoom,omitempty"`
+}
+
+type manifestFile struct {
+	// ID is Zoom's ID of the recording file.
+	ID             string    `json:"id,omitempty"`
+	Object         string    `json:"object"`
+	URL            string    `json:"url"`
+	RecordingStart string    `json:"recording_start"`
+	RecordingType  string    `json:"recording_type"`
+	FileType       string    `json:"file_type"`
+	Segment        int       `json:"segment,omitempty"`
+	Size           int64     `json:"size"`
+	MD5            string    `json:"md5,omitempty"`
+	CRC32C         string    `json:"crc32c,omitempty"`
+	ArchivedAt     time.Time `json:"archived_at"`
+	// Hold protects the file from retention.
+	Hold bool `json:"hold,omitempty"`
+}
+
+// manifestRecorder collects the files archived during a run.
+type manifestRecorder struct {
+	mu       sync.Mutex
+	meetings []manifestMeeting
+}
+
+func (m *manifestRecorder) record(mtg meeting, recording recordingFile, attrs *storage.ObjectAttrs) {
+	file := manifestFile{
+		ID:             recording.ID,
+		RecordingStart: recording.RecordingStart,
+		RecordingType:  recording.RecordingType,
+		FileType:       recording.FileType,
+		Segment:        recording.Segment,
+		ArchivedAt:     time.Now().UTC(),
+	}
+	if attrs != nil {
+		file.Object = attrs.Name
+		file.URL = fmt.Sprintf("gs://%s/%s", attrs.Bucket, attrs.Name)
+		file.Size = attrs.Size
+		file.MD5 = hex.EncodeToString(attrs.MD5)
+		file.CRC32C = fmt.Sprintf("%08x", attrs.CRC32C)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.meetings {
+		if m.meetings[i].UUID == mtg.ID {
+			m.meetings[i].Files = append(m.meetings[i].Files, file)
+			return
+		}
+	}
+	m.meetings = append(m.meetings, manifestMeeting{
+		UUID:      mtg.ID,
+		Topic:     mtg.Topic,
+		StartTime: mtg.StartTime,
+		Duration:  mtg.Duration,
+		Folder:    path.Dir(file.Object),
+		Files:     []manifestFile{file},
+		Zoom:      mtg.Zoom,
+	})
+}
+
+// updateManifest merges the files archived during this run into the manifest
+// stored in the bucket, again on the latest manifest when another run wrote
+// it in between.
+func updateManifest(ctx context.Context, storageClient *storage.Client, cfg *config, archived *manifestRecorder) error {
+	archived.mu.Lock()
+	runMeetings := append([]manifestMeeting(nil), archived.meetings...)
+	archived.mu.Unlock()
+
+	obj := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.ManifestFileName))
+	return retryOnConflict(ctx, cfg.ManifestFileName, func() error {
+		m, generation, err := readManifest(ctx, storageClient, cfg)
+		if err != nil {
+			return err
+		}
+		// merge changes the files of the meetings it is given.
+		meetings := make([]manifestMeeting, len(runMeetings))
+		for i, mtg := range runMeetings {
+			mtg.Files = append([]manifestFile(nil), mtg.Files...)
+			meetings[i] = mtg
+		}
+		m.merge(meetings)
+		m.UpdatedAt = time.Now().UTC()
+		m.Bucket = cfg.Bucket
+
+		return writeJSONObject(ctx, ifGeneration(obj, generation), m)
+	})
+}
+
+// loadManifest reads the manifest from the bucket, returning an empty one when
+// none has been written yet.
+func loadManifest(ctx context.Context, storageClient *storage.Client, cfg *config) (*manifest, error) {
+	m, _, err := readManifest(ctx, storageClient, cfg)
+	return m, err
+}
+
+// readManifest is loadManifest that also returns the generation of the
+// manifest, 0 when none has been written yet.
+func readManifest(ctx context.Context, storageClient *storage.Client, cfg *config) (*manifest, int64, error) {
+	m := &manifest{}
+	raw, generation, err := readObject(ctx, storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.ManifestFileName)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if raw == nil {
+		return m, 0, nil
+	}
+	if err := json.Unmarshal(raw, m); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	return m, generation, nil
+}
+
+// merge upserts meetings by UUID and their files by object name, keeping the
+// manifest ordered by meeting start time, newest first.
+func (m *manifest) merge(meetings []manifestMeeting) {
+	for _, incoming := range meetings {
+		i := m.meetingIndex(incoming.UUID)
+		if i < 0 {
+			m.Meetings = append(m.Meetings, incoming)
+			continue
+		}
+
+		existing := &m.Meetings[i]
+		files := incoming.Files
+		for _, file := range existing.Files {
+			if j := objectIndex(incoming.Files, file.Object); j < 0 {
+				files = append(files, file)
+			} else {
+				files[j].Hold = files[j].Hold || file.Hold
+			}
+		}
+		sort.Slice(files, func(a, b int) bool { return files[a].Object < files[b].Object })
+
+		incoming.Files = files
+		incoming.Hold = incoming.Hold || existing.Hold
+		*existing = incoming
+	}
+
+	sort.SliceStable(m.Meetings, func(a, b int) bool {
+		return strings.Compare(m.Meetings[a].StartTime, m.Meetings[b].StartTime) > 0
+	})
+}
+
+func (m *manifest) meetingIndex(uuid string) int {
+	for i := range m.Meetings {
+		if m.Meetings[i].UUID == uuid {
+			return i
+		}
+	}
+	return -1
+}
+
+func objectIndex(files []manifestFile, object string) int {
+	for i, file := range files {
+		if file.Object == object {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,128 @@
+package zoombackup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// meetingChatName is the object name of the structured in-meeting chat
+// within the meeting folder, next to the chat TXT files Zoom recorded.
+const meetingChatName = "chat.json"
+
+// chatLinePattern matches the line that starts a message of Zoom's in-meeting
+// chat TXT, in the older layout with the message on the same line,
+// "00:01:02\t From  Ann Lee : Hello", as well as the newer one with the
+// message on the following lines, "00:01:02 From Ann Lee to Everyone:".
+var chatLinePattern = regexp.MustCompile(`^(\d{1,2}):(\d{2}):(\d{2})\s+From\s+(.+?)(?:\s+to\s+(.+?))?\s*:\s?(.*)$`)
+
+// chatMessage is one message of the in-meeting chat.
+type chatMessage struct {
+	// Offset is the time since the recording started, as Zoom wrote it.
+	Offset string `json:"offset"`
+	// Time is the start of the recording plus Offset.
+	Time      *time.Time `json:"time,omitempty"`
+	Sender    string     `json:"sender"`
+	Recipient string     `json:"recipient,omitempty"`
+	Message   string     `json:"message"`
+}
+
+// parseMeetingChat turns Zoom's in-meeting chat TXT of a recording that
+// started at start into its messages, keeping the names of senders and
+// recipients as Zoom wrote them. Lines that do not start a message continue
+// the previous one.
+func parseMeetingChat(raw []byte, start time.Time) []chatMessage {
+	messages := []chatMessage{}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if m := chatLinePattern.FindStringSubmatch(strings.TrimPrefix(line, "\ufeff")); m != nil {
+			hours, _ := strconv.Atoi(m[1])
+			minutes, _ := strconv.Atoi(m[2])
+			seconds, _ := strconv.Atoi(m[3])
+			msg := chatMessage{
+				Offset:    fmt.Sprintf("%02d:%s:%s", hours, m[2], m[3]),
+				Sender:    strings.TrimSpace(m[4]),
+				Recipient: strings.TrimSpace(m[5]),
+				Message:   strings.TrimSpace(m[6]),
+			}
+			if !start.IsZero() {
+				at := start.Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
+				msg.Time = &at
+			}
+			messages = append(messages, msg)
+			continue
+		}
+		text := strings.TrimSpace(line)
+		if text == "" || len(messages) == 0 {
+			continue
+		}
+		last := &messages[len(messages)-1]
+		if last.Message != "" {
+			last.Message += "\n"
+		}
+		last.Message += text
+	}
+	return messages
+}
+
+// meetingChatFileName is the object name of the meeting's ith chat TXT
+// within the meeting folder: chat.txt, then chat-2.txt and so on for meetings
+// recorded in several parts.
+func meetingChatFileName(i int) string {
+	if i == 0 {
+		return "chat.txt"
+	}
+	return fmt.Sprintf("chat-%d.txt", i+1)
+}
+
+// exportMeetingChat stores the in-meeting chat Zoom recorded for the meeting
+// in the meeting folder, every chat TXT as it was downloaded and all of their
+// messages together as chat.json.
+func (run *backupRun) exportMeetingChat(ctx context.Context, mtg meeting) error {
+	if len(mtg.Chat) == 0 {
+		return nil
+	}
+	folder, err := meetingFolder(run.cfg, mtg)
+	if err != nil {
+		return err
+	}
+	messages := []chatMessage{}
+	for i, file := range mtg.Chat {
+		body, _, err := run.zoom.downloadFile(ctx, file.DownloadURL, downloadTimeout(run.cfg, file.FileSize))
+		if err != nil {
+			return fmt.Errorf("failed to download chat: %w", err)
+		}
+		raw, err := ioutil.ReadAll(body)
+		_ = body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to download chat: %w", err)
+		}
+
+		txtName := run.cfg.objectName(path.Join(folder, meetingChatFileName(i)))
+		if err := run.writeMeetingFile(ctx, mtg, txtName, "CHAT", run.cfg.contentType("CHAT"), raw); err != nil {
+			return fmt.Errorf("failed to write %s: %w", txtName, err)
+		}
+		start, _ := time.Parse(time.RFC3339, file.RecordingStart)
+		messages = append(messages, parseMeetingChat(raw, start)...)
+	}
+
+	jsonName := run.cfg.objectName(path.Join(folder, meetingChatName))
+	raw, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := run.writeMeetingFile(ctx, mtg, jsonName, "JSON", run.cfg.contentType("JSON"), raw); err != nil {
+		return fmt.Errorf("failed to write %s: %w", jsonName, err)
+	}
+	return nil
+}
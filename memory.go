@@ -0,0 +1,69 @@
+package zoombackup
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// uploadChunkAuto sizes upload chunks from the memory available.
+const uploadChunkAuto = "auto"
+
+// unlimitedMemory is less than the huge number cgroup v1 reports for
+// containers without a limit.
+const unlimitedMemory = 1 << 60
+
+// memoryLimit is the memory the process may use: MEMORY_LIMIT, else what
+// Cloud Functions or the container's cgroup allows, or 0 when unknown.
+func memoryLimit(configured int64) int64 {
+	if configured > 0 {
+		return configured
+	}
+	if mb, err := strconv.ParseInt(os.Getenv("FUNCTION_MEMORY_MB"), 10, 64); err == nil && mb > 0 {
+		return mb << 20
+	}
+	for _, file := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		limit, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+		// cgroup v2 reports "max" without a limit.
+		if err != nil || limit <= 0 || limit >= unlimitedMemory {
+			return 0
+		}
+		return limit
+	}
+	return 0
+}
+
+// autoUploadChunkSize gives the upload chunks of all concurrent uploads a
+// quarter of the memory limit, leaving the rest to the runtime, downloads
+// and the other stages. Chunks stay between the 256KiB minimum and the 16MiB
+// default; without a known limit the default is used.
+func autoUploadChunkSize(limit int64, uploads int) int {
+	if limit <= 0 || uploads < 1 {
+		return googleapi.DefaultUploadChunkSize
+	}
+	chunk := limit / 4 / int64(uploads)
+	chunk -= chunk % googleapi.MinUploadChunkSize
+	switch {
+	case chunk < googleapi.MinUploadChunkSize:
+		return googleapi.MinUploadChunkSize
+	case chunk > googleapi.DefaultUploadChunkSize:
+		return googleapi.DefaultUploadChunkSize
+	}
+	return int(chunk)
+}
+
+// memoryInUse is the memory the Go runtime holds from the operating system
+// and has not returned, which is what counts against the limit.
+func memoryInUse() int64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return int64(stats.Sys - stats.HeapReleased)
+}
@@ -0,0 +1,194 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// transferRecord is the outcome of a single recording transfer. Records are
+// kept in the bucket across runs so regressions in the Zoom CDN or network
+// path show up over weeks rather than a single run.
+type transferRecord struct {
+	Time     time.Time     `json:"time"`
+	File     string        `json:"file"`
+	Success  bool          `json:"success"`
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"duration"`
+	Retries  int           `json:"retries"`
+	Error    string        `json:"error,omitempty"`
+	// MemoryBytes is the memory in use when the transfer ended.
+	MemoryBytes int64 `json:"memory_bytes,omitempty"`
+}
+
+// sloReport summarises the transfer records within the rolling window.
+type sloReport struct {
+	GeneratedAt         time.Time     `json:"generated_at"`
+	WindowStart         time.Time     `json:"window_start"`
+	Transfers           int           `json:"transfers"`
+	Failures            int           `json:"failures"`
+	SuccessRate         float64       `json:"success_rate"`
+	SuccessTarget       float64       `json:"success_target"`
+	TargetMet           bool          `json:"target_met"`
+	AvgThroughputBps    float64       `json:"avg_throughput_bytes_per_second"`
+	P95Duration         time.Duration `json:"p95_duration"`
+	TotalRetries        int           `json:"total_retries"`
+	TransfersWithRetry  int           `json:"transfers_with_retry"`
+	RunTransfers        int           `json:"run_transfers"`
+	RunFailures         int           `json:"run_failures"`
+	RunAvgThroughputBps float64       `json:"run_avg_throughput_bytes_per_second"`
+}
+
+type transferMetrics struct {
+	mu      sync.Mutex
+	records []transferRecord
+}
+
+// recordTransfer adds the memory in use to the transfer, logs it so runs
+// that come close to the memory limit show which files did, and records it.
+func (run *backupRun) recordTransfer(r transferRecord) {
+	r.MemoryBytes = memoryInUse()
+	log.Printf("Memory in use after %s: %s", r.File, humanBytes(r.MemoryBytes))
+	run.report.memorySampled(r.MemoryBytes)
+	run.metrics.record(r)
+}
+
+func (m *transferMetrics) record(r transferRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, r)
+}
+
+// saveTransferMetrics merges this run's records into the history stored in
+// the bucket, again on the latest history when another run wrote it in
+// between, drops records older than the SLO window and writes a fresh SLO
+// report next to the history.
+func saveTransferMetrics(ctx context.Context, storageClient *storage.Client, cfg *config, m *transferMetrics) error {
+	m.mu.Lock()
+	runRecords := append([]transferRecord(nil), m.records...)
+	m.mu.Unlock()
+
+	bucket := storageClient.Bucket(cfg.Bucket)
+	obj := bucket.Object(cfg.objectName(cfg.MetricsObject))
+	now := time.Now()
+	windowStart := now.AddDate(0, 0, -cfg.SLOWindowDays)
+	var kept []transferRecord
+	err := retryOnConflict(ctx, cfg.MetricsObject, func() error {
+		history := []transferRecord{}
+		raw, generation, err := readObject(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("failed to read transfer history: %w", err)
+		}
+		if raw != nil {
+			if err := json.Unmarshal(raw, &history); err != nil {
+				return fmt.Errorf("failed to unmarshal transfer history: %w", err)
+			}
+		}
+
+		kept = history[:0]
+		for _, record := range append(history, runRecords...) {
+			if record.Time.After(windowStart) {
+				kept = append(kept, record)
+			}
+		}
+		return writeJSONObject(ctx, ifGeneration(obj, generation), kept)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write transfer history: %w", err)
+	}
+
+	report := buildSLOReport(kept, runRecords, cfg.SLOSuccessTarget)
+	report.GeneratedAt = now
+	report.WindowStart = windowStart
+	if !report.TargetMet {
+		log.Printf("SLO breached: %.2f%% of %d transfers succeeded over the last %d days, target is %.2f%%",
+			report.SuccessRate*100, report.Transfers, cfg.SLOWindowDays, cfg.SLOSuccessTarget*100)
+	}
+
+	if err := writeJSONObject(ctx, bucket.Object(cfg.objectName(cfg.SLOReportObject)), report); err != nil {
+		return fmt.Errorf("failed to write SLO report: %w", err)
+	}
+	return nil
+}
+
+func buildSLOReport(window, run []transferRecord, target float64) sloReport {
+	report := sloReport{SuccessTarget: target, SuccessRate: 1}
+
+	var durations []time.Duration
+	var bytes int64
+	var elapsed time.Duration
+	for _, record := range window {
+		report.Transfers++
+		report.TotalRetries += record.Retries
+		if record.Retries > 0 {
+			report.TransfersWithRetry++
+		}
+		if !record.Success {
+			report.Failures++
+			continue
+		}
+		durations = append(durations, record.Duration)
+		bytes += record.Bytes
+		elapsed += record.Duration
+	}
+	if report.Transfers > 0 {
+		report.SuccessRate = float64(report.Transfers-report.Failures) / float64(report.Transfers)
+	}
+	report.TargetMet = report.SuccessRate >= target
+	report.AvgThroughputBps = throughput(bytes, elapsed)
+
+	if len(durations) > 0 {
+		sort.Slice(durations, func(a, b int) bool { return durations[a] < durations[b] })
+		i := int(math.Ceil(0.95*float64(len(durations)))) - 1
+		report.P95Duration = durations[i]
+	}
+
+	var runBytes int64
+	var runElapsed time.Duration
+	for _, record := range run {
+		report.RunTransfers++
+		if !record.Success {
+			report.RunFailures++
+			continue
+		}
+		runBytes += record.Bytes
+		runElapsed += record.Duration
+	}
+	report.RunAvgThroughputBps = throughput(runBytes, runElapsed)
+
+	return report
+}
+
+func throughput(bytes int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(bytes) / elapsed.Seconds()
+}
+
+func writeJSONObject(ctx context.Context, obj *storage.ObjectHandle, v interface{}) error {
+	return writeJSON(obj.NewWriter(ctx), v)
+}
+
+// writeJSON encodes v into wc and closes it.
+func writeJSON(wc *storage.Writer, v interface{}) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		_ = wc.Close()
+		return err
+	}
+	wc.ContentType = "application/json"
+	if _, err := bytes.NewReader(raw).WriteTo(wc); err != nil {
+		_ = wc.Close()
+		return err
+	}
+	return wc.Close()
+}
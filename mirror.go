@@ -0,0 +1,116 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// mirror is a destination archived recordings are copied to besides the
+// bucket, which stays the archive of record.
+type mirror interface {
+	// String names the mirror in logs.
+	String() string
+	// exists reports whether name was mirrored before with this size.
+	exists(ctx context.Context, name string, size int64) (bool, error)
+	// upload copies the recording read from r to name.
+	upload(ctx context.Context, name string, size int64, contentType string, r io.Reader) error
+}
+
+// newMirrors returns the mirrors the job configures.
+func newMirrors(ctx context.Context, cfg *config) ([]mirror, error) {
+	var mirrors []mirror
+	if cfg.DriveFolderID != "" {
+		m, err := newDriveMirror(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		mirrors = append(mirrors, m)
+	}
+	if cfg.DropboxFolder != "" {
+		mirrors = append(mirrors, newDropboxMirror(cfg))
+	}
+	if cfg.SFTPHost != "" {
+		m, err := newSFTPMirror(cfg)
+		if err != nil {
+			return nil, err
+		}
+		mirrors = append(mirrors, m)
+	}
+	if cfg.RcloneDestination != "" {
+		mirrors = append(mirrors, newRcloneMirror(cfg))
+	}
+	return mirrors, nil
+}
+
+// mirrorMeeting copies the meeting's archived recording files to every
+// mirror, below the same path they have in the bucket. Files a mirror already
+// has with the same size are skipped, so meetings archived again are not
+// duplicated.
+func (run *backupRun) mirrorMeeting(ctx context.Context, meeting meeting, files []archivedFile) error {
+	for _, file := range files {
+		fileSaveName, err := getFileSaveName(run.cfg, meeting, file.recording)
+		if err != nil {
+			return err
+		}
+		name := run.cfg.objectName(fileSaveName)
+		for _, m := range run.mirrors {
+			if err := run.mirrorFile(ctx, m, file, name); err != nil {
+				return fmt.Errorf("failed to mirror %s to %s: %w", name, m, err)
+			}
+			if file.checksum != nil && run.cfg.ChecksumFiles == checksumFilesSidecar {
+				if err := mirrorChecksumSidecar(ctx, m, file, name+"."+run.cfg.ChecksumAlgorithm); err != nil {
+					return fmt.Errorf("failed to mirror %s to %s: %w", name, m, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mirrorChecksumSidecar puts the checksum sidecar of CHECKSUM_FILES=sidecar
+// next to the mirrored recording, so the copy can be verified on its own.
+func mirrorChecksumSidecar(ctx context.Context, m mirror, file archivedFile, name string) error {
+	line := checksumLine(file.attrs.Name, file.checksum)
+	exists, err := m.exists(ctx, name, int64(len(line)))
+	if err != nil || exists {
+		return err
+	}
+	return m.upload(ctx, name, int64(len(line)), "text/plain; charset=utf-8", strings.NewReader(line))
+}
+
+func (run *backupRun) mirrorFile(ctx context.Context, m mirror, file archivedFile, name string) error {
+	if err := run.limits.upload.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	defer run.limits.upload.Release(1)
+
+	exists, err := m.exists(ctx, name, file.recording.FileSize)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Println("Already mirrored", name, "to", m)
+		return nil
+	}
+	body, err := run.openStored(ctx, file.attrs.Name)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	log.Println("Mirroring", name, "to", m)
+	return m.upload(ctx, name, file.recording.FileSize, run.cfg.contentType(file.recording.FileType), body)
+}
+
+// closeMirrors releases the connections of the mirrors that keep any.
+func (run *backupRun) closeMirrors() {
+	for _, m := range run.mirrors {
+		if c, ok := m.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Println("Could not close", m, err)
+			}
+		}
+	}
+}
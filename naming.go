@@ -0,0 +1,202 @@
+package zoombackup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// defaultTopicAllowedChars keeps letters, digits, spaces and punctuation that
+// is harmless in object names and URLs.
+const defaultTopicAllowedChars = `\p{L}\p{N} _.,'()&+-`
+
+// defaultNamingTemplate extends the original Topic-MM-DD-YYYY/ layout with
+// the short hash of the meeting UUID, so meetings with the same topic on the
+// same day get folders of their own.
+const defaultNamingTemplate = `{{if .Topic}}{{.Topic}}-{{end}}{{.Date}}-{{.UUIDHash}}/{{.Start}}-{{.Type}}{{.Segment}}.{{.Ext}}`
+
+// meetingFolderPattern splits the folders of the default layout, with or
+// without the UUID hash, into topic and date.
+var meetingFolderPattern = regexp.MustCompile(`^(?:(.*)-)?(\d{2}-\d{2}-\d{4})(?:-[0-9a-f]{8})?$`)
+
+// objectNameData is what NAMING_TEMPLATE is executed with for every recording
+// file.
+type objectNameData struct {
+	UUID string
+	// UUIDHash is a short hash of the UUID that is safe in object names,
+	// which UUIDs containing / and + are not.
+	UUIDHash string
+	// FileID is Zoom's ID of the recording file, the same in every run.
+	FileID string
+	// Topic is sanitized for use in object names, see sanitizeTopic.
+	Topic     string
+	TopicSlug string
+	// Host is HostEmail, kept for existing templates.
+	Host      string
+	HostEmail string
+	// HostName is sanitized like Topic.
+	HostName string
+	Source   string
+	// Year, Month and Day are the zero padded meeting start date in
+	// TIMEZONE.
+	Year  string
+	Month string
+	Day   string
+	// Date is the meeting start date in TIMEZONE as MM-DD-YYYY.
+	Date string
+	// Start is the start time of the recording file as reported by Zoom.
+	Start string
+	Type  string
+	// Segment is "-N" for the Nth file of the meeting with the same Start,
+	// Type and Ext and empty otherwise.
+	Segment string
+	Ext     string
+}
+
+func parseNamingTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("naming").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse NAMING_TEMPLATE: %w", err)
+	}
+	return tmpl, nil
+}
+
+// getFileSaveName renders the object name, relative to GSTORAGE_PATH, of a
+// recording file of the meeting.
+func getFileSaveName(cfg *config, mtg meeting, recording recordingFile) (string, error) {
+	meetingDate, err := meetingDate(cfg, mtg.StartTime)
+	if err != nil {
+		return "", err
+	}
+
+	data := objectNameData{
+		UUID:      mtg.ID,
+		UUIDHash:  uuidHash(mtg.ID),
+		FileID:    recording.ID,
+		Topic:     sanitizeTopic(cfg, mtg.Topic),
+		TopicSlug: slugify(mtg.Topic),
+		Host:      mtg.HostEmail,
+		HostEmail: mtg.HostEmail,
+		HostName:  sanitizeTopic(cfg, mtg.HostName),
+		Source:    mtg.source(),
+		Year:      meetingDate.Format("2006"),
+		Month:     meetingDate.Format("01"),
+		Day:       meetingDate.Format("02"),
+		Date:      meetingDate.Format(dateFormatTo),
+		Start:     recording.RecordingStart,
+		Type:      recording.RecordingType,
+		Segment:   recording.segmentSuffix(),
+		Ext:       strings.ToLower(recording.FileType),
+	}
+	buf := new(bytes.Buffer)
+	if err := cfg.naming.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("failed to render object name: %w", err)
+	}
+
+	name := strings.TrimLeft(path.Clean("/"+buf.String()), "/")
+	if name == "" {
+		return "", errors.New("NAMING_TEMPLATE rendered an empty object name")
+	}
+	// Templates without .Segment or .FileID would store every segment
+	// under the same name, overwriting the earlier ones.
+	if data.Segment != "" && !strings.Contains(cfg.NamingTemplate, ".Segment") && !strings.Contains(cfg.NamingTemplate, ".FileID") {
+		ext := path.Ext(name)
+		name = strings.TrimSuffix(name, ext) + data.Segment + ext
+	}
+	return name, nil
+}
+
+// uuidHash is the 8 hex digit FNV-1a hash of the meeting UUID. Every
+// occurrence of a recurring meeting has a UUID of its own, so they hash
+// differently, and the hash stays the same across runs.
+func uuidHash(uuid string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uuid))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// meetingDate is the day the meeting started in TIMEZONE. Start times
+// without a time of day are taken as they are.
+func meetingDate(cfg *config, startTime string) (time.Time, error) {
+	if start, err := time.Parse(time.RFC3339, startTime); err == nil {
+		return start.In(cfg.location), nil
+	}
+	if len(startTime) < dateLength {
+		return time.Time{}, fmt.Errorf("failed to parse date: %q is too short", startTime)
+	}
+	date, err := time.ParseInLocation(dateFormatFrom, startTime[:dateLength], cfg.location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse date: %w", err)
+	}
+	return date, nil
+}
+
+// slugify lowercases s and replaces every run of characters other than
+// letters and digits with a single dash.
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if b.Len() == 0 {
+		return "untitled"
+	}
+	return b.String()
+}
+
+// sanitizeTopic makes a meeting topic safe to use in object names. Characters
+// outside TOPIC_ALLOWED_CHARS are replaced by TOPIC_REPLACEMENT, leading and
+// trailing spaces, dots and replacements are dropped and the result is cut to
+// TOPIC_MAX_LENGTH characters. Distinct topics can end up the same this way,
+// e.g. "Q&A / Sales" and "Q&A ? Sales", so whenever a topic had to change a
+// short hash of the original is appended, which keeps names stable across
+// runs without remembering earlier ones.
+func sanitizeTopic(cfg *config, topic string) string {
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		return ""
+	}
+
+	trim := " ." + cfg.TopicReplacement
+	safe := cfg.topicDisallowed.ReplaceAllLiteralString(topic, cfg.TopicReplacement)
+	safe = strings.Trim(safe, trim)
+	if runes := []rune(safe); len(runes) > cfg.TopicMaxLength {
+		safe = strings.TrimRight(string(runes[:cfg.TopicMaxLength]), trim)
+	}
+	if safe == "" {
+		safe = "untitled"
+	}
+
+	if safe != topic && cfg.TopicCollisionSuffix {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(topic))
+		safe = fmt.Sprintf("%s~%06x", safe, h.Sum32()&0xffffff)
+	}
+	return safe
+}
+
+// compileTopicCharset returns a regexp matching runs of characters outside
+// the allowed character class.
+func compileTopicCharset(allowed string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("[^" + allowed + "]+")
+	if err != nil {
+		return nil, fmt.Errorf("TOPIC_ALLOWED_CHARS is not a valid character class: %w", err)
+	}
+	return re, nil
+}
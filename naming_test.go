@@ -0,0 +1,110 @@
+package zoombackup
+
+import (
+	"strings"
+	"testing"
+)
+
+func testNamingConfig(t *testing.T, collisionSuffix bool) *config {
+	t.Helper()
+	disallowed, err := compileTopicCharset(defaultTopicAllowedChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &config{
+		topicDisallowed:      disallowed,
+		TopicReplacement:     "_",
+		TopicMaxLength:       20,
+		TopicCollisionSuffix: collisionSuffix,
+	}
+}
+
+func TestSanitizeTopic(t *testing.T) {
+	cfg := testNamingConfig(t, false)
+	tests := []struct {
+		name  string
+		topic string
+		want  string
+	}{
+		{"unchanged", "Weekly Standup", "Weekly Standup"},
+		{"empty", "  ", ""},
+		{"slash", "Q&A / Sales", "Q&A _ Sales"},
+		{"hash and question mark", "Release #42?", "Release _42"},
+		{"path traversal", "../../etc", "etc"},
+		{"control characters", "Line\nbreak\ttab\x00", "Line_break_tab"},
+		{"emoji", "Team 🎉 Party", "Team _ Party"},
+		{"letters of any script", "Ünïcode Straße 会议", "Ünïcode Straße 会议"},
+		{"trailing dots and spaces", "Notes. . . ", "Notes"},
+		{"leading dots", "..hidden", "hidden"},
+		{"nothing allowed", "???", "untitled"},
+		{"truncated", "abcdefghij klmnopqrstuvwxyz", "abcdefghij klmnopqrs"},
+		{"truncated before a space", "abcdefghijklmnopqrs tuvwxyz", "abcdefghijklmnopqrs"},
+		{"truncated by characters", strings.Repeat("é", 25), strings.Repeat("é", 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeTopic(cfg, tt.topic); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeTopicCollisionSuffix(t *testing.T) {
+	cfg := testNamingConfig(t, true)
+	tests := []struct {
+		name       string
+		topic      string
+		wantPrefix string
+		wantSuffix bool
+	}{
+		{"unchanged", "Weekly Standup", "Weekly Standup", false},
+		{"slash", "Q&A / Sales", "Q&A _ Sales~", true},
+		{"question mark", "Q&A ? Sales", "Q&A _ Sales~", true},
+		{"truncated", "abcdefghij klmnopqrstuvwxyz", "abcdefghij klmnopqrs~", true},
+		{"surrounding spaces only", "  Standup  ", "Standup", false},
+	}
+	seen := map[string]string{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitizeTopic(cfg, tt.topic)
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("got %q, want it to start with %q", got, tt.wantPrefix)
+			}
+			if hasSuffix := got != tt.wantPrefix; hasSuffix != tt.wantSuffix || (hasSuffix && len(got) != len(tt.wantPrefix)+6) {
+				t.Errorf("got %q, want a 6 digit suffix %t", got, tt.wantSuffix)
+			}
+			if got != sanitizeTopic(cfg, tt.topic) {
+				t.Errorf("the suffix of %q changes between calls", tt.topic)
+			}
+			if other, ok := seen[got]; ok {
+				t.Errorf("%q and %q both became %q", other, tt.topic, got)
+			}
+			seen[got] = tt.topic
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"words", "Weekly Standup", "weekly-standup"},
+		{"slash, hash and question mark", "Q&A / Sales #3?", "q-a-sales-3"},
+		{"control characters", "a\tb\nc\x00", "a-b-c"},
+		{"emoji", "Team 🎉 Party", "team-party"},
+		{"letters of any script", "Ünïcode Straße", "ünïcode-straße"},
+		{"trailing dots and spaces", "Notes. . . ", "notes"},
+		{"leading separators", "--- x ---", "x"},
+		{"nothing left", " ?! ", "untitled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slugify(tt.s); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
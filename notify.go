@@ -0,0 +1,376 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+const (
+	notifyOnAlways = "always"
+	notifyOnErrors = "errors"
+
+	// notifyTimeout bounds sending all notifications of a run. It does not
+	// derive from the run's context so cancelled runs are reported too.
+	notifyTimeout = 30 * time.Second
+
+	defaultSlackTemplate = `{{if .Report.Errors}}:warning:{{else}}:white_check_mark:{{end}} Zoom backup *{{.Report.Job}}*: ` +
+		`{{.Report.FilesArchived}} files archived ({{bytes .Report.BytesArchived}}), {{.Report.FilesFailed}} failed, ` +
+		`{{.Report.MeetingsDeleted}} meetings deleted{{if .Report.Cancelled}}, cancelled{{end}}{{if .Report.IndexError}}, index degraded{{end}}` +
+		`{{range .Report.Errors}}
+• {{.}}{{end}}`
+	defaultWebhookTemplate = `{{json .Report}}`
+	// defaultChatTemplate is the Slack summary without Slack's markup, for
+	// the chat services that render it differently.
+	defaultChatTemplate = "{{if .Report.Errors}}\u26a0\ufe0f{{else}}\u2705{{end}} Zoom backup {{.Report.Job}}: " +
+		`{{.Report.FilesArchived}} files archived ({{bytes .Report.BytesArchived}}), {{.Report.FilesFailed}} failed, ` +
+		`{{.Report.MeetingsDeleted}} meetings deleted{{if .Report.Cancelled}}, cancelled{{end}}{{if .Report.IndexError}}, index degraded{{end}}` +
+		`{{range .Report.Errors}}
+• {{.}}{{end}}`
+
+	// discordMaxContent is the longest message Discord accepts.
+	discordMaxContent = 2000
+)
+
+// Types of the NOTIFIERS channels.
+const (
+	notifierSlack      = "slack"
+	notifierWebhook    = "webhook"
+	notifierTeams      = "teams"
+	notifierGoogleChat = "google_chat"
+	notifierDiscord    = "discord"
+)
+
+// notifierType creates the channels of one NOTIFIERS type.
+type notifierType struct {
+	defaultTemplate string
+	new             func(nc notifierConfig, tmpl *template.Template, httpClient *http.Client) notifier
+}
+
+// notifierTypes are the registered NOTIFIERS types.
+var notifierTypes = map[string]notifierType{}
+
+// registerNotifier adds a NOTIFIERS type whose channels render
+// defaultTemplate unless they set their own. Channels register from an init
+// function of their own file, so adding one needs no changes elsewhere.
+func registerNotifier(kind, defaultTemplate string, new func(nc notifierConfig, tmpl *template.Template, httpClient *http.Client) notifier) {
+	notifierTypes[kind] = notifierType{defaultTemplate: defaultTemplate, new: new}
+}
+
+func init() {
+	registerNotifier(notifierSlack, defaultSlackTemplate, func(nc notifierConfig, tmpl *template.Template, httpClient *http.Client) notifier {
+		return &slackNotifier{webhookURL: nc.URL, tmpl: tmpl, httpClient: httpClient}
+	})
+	registerNotifier(notifierWebhook, defaultWebhookTemplate, func(nc notifierConfig, tmpl *template.Template, httpClient *http.Client) notifier {
+		contentType := nc.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		return &webhookNotifier{url: nc.URL, contentType: contentType, tmpl: tmpl}
+	})
+	for _, kind := range []string{notifierTeams, notifierGoogleChat, notifierDiscord} {
+		kind := kind
+		registerNotifier(kind, defaultChatTemplate, func(nc notifierConfig, tmpl *template.Template, httpClient *http.Client) notifier {
+			return &chatNotifier{kind: kind, webhookURL: nc.URL, tmpl: tmpl, httpClient: httpClient}
+		})
+	}
+}
+
+// notifierTypeNames lists the registered NOTIFIERS types.
+func notifierTypeNames() []string {
+	var names []string
+	for name := range notifierTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// notifierConfig is a channel of NOTIFIERS.
+type notifierConfig struct {
+	Type string `yaml:"type"`
+	URL  string `yaml:"url"`
+	// Template replaces the type's default template.
+	Template string `yaml:"template"`
+	// ContentType is the Content-Type of webhook requests, JSON when empty.
+	ContentType string `yaml:"content_type"`
+}
+
+// notificationData is what notification templates are executed with.
+type notificationData struct {
+	Report *runReport
+}
+
+// notifier delivers the notifications about a job's run to one channel.
+// Each channel renders its own template, so content can be adapted per
+// channel without code changes. Failures are logged and never fail the run.
+type notifier interface {
+	name() string
+	// runStarted is called when the run got past its pause and
+	// ALLOWED_HOURS checks and starts archiving.
+	runStarted(ctx context.Context, data notificationData) error
+	// fileArchived is called with the uploaded event of every recording
+	// file the run archived.
+	fileArchived(ctx context.Context, e event) error
+	// runFinished is called with the report of the finished run, unless
+	// NOTIFY_ON=errors holds back runs without failures.
+	runFinished(ctx context.Context, data notificationData) error
+}
+
+// summaryNotifier is embedded by the channels that only report finished
+// runs.
+type summaryNotifier struct{}
+
+func (summaryNotifier) runStarted(ctx context.Context, data notificationData) error { return nil }
+
+func (summaryNotifier) fileArchived(ctx context.Context, e event) error { return nil }
+
+var notifyTemplateFuncs = template.FuncMap{
+	"bytes": humanBytes,
+	"join":  strings.Join,
+	"json": func(v interface{}) (string, error) {
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	},
+}
+
+// slackNotifier posts to a Slack incoming webhook.
+type slackNotifier struct {
+	summaryNotifier
+	webhookURL string
+	tmpl       *template.Template
+	httpClient *http.Client
+}
+
+func (n *slackNotifier) name() string { return "slack" }
+
+func (n *slackNotifier) runFinished(ctx context.Context, data notificationData) error {
+	text, err := renderNotification(n.tmpl, data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]string{"text": string(text)})
+	if err != nil {
+		return err
+	}
+	return postNotification(ctx, n.httpClient, n.webhookURL, "application/json", payload)
+}
+
+// webhookNotifier posts the rendered template as the request body.
+type webhookNotifier struct {
+	summaryNotifier
+	url         string
+	contentType string
+	tmpl        *template.Template
+	httpClient  *http.Client
+}
+
+func (n *webhookNotifier) name() string { return "webhook" }
+
+func (n *webhookNotifier) runFinished(ctx context.Context, data notificationData) error {
+	body, err := renderNotification(n.tmpl, data)
+	if err != nil {
+		return err
+	}
+	return postNotification(ctx, n.httpClient, n.url, n.contentType, body)
+}
+
+// chatNotifier posts to a Microsoft Teams, Google Chat or Discord incoming
+// webhook, in the message format of the service.
+type chatNotifier struct {
+	summaryNotifier
+	kind       string
+	webhookURL string
+	tmpl       *template.Template
+	httpClient *http.Client
+}
+
+func (n *chatNotifier) name() string { return n.kind }
+
+func (n *chatNotifier) runFinished(ctx context.Context, data notificationData) error {
+	text, err := renderNotification(n.tmpl, data)
+	if err != nil {
+		return err
+	}
+	var message interface{}
+	switch n.kind {
+	case notifierTeams:
+		// Teams renders the text as Markdown, which needs blank lines to
+		// keep lines apart.
+		summary := strings.SplitN(string(text), "\n", 2)[0]
+		message = map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  summary,
+			"text":     strings.Replace(string(text), "\n", "\n\n", -1),
+		}
+	case notifierDiscord:
+		content := []rune(string(text))
+		if len(content) > discordMaxContent {
+			content = append(content[:discordMaxContent-1], '…')
+		}
+		message = map[string]string{"content": string(content)}
+	default:
+		message = map[string]string{"text": string(text)}
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return postNotification(ctx, n.httpClient, n.webhookURL, "application/json", payload)
+}
+
+// newNotifiers returns the channels configured for the job: Slack and the
+// webhook of their own settings, then NOTIFIERS.
+func newNotifiers(ctx context.Context, storageClient *storage.Client, cfg *config) ([]notifier, error) {
+	var channels []notifierConfig
+	if cfg.NotifySlackWebhookURL != "" {
+		channels = append(channels, notifierConfig{Type: notifierSlack, URL: cfg.NotifySlackWebhookURL, Template: cfg.NotifySlackTemplate})
+	}
+	if cfg.NotifyWebhookURL != "" {
+		channels = append(channels, notifierConfig{Type: notifierWebhook, URL: cfg.NotifyWebhookURL, Template: cfg.NotifyWebhookTemplate, ContentType: cfg.NotifyWebhookContentType})
+	}
+	channels = append(channels, cfg.Notifiers...)
+
+	var notifiers []notifier
+	for _, nc := range channels {
+		kind, ok := notifierTypes[nc.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown notifier type %q", nc.Type)
+		}
+		tmpl, err := loadNotifyTemplate(ctx, storageClient, nc.Type, nc.Template, kind.defaultTemplate)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, kind.new(nc, tmpl, cfg.client()))
+	}
+	return notifiers, nil
+}
+
+// notifyStart sets up the job's channels for the run and tells them it
+// started. The channels are kept with the report for the rest of the run.
+func notifyStart(storageClient *storage.Client, cfg *config, report *runReport) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	notifiers, err := newNotifiers(ctx, storageClient, cfg)
+	if err != nil {
+		log.Println("Could not set up notifications:", err)
+		return
+	}
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	report.notifiers = notifiers
+	for _, n := range notifiers {
+		if err := n.runStarted(ctx, notificationData{Report: report}); err != nil {
+			log.Printf("Could not send %s start notification: %v", n.name(), err)
+		}
+	}
+}
+
+// notifyFileArchived passes the uploaded event of an archived file to the
+// run's channels.
+func (run *backupRun) notifyFileArchived(e event) {
+	run.report.mu.Lock()
+	notifiers := run.report.notifiers
+	run.report.mu.Unlock()
+	if len(notifiers) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	for _, n := range notifiers {
+		if err := n.fileArchived(ctx, e); err != nil {
+			log.Printf("Could not send %s file notification: %v", n.name(), err)
+		}
+	}
+}
+
+// notifyRun sends the job's report to every configured channel, or only when
+// the run had errors with NOTIFY_ON=errors. Runs that ended before
+// notifyStart, e.g. paused ones, set the channels up here.
+func notifyRun(storageClient *storage.Client, cfg *config, report *runReport) {
+	if cfg.NotifyOn == notifyOnErrors && !report.failed() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	report.mu.Lock()
+	notifiers := report.notifiers
+	report.mu.Unlock()
+	if notifiers == nil {
+		var err error
+		if notifiers, err = newNotifiers(ctx, storageClient, cfg); err != nil {
+			log.Println("Could not set up notifications:", err)
+			return
+		}
+	}
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	for _, n := range notifiers {
+		if err := n.runFinished(ctx, notificationData{Report: report}); err != nil {
+			log.Printf("Could not send %s notification: %v", n.name(), err)
+		}
+	}
+}
+
+// loadNotifyTemplate parses a channel's template from a local file or a
+// gs://bucket/object URL, falling back to the built-in one.
+func loadNotifyTemplate(ctx context.Context, storageClient *storage.Client, channel, source, fallback string) (*template.Template, error) {
+	text := fallback
+	if source != "" {
+		var raw []byte
+		var err error
+		if strings.HasPrefix(source, "gs://") {
+			raw, err = readGCSObject(ctx, storageClient, source)
+		} else {
+			raw, err = ioutil.ReadFile(source)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s notification template %s: %w", channel, source, err)
+		}
+		text = string(raw)
+	}
+	tmpl, err := template.New(channel).Funcs(notifyTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s notification template: %w", channel, err)
+	}
+	return tmpl, nil
+}
+
+func renderNotification(tmpl *template.Template, data notificationData) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s notification: %w", tmpl.Name(), err)
+	}
+	return buf.Bytes(), nil
+}
+
+func postNotification(ctx context.Context, httpClient *http.Client, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform request: %w", err)
+	}
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("invalid response code: %d -- %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"strconv"
+)
+
+const (
+	zoomPastMeetingParticipantsPath = "/past_meetings/%s/participants?page_size=300"
+	zoomPastWebinarParticipantsPath = "/past_webinars/%s/participants?page_size=300"
+)
+
+type participant struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	UserEmail string `json:"user_email"`
+	JoinTime  string `json:"join_time,omitempty"`
+	LeaveTime string `json:"leave_time,omitempty"`
+	Duration  int    `json:"duration,omitempty"`
+}
+
+type participantsResponse struct {
+	NextPageToken string        `json:"next_page_token"`
+	Participants  []participant `json:"participants"`
+}
+
+func (c *zoomClient) listParticipants(ctx context.Context, mtg meeting) ([]participant, error) {
+	endpoint := zoomPastMeetingParticipantsPath
+	if mtg.isWebinar() {
+		endpoint = zoomPastWebinarParticipantsPath
+	}
+	participants := []participant{}
+	nextPageToken := ""
+	for {
+		reqPath := fmt.Sprintf(endpoint, meetingPathID(mtg.ID))
+		if nextPageToken != "" {
+			reqPath += "&next_page_token=" + url.QueryEscape(nextPageToken)
+		}
+
+		response := &participantsResponse{}
+		if err := c.getJSON(ctx, reqPath, response); err != nil {
+			return nil, err
+		}
+		participants = append(participants, response.Participants...)
+
+		if response.NextPageToken == "" {
+			return participants, nil
+		}
+		nextPageToken = response.NextPageToken
+	}
+}
+
+// exportParticipants stores the meeting's attendance as participants.json and
+// participants.csv in the meeting folder.
+func (run *backupRun) exportParticipants(ctx context.Context, mtg meeting) error {
+	participants, err := run.zoom.listParticipants(ctx, mtg)
+	if errors.Is(err, errZoomNotFound) {
+		// Zoom keeps no report for meetings without paid features or past
+		// its report retention.
+		log.Println("No participants report for", mtg.ID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch participants: %w", err)
+	}
+	folder, err := meetingFolder(run.cfg, mtg)
+	if err != nil {
+		return err
+	}
+	jsonName := run.cfg.objectName(path.Join(folder, "participants.json"))
+	raw, err := json.MarshalIndent(participants, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := run.writeMeetingFile(ctx, mtg, jsonName, "JSON", run.cfg.contentType("JSON"), raw); err != nil {
+		return fmt.Errorf("failed to write %s: %w", jsonName, err)
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	_ = w.Write([]string{"id", "name", "user_email", "join_time", "leave_time", "duration"})
+	for _, p := range participants {
+		_ = w.Write([]string{p.ID, p.Name, p.UserEmail, p.JoinTime, p.LeaveTime, strconv.Itoa(p.Duration)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	csvName := run.cfg.objectName(path.Join(folder, "participants.csv"))
+	if err := run.writeMeetingFile(ctx, mtg, csvName, "CSV", run.cfg.contentType("CSV"), buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", csvName, err)
+	}
+	return nil
+}
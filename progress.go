@@ -0,0 +1,55 @@
+package zoombackup
+
+import (
+	"io"
+	"log"
+	"time"
+)
+
+// progressReader logs how far a download got every interval, with the
+// throughput so far and, when the size is known, the percentage and an ETA.
+type progressReader struct {
+	r        io.Reader
+	name     string
+	size     int64
+	interval time.Duration
+
+	read    int64
+	started time.Time
+	logged  time.Time
+}
+
+// newProgressReader wraps r, or returns it unchanged when interval is 0. size
+// is the expected number of bytes, 0 or less when unknown.
+func newProgressReader(r io.Reader, name string, size int64, interval time.Duration) io.Reader {
+	if interval <= 0 {
+		return r
+	}
+	now := time.Now()
+	return &progressReader{r: r, name: name, size: size, interval: interval, started: now, logged: now}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if now := time.Now(); now.Sub(p.logged) >= p.interval {
+		p.logged = now
+		p.log(now)
+	}
+	return n, err
+}
+
+func (p *progressReader) log(now time.Time) {
+	elapsed := now.Sub(p.started)
+	rate := throughput(p.read, elapsed)
+	if p.size <= 0 {
+		log.Printf("Downloading %s: %s at %s/s", p.name, humanBytes(p.read), humanBytes(int64(rate)))
+		return
+	}
+	eta := "unknown"
+	if rate > 0 && p.read < p.size {
+		eta = (time.Duration(float64(p.size-p.read)/rate) * time.Second).Round(time.Second).String()
+	}
+	log.Printf("Downloading %s: %s of %s (%.0f%%) at %s/s, ETA %s",
+		p.name, humanBytes(p.read), humanBytes(p.size), float64(p.read)*100/float64(p.size), humanBytes(int64(rate)), eta)
+}
@@ -0,0 +1,247 @@
+package zoombackup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// publishedObjectPrefix is where published meeting pages are stored, below
+// GSTORAGE_PATH.
+const publishedObjectPrefix = "published/"
+
+const publishTemplate = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Topic}}</title>
+<style>body{font-family:sans-serif;max-width:960px;margin:2em auto;padding:0 1em}video,audio{width:100%}
+.cue{margin:.3em 0}.time{color:#888;font-size:.85em;margin-right:.5em}pre{white-space:pre-wrap}</style>
+</head><body>
+<h1>{{.Topic}}</h1>
+<p>{{.Start.Format "Mon, Jan 2 2006 15:04 MST"}}{{if .Duration}} &middot; {{.Duration}} minutes{{end}}</p>
+{{- range .Media}}
+<h3>{{.Title}}</h3>
+{{if .Audio}}<audio controls preload="metadata" src="{{.URL}}"></audio>{{else}}<video controls preload="metadata" src="{{.URL}}"></video>{{end}}
+<p><a href="{{.URL}}">Download</a></p>
+{{- end}}
+{{- if .Transcript}}
+<h2>Transcript</h2>
+{{- range .Transcript}}
+<div class="cue"><span class="time">{{.Time}}</span>{{.Text}}</div>
+{{- end}}
+{{- end}}
+{{- if .Chat}}
+<h2>Chat</h2>
+<pre>{{.Chat}}</pre>
+{{- end}}
+<p><small>Links expire {{.Expires.Format "Jan 2 2006 15:04 MST"}}.</small></p>
+</body></html>`
+
+// publishedPage is what the publish template is rendered with.
+type publishedPage struct {
+	Topic      string
+	Start      time.Time
+	Duration   int
+	Media      []publishedMedia
+	Transcript []transcriptCue
+	Chat       string
+	Expires    time.Time
+}
+
+type publishedMedia struct {
+	Title string
+	URL   string
+	Audio bool
+}
+
+// transcriptCue is one cue of a WebVTT transcript.
+type transcriptCue struct {
+	Time string
+	Text string
+}
+
+func publishCommand(args []string) int {
+	fs := flag.NewFlagSet("zoom-backup publish", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	job := fs.String("job", "", "job whose archive to publish from; required with several jobs")
+	uuid := fs.String("meeting", "", "UUID of the archived meeting to publish")
+	expiry := fs.Duration("expiry", 7*24*time.Hour, "how long the page and its links stay valid, at most 168h")
+	out := fs.String("out", "", "write the page to this local file instead of the bucket")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *uuid == "" {
+		fs.Usage()
+		return 2
+	}
+	if *expiry <= 0 || *expiry > 7*24*time.Hour {
+		log.Println("-expiry must be between 0 and 168h")
+		return 2
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	storageClient, jobs, err := setupCLI(ctx, func(*config) error { return nil })
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	cfg, err := selectJob(jobs, *job)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	link, err := publishMeeting(ctx, storageClient, cfg, *uuid, *expiry, *out)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	fmt.Println(link)
+	return 0
+}
+
+// publishMeeting renders a standalone page for an archived meeting, with
+// signed links to its recordings and its transcript and chat inlined, like
+// Zoom's share page. The page is stored under published/ and a signed URL to
+// it is returned, or it is written to out and out is returned.
+func publishMeeting(ctx context.Context, storageClient *storage.Client, cfg *config, uuid string, expiry time.Duration, out string) (string, error) {
+	if cfg.encryptionKey != nil {
+		return "", errors.New("recordings encrypted with ENCRYPTION_KEY cannot be shared through signed URLs")
+	}
+	signer, err := newURLSigner(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	if signer == nil {
+		return "", errors.New("publishing requires SIGNING_SERVICE_ACCOUNT")
+	}
+	m, err := loadManifest(ctx, storageClient, cfg)
+	if err != nil {
+		return "", err
+	}
+	i := m.meetingIndex(uuid)
+	if i < 0 {
+		return "", fmt.Errorf("meeting %s is not in the manifest", uuid)
+	}
+	mtg := m.Meetings[i]
+
+	page := publishedPage{
+		Topic:    mtg.Topic,
+		Duration: mtg.Duration,
+		Expires:  time.Now().Add(expiry).In(cfg.location),
+	}
+	if start, err := time.Parse(time.RFC3339, mtg.StartTime); err == nil {
+		page.Start = start.In(cfg.location)
+	}
+	run := &backupRun{cfg: cfg, storageClient: storageClient}
+	for _, file := range mtg.Files {
+		if file.Object == "" {
+			continue
+		}
+		switch strings.ToUpper(file.FileType) {
+		case "MP4", "M4A":
+			link, err := signer.objectURL(cfg.Bucket, file.Object, expiry)
+			if err != nil {
+				return "", fmt.Errorf("failed to sign %s: %w", file.Object, err)
+			}
+			page.Media = append(page.Media, publishedMedia{
+				Title: strings.Title(strings.Replace(file.RecordingType, "_", " ", -1)),
+				URL:   link,
+				Audio: strings.ToUpper(file.FileType) == "M4A",
+			})
+		case "TRANSCRIPT", "VTT", "CC":
+			if page.Transcript != nil {
+				continue
+			}
+			content, err := run.readStored(ctx, file.Object)
+			if err != nil {
+				return "", err
+			}
+			page.Transcript = parseTranscript(content)
+		case "CHAT", "TXT":
+			content, err := run.readStored(ctx, file.Object)
+			if err != nil {
+				return "", err
+			}
+			page.Chat = string(content)
+		}
+	}
+	if len(page.Media) == 0 && page.Transcript == nil && page.Chat == "" {
+		return "", fmt.Errorf("meeting %s has no archived files to publish", uuid)
+	}
+
+	html := new(bytes.Buffer)
+	tmpl := template.Must(template.New("publish").Parse(publishTemplate))
+	if err := tmpl.Execute(html, page); err != nil {
+		return "", fmt.Errorf("failed to render page: %w", err)
+	}
+	if out != "" {
+		return out, ioutil.WriteFile(out, html.Bytes(), 0644)
+	}
+
+	name := cfg.objectName(publishedObjectPrefix + url.PathEscape(uuid) + ".html")
+	wc := storageClient.Bucket(cfg.Bucket).Object(name).NewWriter(ctx)
+	wc.ContentType = "text/html; charset=utf-8"
+	if _, err := io.Copy(wc, html); err != nil {
+		_ = wc.Close()
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := wc.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return signer.objectURL(cfg.Bucket, name, expiry)
+}
+
+// readStored reads a whole archived object as it was downloaded from Zoom.
+func (run *backupRun) readStored(ctx context.Context, object string) ([]byte, error) {
+	content, err := run.openStored(ctx, object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", object, err)
+	}
+	defer content.Close()
+	raw, err := ioutil.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", object, err)
+	}
+	return raw, nil
+}
+
+// parseTranscript extracts the cues of a WebVTT file, with their start time
+// without milliseconds.
+func parseTranscript(vtt []byte) []transcriptCue {
+	cues := []transcriptCue{}
+	var cue *transcriptCue
+	scanner := bufio.NewScanner(bytes.NewReader(vtt))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			cue = nil
+		case strings.Contains(line, "-->"):
+			start := strings.TrimSpace(strings.SplitN(line, "-->", 2)[0])
+			if dot := strings.LastIndex(start, "."); dot > 0 {
+				start = start[:dot]
+			}
+			cues = append(cues, transcriptCue{Time: start})
+			cue = &cues[len(cues)-1]
+		case cue != nil:
+			if cue.Text != "" {
+				cue.Text += " "
+			}
+			cue.Text += line
+		}
+	}
+	return cues
+}
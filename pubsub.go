@@ -0,0 +1,135 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// PubSubMessage is the payload of the Pub/Sub event that triggers
+// ZoomBackupPubSub.
+type PubSubMessage struct {
+	Data []byte `json:"data"`
+}
+
+// triggerRequest is the JSON message of a Pub/Sub trigger. Every field is
+// optional, so Cloud Scheduler jobs can fan the work out per job, per user or
+// per date range.
+type triggerRequest struct {
+	// Job runs only the named job of JOBS_CONFIG.
+	Job string `json:"job"`
+	// Users replaces ZOOM_USER_ID and ZOOM_GROUP_IDS.
+	Users []string `json:"users"`
+	// From and To are dates, YYYY-MM-DD in TIMEZONE, bounding the
+	// recordings listed, both inclusive. To defaults to today.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Days replaces LOOKBACK_DAYS when From is not set.
+	Days int `json:"days"`
+	// Queue "work" archives meetings queued in QUEUE_MODE instead of
+	// listing them.
+	Queue string `json:"queue"`
+	// Meeting backs up only the meeting with this UUID.
+	Meeting string `json:"meeting"`
+}
+
+// ZoomBackupPubSub is the entry point of the Cloud Function when it is
+// triggered through Pub/Sub instead of HTTP. The message selects what to back
+// up; an empty one backs up like an HTTP request. Invalid messages are logged
+// and dropped rather than returned as errors, which would have Pub/Sub
+// deliver them again.
+func ZoomBackupPubSub(ctx context.Context, m PubSubMessage) error {
+	req := &triggerRequest{}
+	if len(m.Data) > 0 {
+		if err := json.Unmarshal(m.Data, req); err != nil {
+			log.Println("Ignoring invalid Pub/Sub message:", err)
+			return nil
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	events, err := openEventStream(cfg.EventsOut)
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	storageClient, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("Error creating new storage client: %w", err)
+	}
+
+	jobs, err := loadJobs(ctx, storageClient, cfg)
+	if err != nil {
+		return err
+	}
+	jobs, err = req.apply(jobs)
+	if err != nil {
+		log.Println("Ignoring invalid Pub/Sub message:", err)
+		return nil
+	}
+
+	runJobs(ctx, storageClient, jobs, events)
+	return nil
+}
+
+// apply narrows the jobs to what the request asks for.
+func (req *triggerRequest) apply(jobs []*config) ([]*config, error) {
+	if req.Job != "" {
+		job, err := selectJob(jobs, req.Job)
+		if err != nil {
+			return nil, err
+		}
+		jobs = []*config{job}
+	}
+	if req.Days < 0 {
+		return nil, errors.New("days must not be negative")
+	}
+	if req.Queue != "" && req.Queue != queueWork {
+		return nil, fmt.Errorf("queue must be %q", queueWork)
+	}
+	if req.Meeting != "" && len(jobs) > 1 {
+		return nil, errors.New("meeting requires job when several jobs are configured")
+	}
+	for _, job := range jobs {
+		job.triggerUsers = req.Users
+		job.queueWorker = req.Queue == queueWork
+		job.meetingUUID = req.Meeting
+		if req.Days > 0 {
+			job.LookbackDays = req.Days
+			job.ListSinceLastSuccess = false
+		}
+		if req.From == "" {
+			if req.To != "" {
+				return nil, errors.New("to requires from")
+			}
+			continue
+		}
+		from, err := time.ParseInLocation(ymdFormat, req.From, job.location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+		to := time.Now().In(job.location)
+		if req.To != "" {
+			if to, err = time.ParseInLocation(ymdFormat, req.To, job.location); err != nil {
+				return nil, fmt.Errorf("invalid to: %w", err)
+			}
+			// Include the whole last day.
+			to = to.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		if to.Before(from) {
+			return nil, errors.New("to must not be before from")
+		}
+		job.listFrom, job.listTo = from, to
+	}
+	return jobs, nil
+}
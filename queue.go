@@ -0,0 +1,216 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	firestore "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	queueModeFirestore = "firestore"
+
+	// queueWork asks a trigger to work the queue.
+	queueWork = "work"
+
+	// queueCollection holds the queued meetings below the job's document
+	// in QUEUE_COLLECTION.
+	queueCollection = "meetings"
+)
+
+// queueNever is the lease of meetings that failed QUEUE_MAX_ATTEMPTS times,
+// which no worker claims again.
+var queueNever = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// meetingQueue keeps the meetings of a job in Firestore for QUEUE_MODE, one
+// document per meeting. Enumerating runs add the meetings they discover and
+// worker runs lease them one at a time, so an account too large for a single
+// invocation is archived by many. A document is deleted once its meeting was
+// archived; until then its lease runs out and another worker retries it.
+type meetingQueue struct {
+	service  *firestore.Service
+	database string
+	parent   string
+}
+
+func newMeetingQueue(ctx context.Context, cfg *config) (*meetingQueue, error) {
+	service, err := firestore.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Firestore client: %w", err)
+	}
+	job := cfg.JobName
+	if job == "" {
+		job = "default"
+	}
+	database := fmt.Sprintf("projects/%s/databases/(default)", cfg.QueueProject)
+	return &meetingQueue{
+		service:  service,
+		database: database,
+		parent:   fmt.Sprintf("%s/documents/%s/%s", database, cfg.QueueCollection, job),
+	}, nil
+}
+
+// queueDocumentID turns a meeting UUID, which may contain slashes, into a
+// document ID.
+func queueDocumentID(meetingID string) string {
+	return strings.Replace(meetingID, "/", "_", -1)
+}
+
+func queueTimestamp(t time.Time) *firestore.Value {
+	return &firestore.Value{TimestampValue: t.UTC().Format(time.RFC3339Nano)}
+}
+
+// enqueueMeetings adds the meetings to the job's queue. Meetings that are
+// queued already keep their document, lease and attempts.
+func (run *backupRun) enqueueMeetings(ctx context.Context, meetings []meeting) error {
+	docs := run.queueDocs()
+	added := 0
+	for _, m := range meetings {
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		_, err = docs.CreateDocument(run.queue.parent, queueCollection, &firestore.Document{
+			Fields: map[string]firestore.Value{
+				"meeting":     {StringValue: string(raw)},
+				"topic":       {StringValue: m.Topic},
+				"enqueued_at": *queueTimestamp(now),
+				"lease_until": *queueTimestamp(now),
+				"attempts":    {IntegerValue: 0, ForceSendFields: []string{"IntegerValue"}},
+			},
+		}).DocumentId(queueDocumentID(m.ID)).Context(ctx).Do()
+		if isQueueConflict(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to enqueue %s: %w", m.ID, err)
+		}
+		added++
+	}
+	log.Printf("Queued %d of %d meetings for job %s", added, len(meetings), run.cfg.JobName)
+	return nil
+}
+
+// isQueueConflict reports whether Firestore refused a write because the
+// document exists already or another worker changed it first.
+func isQueueConflict(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	return ok && (e.Code == http.StatusConflict || strings.Contains(e.Body, "FAILED_PRECONDITION"))
+}
+
+func (run *backupRun) queueDocs() *firestore.ProjectsDatabasesDocumentsService {
+	return run.queue.service.Projects.Databases.Documents
+}
+
+// workQueue leases and archives meetings from the job's queue, up to
+// QUEUE_BATCH at a time, until the queue has none left to lease or
+// QUEUE_WORK_TIME is up.
+func (run *backupRun) workQueue(ctx context.Context) error {
+	deadline := time.Now().Add(run.cfg.QueueWorkTime)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		leased, err := run.leaseMeetings(ctx)
+		if err != nil {
+			return err
+		}
+		if len(leased) == 0 {
+			log.Println("No meetings left to lease for job", run.cfg.JobName)
+			return nil
+		}
+		var wg sync.WaitGroup
+		for _, l := range leased {
+			wg.Add(1)
+			go func(l leasedMeeting) {
+				defer wg.Done()
+				run.report.meetingDiscovered()
+				run.emit(eventDiscovered, l.meeting, event{Bytes: meetingSize(l.meeting)})
+				done := run.processMeeting(ctx, l.meeting)
+				run.finishLease(ctx, l, done)
+			}(l)
+		}
+		wg.Wait()
+	}
+	return nil
+}
+
+// leasedMeeting is a meeting a worker claimed from the queue.
+type leasedMeeting struct {
+	meeting  meeting
+	name     string
+	attempts int64
+}
+
+// leaseMeetings claims up to QUEUE_BATCH meetings whose lease ran out. A
+// document another worker updated in the meantime is left to that worker.
+func (run *backupRun) leaseMeetings(ctx context.Context) ([]leasedMeeting, error) {
+	docs := run.queueDocs()
+	resp, err := docs.List(run.queue.parent, queueCollection).OrderBy("lease_until").PageSize(int64(run.cfg.QueueBatch) * 2).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued meetings: %w", err)
+	}
+	now := time.Now()
+	var leased []leasedMeeting
+	for _, doc := range resp.Documents {
+		if len(leased) >= run.cfg.QueueBatch {
+			break
+		}
+		leaseUntil, _ := time.Parse(time.RFC3339Nano, doc.Fields["lease_until"].TimestampValue)
+		if leaseUntil.After(now) {
+			break
+		}
+		var m meeting
+		if err := json.Unmarshal([]byte(doc.Fields["meeting"].StringValue), &m); err != nil {
+			log.Println("Dropping unreadable queued meeting", doc.Name, err)
+			if _, err := docs.Delete(doc.Name).Context(ctx).Do(); err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+		attempts := doc.Fields["attempts"].IntegerValue + 1
+		_, err := docs.Patch(doc.Name, &firestore.Document{
+			Fields: map[string]firestore.Value{
+				"lease_until": *queueTimestamp(now.Add(run.cfg.QueueLease)),
+				"attempts":    {IntegerValue: attempts},
+			},
+		}).UpdateMaskFieldPaths("lease_until", "attempts").CurrentDocumentUpdateTime(doc.UpdateTime).Context(ctx).Do()
+		if isQueueConflict(err) {
+			continue
+		}
+		if err != nil {
+			return leased, fmt.Errorf("failed to lease %s: %w", m.ID, err)
+		}
+		// The audio of audio-only meetings is selected again, as it does
+		// not survive the queue.
+		leased = append(leased, leasedMeeting{meeting: selectAudio(run.cfg, selectViews(run.cfg, []meeting{m}))[0], name: doc.Name, attempts: attempts})
+	}
+	return leased, nil
+}
+
+// finishLease removes an archived meeting from the queue. A meeting that
+// failed is retried by the worker that leases it once its lease ran out,
+// unless it failed QUEUE_MAX_ATTEMPTS times already, which parks it.
+func (run *backupRun) finishLease(ctx context.Context, l leasedMeeting, done bool) {
+	docs := run.queueDocs()
+	var err error
+	switch {
+	case done:
+		_, err = docs.Delete(l.name).Context(ctx).Do()
+	case l.attempts >= int64(run.cfg.QueueMaxAttempts):
+		log.Println("Giving up on queued meeting", l.meeting.ID, "after", l.attempts, "attempts")
+		_, err = docs.Patch(l.name, &firestore.Document{
+			Fields: map[string]firestore.Value{"lease_until": *queueTimestamp(queueNever)},
+		}).UpdateMaskFieldPaths("lease_until").Context(ctx).Do()
+	}
+	if err != nil {
+		err = fmt.Errorf("Could not update queued meeting %s: %v", l.meeting.ID, err)
+		log.Println(err)
+		run.report.fail(err)
+	}
+}
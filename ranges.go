@@ -0,0 +1,190 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// downloadRecording downloads a recording file larger than
+// DOWNLOAD_RANGE_SIZE in ranges over DOWNLOAD_CONNECTIONS connections and
+// every other file in a single request.
+func (run *backupRun) downloadRecording(ctx context.Context, recording recordingFile) (io.ReadCloser, int64, error) {
+	cfg := run.cfg
+	timeout := downloadTimeout(cfg, recording.FileSize)
+	if cfg.DownloadConnections > 1 && recording.FileSize > cfg.DownloadRangeSize {
+		return run.zoom.downloadRanges(ctx, recording.DownloadURL, cfg.DownloadRangeSize, cfg.DownloadConnections, cfg.DownloadRetries, timeout)
+	}
+	return run.zoom.downloadFile(ctx, recording.DownloadURL, timeout)
+}
+
+// downloadRanges downloads a recording file in ranges of rangeSize, up to
+// connections of them at a time, and returns its body put back together in
+// order along with its size. Each range is retried up to retries times. A
+// server that does not support ranges answers the first request with the
+// whole file, which is then streamed like any other download. The whole
+// download has to finish within timeout.
+func (c *zoomClient) downloadRanges(ctx context.Context, fileURL string, rangeSize int64, connections, retries int, timeout time.Duration) (io.ReadCloser, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	resp, err := c.downloadRequest(ctx, fileURL, byteRange(0, rangeSize-1))
+	if err != nil {
+		cancel()
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}, resp.ContentLength, nil
+	}
+	size, err := contentRangeSize(resp.Header.Get("Content-Range"))
+	if err == nil && size <= 0 {
+		err = fmt.Errorf("invalid Content-Range %q", resp.Header.Get("Content-Range"))
+	}
+	var first []byte
+	if err == nil {
+		first, err = readRange(resp, 0, rangeSize, size)
+	}
+	_ = resp.Body.Close()
+	if err != nil {
+		cancel()
+		return nil, 0, fmt.Errorf("failed to download recording: %w", err)
+	}
+
+	count := int((size + rangeSize - 1) / rangeSize)
+	r := &rangeReader{
+		buf:    first,
+		next:   1,
+		ranges: make([]chan rangeResult, count),
+		slots:  make(chan struct{}, connections),
+		cancel: cancel,
+	}
+	for i := range r.ranges {
+		r.ranges[i] = make(chan rangeResult, 1)
+	}
+	go func() {
+		for i := 1; i < count; i++ {
+			select {
+			case r.slots <- struct{}{}:
+			case <-ctx.Done():
+				r.ranges[i] <- rangeResult{err: ctx.Err()}
+				return
+			}
+			go func(i int) {
+				data, err := c.fetchRange(ctx, fileURL, int64(i)*rangeSize, rangeSize, size, retries)
+				r.ranges[i] <- rangeResult{data: data, err: err}
+			}(i)
+		}
+	}()
+	return r, size, nil
+}
+
+// fetchRange downloads the range of the file starting at offset.
+func (c *zoomClient) fetchRange(ctx context.Context, fileURL string, offset, rangeSize, size int64, retries int) ([]byte, error) {
+	last := offset + rangeSize - 1
+	if last >= size {
+		last = size - 1
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := c.downloadRequest(ctx, fileURL, byteRange(offset, last))
+		var data []byte
+		if err == nil {
+			if resp.StatusCode != http.StatusPartialContent {
+				err = fmt.Errorf("server ignored the range %d-%d", offset, last)
+			} else {
+				data, err = readRange(resp, offset, rangeSize, size)
+			}
+			_ = resp.Body.Close()
+		}
+		if err == nil || attempt >= retries || ctx.Err() != nil {
+			return data, err
+		}
+		wait, retry := downloadRetryDelay(err, attempt+1)
+		if !retry {
+			return nil, err
+		}
+		log.Printf("Retrying bytes %d-%d in %v after %v", offset, last, wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// readRange reads the body of a partial response for the range starting at
+// offset.
+func readRange(resp *http.Response, offset, rangeSize, size int64) ([]byte, error) {
+	length := size - offset
+	if length > rangeSize {
+		length = rangeSize
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		return nil, fmt.Errorf("failed to read bytes %d-%d: %w", offset, offset+length-1, err)
+	}
+	return data, nil
+}
+
+func byteRange(first, last int64) string {
+	return fmt.Sprintf("bytes=%d-%d", first, last)
+}
+
+// contentRangeSize returns the complete size of a Content-Range header such
+// as bytes 0-1023/146515.
+func contentRangeSize(header string) (int64, error) {
+	i := strings.LastIndex(header, "/")
+	if !strings.HasPrefix(header, "bytes ") || i < 0 {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	size, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	return size, nil
+}
+
+// rangeReader reads the ranges of a download in order while the next ones
+// are being fetched. A range gives its connection slot back once it is read,
+// so at most connections ranges wait in memory.
+type rangeReader struct {
+	buf    []byte
+	next   int
+	ranges []chan rangeResult
+	slots  chan struct{}
+	err    error
+	cancel context.CancelFunc
+}
+
+type rangeResult struct {
+	data []byte
+	err  error
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.next == len(r.ranges) {
+			return 0, io.EOF
+		}
+		res := <-r.ranges[r.next]
+		r.next++
+		if res.err == nil {
+			<-r.slots
+		}
+		r.buf, r.err = res.data, res.err
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Close stops fetching the ranges that were not read.
+func (r *rangeReader) Close() error {
+	r.cancel()
+	return nil
+}
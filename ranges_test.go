@@ -0,0 +1,69 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownloadRanges(t *testing.T) {
+	content := make([]byte, 10*1000+123)
+	for i := range content {
+		content[i] = byte(i * 7)
+	}
+	tests := []struct {
+		name string
+		// ignoreRange answers every request with the whole file.
+		ignoreRange  bool
+		rangeSize    int64
+		wantRequests int
+	}{
+		{"short last range", false, 1000, 11},
+		{"ranges of the whole file", false, int64(len(content)), 1},
+		{"one byte more than a range", false, int64(len(content)) - 1, 2},
+		{"server ignores ranges", true, 1000, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := 0
+			api := http.NewServeMux()
+			api.HandleFunc("/rec/download/a1", func(w http.ResponseWriter, r *http.Request) {
+				if !requireToken(w, r) {
+					return
+				}
+				mu.Lock()
+				requests++
+				mu.Unlock()
+				if tt.ignoreRange {
+					r.Header.Del("Range")
+				}
+				http.ServeContent(w, r, "a1.mp4", time.Time{}, bytes.NewReader(content))
+			})
+			c, srv := newTestZoom(t, api)
+
+			body, size, err := c.downloadRanges(context.Background(), srv.URL+"/v2/rec/download/a1", tt.rangeSize, 3, 0, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer body.Close()
+			got, err := ioutil.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != int64(len(content)) {
+				t.Errorf("got size %d, want %d", size, len(content))
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("got %d bytes that differ from the %d of the file", len(got), len(content))
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+)
+
+// rclone exit codes for a missing directory or file.
+const (
+	rcloneDirNotFound  = 3
+	rcloneFileNotFound = 4
+)
+
+// rcloneMirror copies archived recordings to any rclone remote by running
+// the rclone binary, so every backend rclone supports can be a destination.
+type rcloneMirror struct {
+	binary      string
+	destination string
+	// config is the contents of a managed rclone.conf, written to a
+	// private temporary file when first needed.
+	config string
+
+	mu         sync.Mutex
+	configFile string
+}
+
+func newRcloneMirror(cfg *config) *rcloneMirror {
+	return &rcloneMirror{binary: cfg.RcloneBinary, destination: cfg.RcloneDestination, config: cfg.RcloneManagedConfig}
+}
+
+func (m *rcloneMirror) String() string {
+	return "rclone " + m.destination
+}
+
+func (m *rcloneMirror) exists(ctx context.Context, name string, size int64) (bool, error) {
+	target := m.path(name)
+	dir, base := path.Split(target)
+	out, err := m.run(ctx, nil, "lsjson", "--files-only", "--no-modtime", "--no-mimetype", dir)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && (exitErr.ExitCode() == rcloneDirNotFound || exitErr.ExitCode() == rcloneFileNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var entries []struct {
+		Name string
+		Size int64
+	}
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return false, fmt.Errorf("failed to parse rclone lsjson output: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name == base && entry.Size == size {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// upload streams the recording into rclone rcat, which uploads it without a
+// local copy where the backend allows.
+func (m *rcloneMirror) upload(ctx context.Context, name string, size int64, contentType string, r io.Reader) error {
+	_, err := m.run(ctx, r, "rcat", m.path(name))
+	return err
+}
+
+// path joins the destination, which may be just a remote like nas: or a
+// remote with a path, and name.
+func (m *rcloneMirror) path(name string) string {
+	if strings.HasSuffix(m.destination, ":") {
+		return m.destination + name
+	}
+	return strings.TrimSuffix(m.destination, "/") + "/" + name
+}
+
+// run runs the rclone command with args, feeding it stdin, and returns its
+// output. The error includes what rclone logged.
+func (m *rcloneMirror) run(ctx context.Context, stdin io.Reader, command string, args ...string) ([]byte, error) {
+	configFile, err := m.managedConfig()
+	if err != nil {
+		return nil, err
+	}
+	args = append([]string{command}, args...)
+	if configFile != "" {
+		args = append([]string{"--config", configFile}, args...)
+	}
+	cmd := exec.CommandContext(ctx, m.binary, args...)
+	cmd.Stdin = stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("rclone %s: %w -- %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// managedConfig returns the path of the managed config file, none without
+// one.
+func (m *rcloneMirror) managedConfig() (string, error) {
+	if m.config == "" {
+		return "", nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.configFile != "" {
+		return m.configFile, nil
+	}
+	f, err := ioutil.TempFile("", "rclone-*.conf")
+	if err != nil {
+		return "", fmt.Errorf("failed to write rclone config: %w", err)
+	}
+	_, err = f.WriteString(m.config)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write rclone config: %w", err)
+	}
+	m.configFile = f.Name()
+	return m.configFile, nil
+}
+
+// Close removes the managed config file.
+func (m *rcloneMirror) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.configFile == "" {
+		return nil
+	}
+	err := os.Remove(m.configFile)
+	m.configFile = ""
+	return err
+}
@@ -0,0 +1,118 @@
+package zoombackup
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+const (
+	zoomTrashRecordingsPath = "/users/%s/recordings?trash=true&trash_type=meeting_recordings&page_size=300&from=%s&to=%s"
+	zoomRecordingStatusPath = "/meetings/%s/recordings/status"
+)
+
+func recoverCommand(args []string) int {
+	fs := flag.NewFlagSet("zoom-backup recover", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: zoom-backup recover [flags]")
+		fmt.Fprintln(fs.Output(), "Archives the recordings in Zoom's trash before Zoom purges them after 30 days, or restores them with -restore.")
+		fs.PrintDefaults()
+	}
+	job := fs.String("job", "", "job whose users' trash to recover; required with several jobs")
+	days := fs.Int("days", 0, "look at recordings of meetings of the last this many days instead of LOOKBACK_DAYS")
+	restore := fs.Bool("restore", false, "move the recordings back out of the trash instead of archiving them")
+	dryRun := fs.Bool("dry-run", false, "only list the recordings in the trash")
+	if err := fs.Parse(args); err != nil {
+		return exitFatal
+	}
+	if *days < 0 {
+		log.Println("-days must not be negative")
+		return exitFatal
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	storageClient, jobs, err := setupCLI(ctx, func(*config) error { return nil })
+	if err != nil {
+		log.Println(err)
+		return exitFatal
+	}
+	cfg, err := selectJob(jobs, *job)
+	if err != nil {
+		log.Println(err)
+		return exitFatal
+	}
+	cfg.trash = true
+	if *days > 0 {
+		cfg.LookbackDays = *days
+	}
+
+	if *restore || *dryRun {
+		if err := restoreTrash(ctx, cfg, *dryRun); err != nil {
+			log.Println(err)
+			return exitFatal
+		}
+		return exitOK
+	}
+
+	// Trashed recordings are archived like any others, but never deleted:
+	// they are on their way out of Zoom already.
+	cfg.DeleteFromZoom = false
+	cfg.CanaryMode = ""
+	cfg.QueueMode = ""
+	events, err := openEventStream(cfg.EventsOut)
+	if err != nil {
+		log.Println(err)
+		return exitFatal
+	}
+	defer events.Close()
+	reports := runJobs(ctx, storageClient, []*config{cfg}, events)
+	printSummary(os.Stderr, reports)
+	return exitCode(reports)
+}
+
+// restoreTrash prints the recordings in the trash of the job's users and,
+// unless dryRun is set, moves them back out of the trash.
+func restoreTrash(ctx context.Context, cfg *config, dryRun bool) error {
+	zoom, err := newZoomClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	userIDs, err := resolveUserIDs(ctx, zoom, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to resolve users: %w", err)
+	}
+	oldest, newest := cfg.listRange()
+	failed := 0
+	for _, userID := range userIDs {
+		meetings, err := zoom.listRecordingsBetween(ctx, userID, true, oldest, newest, nil)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the trash of %s: %w", userID, err)
+		}
+		for _, mtg := range meetings {
+			fmt.Printf("%s\t%s\t%s\t%s\n", userID, mtg.StartTime, mtg.ID, mtg.Topic)
+			if dryRun {
+				continue
+			}
+			if err := zoom.recoverRecordings(ctx, mtg.ID); err != nil {
+				log.Println("Could not restore", mtg.ID, err)
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d meetings could not be restored", failed)
+	}
+	return nil
+}
+
+// recoverRecordings moves all recordings of the meeting out of the trash.
+func (c *zoomClient) recoverRecordings(ctx context.Context, meetingID string) error {
+	if _, err := c.do(ctx, "PUT", fmt.Sprintf(zoomRecordingStatusPath, meetingPathID(meetingID)), map[string]string{"action": "recover"}); err != nil {
+		return fmt.Errorf("failed to recover recordings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package zoombackup
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// runReport summarises one job's run. Each job gets its own report so jobs
+// sharing an invocation never mix up their results.
+type runReport struct {
+	mu sync.Mutex
+
+	Job           string    `json:"job"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	Paused        bool      `json:"paused,omitempty"`
+	OutsideWindow bool      `json:"outside_window,omitempty"`
+	Cancelled     bool      `json:"cancelled,omitempty"`
+	Fatal         string    `json:"fatal,omitempty"`
+	// Policy names the weekday policies the run applied.
+	Policy          string `json:"policy,omitempty"`
+	Canary          string `json:"canary,omitempty"`
+	Users           int    `json:"users"`
+	Meetings        int    `json:"meetings"`
+	FilesArchived   int    `json:"files_archived"`
+	FilesFailed     int    `json:"files_failed"`
+	FilesSkipped    int    `json:"files_skipped,omitempty"`
+	BytesArchived   int64  `json:"bytes_archived"`
+	MeetingsDeleted int    `json:"meetings_deleted"`
+	SettingsDrift   int    `json:"settings_drift,omitempty"`
+	// MeetingsDeferred were left for the next run by the end of
+	// ALLOWED_HOURS.
+	MeetingsDeferred int `json:"meetings_deferred,omitempty"`
+	// MeetingsPostponed were left for later runs by MAX_MEETINGS_PER_RUN.
+	MeetingsPostponed int `json:"meetings_postponed,omitempty"`
+	// VerifyProblems counts the problems VERIFY_DAYS found in the archive.
+	VerifyProblems int `json:"verify_problems,omitempty"`
+	// ObjectsPruned were deleted or moved to ARCHIVE by retention.
+	ObjectsPruned int `json:"objects_pruned,omitempty"`
+	// PeakMemoryBytes is the most memory in use after any file.
+	PeakMemoryBytes int64 `json:"peak_memory_bytes,omitempty"`
+	// IndexError tells why the index could not be generated or was
+	// degraded.
+	IndexError string   `json:"index_error,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+	// Deprecations lists the Zoom API endpoints used during the run that
+	// Zoom announced to be deprecated or sunset.
+	Deprecations []apiDeprecation `json:"zoom_api_deprecations,omitempty"`
+	// Timelines has the outcome and steps of every meeting and its files
+	// in the order they were discovered.
+	Timelines []*meetingTimeline `json:"timelines,omitempty"`
+	timelines map[string]int
+	// notifiers are the job's notification channels once the run started.
+	notifiers []notifier
+	// listedWindow is set when the run listed the recordings of its regular
+	// window, processing when any meeting had files Zoom was still
+	// processing and withheld when any meeting was archived but kept on
+	// Zoom, e.g. because the control object disabled deletions. A later run
+	// has to list those again.
+	listedWindow bool
+	processing   bool
+	withheld     bool
+}
+
+func newRunReport(job string) *runReport {
+	return &runReport{Job: job, StartedAt: time.Now().UTC()}
+}
+
+// meetingDiscovered counts a meeting leased from the queue.
+func (r *runReport) meetingDiscovered() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Meetings++
+}
+
+// fileArchived counts a file uploaded to object.
+func (r *runReport) fileArchived(mtg meeting, file, object string, bytes int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.FilesArchived++
+	r.BytesArchived += bytes
+	r.fileOutcome(mtg, file, outcomeArchived, object, bytes, nil)
+}
+
+// fileArchivedBefore records a file an earlier attempt of the run archived
+// already without counting it again.
+func (r *runReport) fileArchivedBefore(mtg meeting, file, object string, bytes int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fileOutcome(mtg, file, outcomeArchived, object, bytes, nil)
+}
+
+func (r *runReport) fileFailed(mtg meeting, file string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.FilesFailed++
+	r.Errors = append(r.Errors, err.Error())
+	r.fileOutcome(mtg, file, outcomeFailed, "", 0, err)
+}
+
+// fileOutcome records what became of a file of the meeting. The caller
+// holds r.mu.
+func (r *runReport) fileOutcome(mtg meeting, name, outcome, object string, bytes int64, err error) {
+	file := r.timeline(mtg.ID, mtg.Topic).file(name)
+	file.Outcome = outcome
+	file.Object = object
+	file.Bytes = bytes
+	if err != nil {
+		file.Error = err.Error()
+	}
+}
+
+func (r *runReport) memorySampled(bytes int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if bytes > r.PeakMemoryBytes {
+		r.PeakMemoryBytes = bytes
+	}
+}
+
+func (r *runReport) meetingDeleted() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.MeetingsDeleted++
+}
+
+func (r *runReport) meetingDeferred(mtg meeting) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.MeetingsDeferred++
+	r.timeline(mtg.ID, mtg.Topic).Outcome = outcomeDeferred
+}
+
+// meetingsPostponed counts meetings left for later runs by
+// MAX_MEETINGS_PER_RUN.
+func (r *runReport) meetingsPostponed(meetings []meeting) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.MeetingsPostponed += len(meetings)
+	for _, m := range meetings {
+		r.FilesSkipped += len(m.Files)
+		r.timeline(m.ID, m.Topic).Outcome = outcomePostponed
+	}
+}
+
+// meetingsQueued records meetings handed to the queue of QUEUE_MODE.
+func (r *runReport) meetingsQueued(meetings []meeting) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range meetings {
+		r.timeline(m.ID, m.Topic).Outcome = outcomeQueued
+	}
+}
+
+// filesSkipped counts files of the meeting left on Zoom for later runs.
+func (r *runReport) filesSkipped(mtg meeting, files []recordingFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.FilesSkipped += len(files)
+	for _, f := range files {
+		r.fileOutcome(mtg, f.FileName(), outcomeSkipped, "", 0, nil)
+	}
+}
+
+// settingsDrifted counts recording settings that differed from
+// RECORDING_SETTINGS.
+func (r *runReport) settingsDrifted(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.SettingsDrift += n
+}
+
+// cancelled records that the run was stopped before it finished.
+func (r *runReport) cancelled() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Cancelled = true
+	r.Errors = append(r.Errors, "run cancelled")
+}
+
+// verified records the problems found by VERIFY_DAYS; any fail the run.
+func (r *runReport) verified(problems int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.VerifyProblems += problems
+	if problems > 0 {
+		r.Errors = append(r.Errors, fmt.Sprintf("verify found %d problems in the archive", problems))
+	}
+}
+
+func (r *runReport) objectsPruned(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ObjectsPruned += n
+}
+
+// indexFailed records that the index could not be generated or was degraded.
+func (r *runReport) indexFailed(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.IndexError = err.Error()
+	r.Errors = append(r.Errors, err.Error())
+}
+
+// failed reports whether any file or step of the run failed.
+func (r *runReport) failed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.FilesFailed > 0 || len(r.Errors) > 0
+}
+
+// abort records the error that stopped the run.
+func (r *runReport) abort(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Fatal = err.Error()
+	r.Errors = append(r.Errors, err.Error())
+}
+
+// status sums up the outcome of the run in a word. The caller holds r.mu.
+func (r *runReport) status() string {
+	switch {
+	case r.Fatal != "":
+		return "fatal"
+	case r.Cancelled:
+		return "cancelled"
+	case r.FilesFailed > 0 || len(r.Errors) > 0:
+		return "partial"
+	case r.Paused:
+		return "paused"
+	case r.OutsideWindow:
+		return "outside-window"
+	}
+	return "ok"
+}
+
+// windowListed records that the run listed its regular window.
+func (r *runReport) windowListed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listedWindow = true
+}
+
+// meetingProcessing records a meeting with files Zoom was still processing.
+func (r *runReport) meetingProcessing() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processing = true
+}
+
+// deletionWithheld records a meeting whose deletion from Zoom was withheld.
+func (r *runReport) deletionWithheld() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.withheld = true
+}
+
+// listedCompletely tells whether the run listed its regular window and left
+// nothing in it for later runs, so the next run may list from its start.
+// The caller holds r.mu.
+func (r *runReport) listedCompletely() bool {
+	return r.status() == "ok" && r.listedWindow && !r.processing && !r.withheld &&
+		r.FilesSkipped == 0 && r.MeetingsDeferred == 0 && r.MeetingsPostponed == 0
+}
+
+// fail records an error that is not tied to a single file.
+func (r *runReport) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Errors = append(r.Errors, err.Error())
+}
+
+func (r *runReport) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.FinishedAt = time.Now().UTC()
+	r.settleOutcomes()
+	r.Deprecations = zoomDeprecations.since(r.StartedAt)
+}
+
+func (r *runReport) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fmt.Sprintf("job %s: %d users, %d meetings, %d files archived (%s), %d files failed, %d files skipped, %d meetings deleted, %d errors in %s",
+		r.Job, r.Users, r.Meetings, r.FilesArchived, humanBytes(r.BytesArchived), r.FilesFailed,
+		r.FilesSkipped, r.MeetingsDeleted, len(r.Errors), r.FinishedAt.Sub(r.StartedAt).Round(time.Second))
+}
+
+func (r *runReport) log() {
+	log.Println("Finished", r.String())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.Deprecations {
+		log.Printf("WARNING job %s uses deprecated Zoom API endpoint=%q sunset=%q link=%q", r.Job, d.Endpoint, d.Sunset, d.Link)
+	}
+}
@@ -0,0 +1,275 @@
+package zoombackup
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/klauspost/compress/zstd"
+)
+
+type restoreOptions struct {
+	meetings map[string]bool
+	// from and to bound the meetings' start dates, both inclusive. Zero
+	// values leave the range open.
+	from, to time.Time
+	outDir   string
+	share    bool
+	expiry   time.Duration
+	force    bool
+	out      io.Writer
+}
+
+func restoreCommand(args []string) int {
+	fs := flag.NewFlagSet("zoom-backup restore", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: zoom-backup restore [flags] [MEETING_UUID...]")
+		fmt.Fprintln(fs.Output(), "Downloads archived meetings to local disk, or prints signed URLs to share them.")
+		fs.PrintDefaults()
+	}
+	job := fs.String("job", "", "job whose bucket and manifest to use; required with several jobs")
+	from := fs.String("from", "", "restore meetings that started on or after this date, YYYY-MM-DD in TIMEZONE")
+	to := fs.String("to", "", "restore meetings that started on or before this date, YYYY-MM-DD in TIMEZONE")
+	outDir := fs.String("out", ".", "directory to restore the recordings into")
+	share := fs.Bool("share", false, "print signed URLs instead of downloading; requires SIGNING_SERVICE_ACCOUNT")
+	expiry := fs.Duration("expiry", 24*time.Hour, "how long signed URLs stay valid, at most 168h")
+	force := fs.Bool("force", false, "overwrite files that already exist locally")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 && *from == "" && *to == "" {
+		fs.Usage()
+		return 2
+	}
+	if *expiry <= 0 || *expiry > 7*24*time.Hour {
+		log.Println("-expiry must be between 0 and 168h")
+		return 2
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	storageClient, jobs, err := setupCLI(ctx, func(*config) error { return nil })
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	cfg, err := selectJob(jobs, *job)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	opts := restoreOptions{
+		meetings: map[string]bool{},
+		outDir:   *outDir,
+		share:    *share,
+		expiry:   *expiry,
+		force:    *force,
+		out:      os.Stdout,
+	}
+	for _, uuid := range fs.Args() {
+		opts.meetings[uuid] = true
+	}
+	for flagValue, dst := range map[string]*time.Time{*from: &opts.from, *to: &opts.to} {
+		if flagValue == "" {
+			continue
+		}
+		if *dst, err = time.ParseInLocation(ymdFormat, flagValue, cfg.location); err != nil {
+			log.Println("Invalid date:", err)
+			return 2
+		}
+	}
+	if !opts.to.IsZero() {
+		opts.to = opts.to.AddDate(0, 0, 1)
+	}
+
+	if err := restore(ctx, storageClient, cfg, opts); err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// restore brings the selected meetings of the manifest back: each file is
+// downloaded, decrypted and decompressed to <out>/<folder>/<recording file
+// name>, mirroring the archive's layout with the names Zoom gave the files,
+// or shared through a signed URL.
+func restore(ctx context.Context, storageClient *storage.Client, cfg *config, opts restoreOptions) error {
+	m, err := loadManifest(ctx, storageClient, cfg)
+	if err != nil {
+		return err
+	}
+	var signer *urlSigner
+	if opts.share {
+		if cfg.encryptionKey != nil {
+			return errors.New("objects encrypted with ENCRYPTION_KEY cannot be shared through signed URLs, restore them to disk instead")
+		}
+		if signer, err = newURLSigner(ctx, cfg); err != nil {
+			return err
+		}
+		if signer == nil {
+			return errors.New("-share requires SIGNING_SERVICE_ACCOUNT")
+		}
+	}
+
+	run := &backupRun{cfg: cfg, storageClient: storageClient}
+	meetings, files, failed := 0, 0, 0
+	for _, mtg := range m.Meetings {
+		if !opts.selects(mtg) {
+			continue
+		}
+		meetings++
+		for _, file := range mtg.Files {
+			if file.Object == "" {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			name := restoredName(cfg, mtg, file)
+			if opts.share {
+				link, err := signer.objectURL(cfg.Bucket, file.Object, opts.expiry)
+				if err != nil {
+					log.Printf("Could not sign %s: %v", file.Object, err)
+					failed++
+					continue
+				}
+				if strings.HasSuffix(file.Object, zstdExt) {
+					name += zstdExt
+				}
+				fmt.Fprintf(opts.out, "%s\t%s\n", name, link)
+				files++
+				continue
+			}
+			restored, err := run.restoreFile(ctx, file.Object, filepath.Join(opts.outDir, filepath.FromSlash(name)), opts.force)
+			if err != nil {
+				log.Printf("Could not restore %s: %v", file.Object, err)
+				failed++
+				continue
+			}
+			if restored {
+				files++
+			}
+		}
+	}
+
+	log.Printf("Restored %d files of %d meetings, %d failed", files, meetings, failed)
+	if meetings == 0 {
+		return errors.New("no archived meeting matches")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d files could not be restored", failed)
+	}
+	return nil
+}
+
+// selects reports whether the meeting was asked for by UUID or starts within
+// the date range.
+func (opts restoreOptions) selects(mtg manifestMeeting) bool {
+	if opts.meetings[mtg.UUID] {
+		return true
+	}
+	if opts.from.IsZero() && opts.to.IsZero() {
+		return false
+	}
+	start, err := time.Parse(time.RFC3339, mtg.StartTime)
+	if err != nil {
+		return false
+	}
+	return !start.Before(opts.from) && (opts.to.IsZero() || start.Before(opts.to))
+}
+
+// restoredName is the file's path relative to the restore directory: its
+// folder below PREFIX and the file name Zoom's recording metadata gives it.
+func restoredName(cfg *config, mtg manifestMeeting, file manifestFile) string {
+	folder := mtg.Folder
+	if cfg.Prefix != "" {
+		folder = strings.TrimPrefix(strings.TrimPrefix(folder, cfg.Prefix), "/")
+	}
+	name := recordingFile{
+		RecordingStart: file.RecordingStart,
+		RecordingType:  file.RecordingType,
+		FileType:       file.FileType,
+		Segment:        file.Segment,
+	}.FileName()
+	if folder == "" || folder == "." {
+		return name
+	}
+	return folder + "/" + name
+}
+
+// restoreFile downloads the object to dst through a temporary file, so an
+// interrupted restore never leaves a truncated recording behind. Existing
+// files are kept unless force is set.
+func (run *backupRun) restoreFile(ctx context.Context, object, dst string, force bool) (bool, error) {
+	if _, err := os.Stat(dst); err == nil && !force {
+		log.Println("Keeping existing", dst)
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return false, err
+	}
+
+	content, err := run.openStored(ctx, object)
+	if err != nil {
+		return false, err
+	}
+	defer content.Close()
+
+	tmp := dst + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return false, err
+	}
+	n, err := io.Copy(f, content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return false, err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return false, err
+	}
+	log.Printf("Restored %s to %s (%s)", object, dst, humanBytes(n))
+	return true, nil
+}
+
+// openStored reads an archived object as it was downloaded from Zoom,
+// undoing encryption and COMPRESSION.
+func (run *backupRun) openStored(ctx context.Context, object string) (io.ReadCloser, error) {
+	r, err := run.contentObject(object).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(object, zstdExt) {
+		return r, nil
+	}
+	dec, err := zstd.NewReader(r)
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return &zstdReadCloser{Decoder: dec, body: r}, nil
+}
+
+// zstdReadCloser closes the decoder along with the object it reads from.
+type zstdReadCloser struct {
+	*zstd.Decoder
+	body io.Closer
+}
+
+func (z *zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return z.body.Close()
+}
@@ -0,0 +1,220 @@
+package zoombackup
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+const (
+	retentionActionDelete  = "delete"
+	retentionActionArchive = "archive"
+)
+
+// retentionEnabled reports whether RETENTION_DAYS or RETENTION_MAX_PER_TOPIC
+// is set.
+func (cfg *config) retentionEnabled() bool {
+	return cfg.RetentionDays > 0 || cfg.RetentionMaxPerTopic > 0
+}
+
+// expiredMeeting is a meeting of the manifest retention applies to.
+type expiredMeeting struct {
+	uuid   string
+	reason string
+}
+
+// selectExpired returns the meetings older than RETENTION_DAYS and those
+// beyond the newest RETENTION_MAX_PER_TOPIC of their topic. Meetings on hold
+// are never selected and do not count towards the limit.
+func selectExpired(cfg *config, m *manifest, now time.Time) []expiredMeeting {
+	var expired []expiredMeeting
+	perTopic := map[string]int{}
+	// The manifest is ordered newest first, so the meetings of a topic
+	// beyond the limit are its oldest.
+	for _, mtg := range m.Meetings {
+		if mtg.Hold {
+			continue
+		}
+		perTopic[mtg.Topic]++
+		start, err := time.Parse(time.RFC3339, mtg.StartTime)
+		switch {
+		case cfg.RetentionDays > 0 && err == nil && start.Before(now.AddDate(0, 0, -cfg.RetentionDays)):
+			expired = append(expired, expiredMeeting{uuid: mtg.UUID, reason: fmt.Sprintf("older than %d days", cfg.RetentionDays)})
+		case cfg.RetentionMaxPerTopic > 0 && perTopic[mtg.Topic] > cfg.RetentionMaxPerTopic:
+			expired = append(expired, expiredMeeting{uuid: mtg.UUID, reason: fmt.Sprintf("beyond the newest %d of %q", cfg.RetentionMaxPerTopic, mtg.Topic)})
+		}
+	}
+	return expired
+}
+
+// applyRetention deletes the files of expired meetings, or moves them to the
+// ARCHIVE storage class with RETENTION_ACTION=archive, and drops deleted files
+// from the manifest, even those deleted before a failure stopped it. Files on
+// hold are kept. With dryRun it only logs what it would do. It returns how
+// many objects it deleted or moved.
+func applyRetention(ctx context.Context, storageClient *storage.Client, cfg *config, dryRun bool) (int, error) {
+	m, err := loadManifest(ctx, storageClient, cfg)
+	if err != nil {
+		return 0, err
+	}
+	run := &backupRun{cfg: cfg, storageClient: storageClient}
+	pruned := 0
+	deleted := map[string]bool{}
+	var retainErr error
+prune:
+	for _, expired := range selectExpired(cfg, m, time.Now()) {
+		mtg := m.Meetings[m.meetingIndex(expired.uuid)]
+		for _, file := range mtg.Files {
+			// A manifest edited by hand must not make retention delete the
+			// job's own files.
+			if file.Hold || file.Object == "" || isJobObject(cfg, file.Object) {
+				continue
+			}
+			if dryRun {
+				log.Printf("Would %s %s (%s)", cfg.RetentionAction, file.Object, expired.reason)
+				continue
+			}
+			moved, err := run.retainFile(ctx, file.Object)
+			if err != nil {
+				retainErr = err
+				break prune
+			}
+			if moved {
+				log.Printf("Retention: %s %s (%s)", cfg.RetentionAction, file.Object, expired.reason)
+				pruned++
+			}
+			if cfg.RetentionAction == retentionActionDelete {
+				deleted[file.Object] = true
+			}
+		}
+	}
+	if len(deleted) == 0 {
+		return pruned, retainErr
+	}
+
+	if err := dropFromManifest(ctx, storageClient, cfg, deleted); err != nil {
+		err = fmt.Errorf("failed to write manifest: %w", err)
+		if retainErr != nil {
+			err = fmt.Errorf("%v; %v", retainErr, err)
+		}
+		return pruned, err
+	}
+	return pruned, retainErr
+}
+
+// dropFromManifest removes the objects, and the meetings left without files,
+// from the manifest, again on the latest manifest when another run wrote it
+// in between.
+func dropFromManifest(ctx context.Context, storageClient *storage.Client, cfg *config, objects map[string]bool) error {
+	obj := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.ManifestFileName))
+	return retryOnConflict(ctx, cfg.ManifestFileName, func() error {
+		m, generation, err := readManifest(ctx, storageClient, cfg)
+		if err != nil {
+			return err
+		}
+		var meetings []manifestMeeting
+		for _, mtg := range m.Meetings {
+			var kept []manifestFile
+			for _, file := range mtg.Files {
+				if !objects[file.Object] {
+					kept = append(kept, file)
+				}
+			}
+			mtg.Files = kept
+			if len(mtg.Files) > 0 {
+				meetings = append(meetings, mtg)
+			}
+		}
+		m.Meetings = meetings
+		m.UpdatedAt = time.Now().UTC()
+		return writeJSONObject(ctx, ifGeneration(obj, generation), m)
+	})
+}
+
+// retainFile applies RETENTION_ACTION to one object and reports whether it
+// changed anything.
+func (run *backupRun) retainFile(ctx context.Context, object string) (bool, error) {
+	obj := run.contentObject(object)
+	if run.cfg.RetentionAction == retentionActionDelete {
+		err := obj.Delete(ctx)
+		if err == storage.ErrObjectNotExist {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to delete %s: %w", object, err)
+		}
+		return true, nil
+	}
+
+	attrs, err := obj.Attrs(ctx)
+	if err == storage.ErrObjectNotExist {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", object, err)
+	}
+	if attrs.StorageClass == "ARCHIVE" {
+		return false, nil
+	}
+	// A rewrite replaces the object's metadata, so everything worth keeping
+	// is carried over.
+	copier := obj.CopierFrom(obj)
+	copier.StorageClass = "ARCHIVE"
+	copier.DestinationKMSKeyName = run.cfg.KMSKeyName
+	copier.ContentType = attrs.ContentType
+	copier.ContentEncoding = attrs.ContentEncoding
+	copier.ContentDisposition = attrs.ContentDisposition
+	copier.CacheControl = attrs.CacheControl
+	copier.Metadata = attrs.Metadata
+	copier.CustomTime = attrs.CustomTime
+	if _, err := copier.Run(ctx); err != nil {
+		return false, fmt.Errorf("failed to move %s to ARCHIVE: %w", object, err)
+	}
+	return true, nil
+}
+
+func pruneCommand(args []string) int {
+	fs := flag.NewFlagSet("zoom-backup prune", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	job := fs.String("job", "", "job whose archive to prune; required with several jobs")
+	dryRun := fs.Bool("dry-run", false, "only print what would be deleted or archived")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	storageClient, jobs, err := setupCLI(ctx, func(*config) error { return nil })
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	cfg, err := selectJob(jobs, *job)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	if !cfg.retentionEnabled() {
+		log.Println("Set RETENTION_DAYS or RETENTION_MAX_PER_TOPIC to prune the archive")
+		return 2
+	}
+
+	pruned, err := applyRetention(ctx, storageClient, cfg, *dryRun || cfg.RetentionDryRun)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	log.Printf("Retention: %s %d objects", cfg.RetentionAction, pruned)
+	if cfg.IndexEnabled && pruned > 0 {
+		if err := generateURLSListHTML(ctx, storageClient, cfg); err != nil {
+			log.Println("Could not generate html file:", err)
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,205 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// retentionMeeting returns a manifest meeting of topic that started daysAgo
+// with a file for each object.
+func retentionMeeting(uuid, topic string, daysAgo int, objects ...string) manifestMeeting {
+	mtg := manifestMeeting{
+		UUID:      uuid,
+		Topic:     topic,
+		StartTime: time.Now().AddDate(0, 0, -daysAgo).UTC().Format(time.RFC3339),
+	}
+	for _, object := range objects {
+		mtg.Files = append(mtg.Files, manifestFile{Object: object})
+	}
+	return mtg
+}
+
+func held(mtg manifestMeeting) manifestMeeting {
+	mtg.Hold = true
+	return mtg
+}
+
+func heldFile(mtg manifestMeeting, i int) manifestMeeting {
+	mtg.Files[i].Hold = true
+	return mtg
+}
+
+func TestApplyRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		days     int
+		perTopic int
+		dryRun   bool
+		meetings []manifestMeeting
+		// missing are listed in the manifest but no longer in the bucket.
+		missing     []string
+		failDeletes []string
+		wantPruned  int
+		wantDeleted string
+		// wantManifest are the objects left in the manifest.
+		wantManifest string
+		wantErr      bool
+	}{
+		{
+			name: "older than retention days",
+			days: 30,
+			meetings: []manifestMeeting{
+				retentionMeeting("new", "Standup", 1, "new.mp4"),
+				retentionMeeting("old", "Standup", 40, "old.mp4", "old.m4a"),
+			},
+			wantPruned:   2,
+			wantDeleted:  "[old.mp4 old.m4a]",
+			wantManifest: "[new.mp4]",
+		},
+		{
+			name:     "beyond the newest of a topic",
+			perTopic: 1,
+			meetings: []manifestMeeting{
+				retentionMeeting("s1", "Standup", 1, "s1.mp4"),
+				retentionMeeting("s2", "Standup", 2, "s2.mp4"),
+				retentionMeeting("r1", "Review", 3, "r1.mp4"),
+				retentionMeeting("s3", "Standup", 4, "s3.mp4"),
+			},
+			wantPruned:   2,
+			wantDeleted:  "[s2.mp4 s3.mp4]",
+			wantManifest: "[s1.mp4 r1.mp4]",
+		},
+		{
+			name: "held meetings and files are kept",
+			days: 30,
+			meetings: []manifestMeeting{
+				held(retentionMeeting("held", "Standup", 40, "held.mp4")),
+				heldFile(retentionMeeting("old", "Standup", 50, "kept.mp4", "old.mp4"), 0),
+			},
+			wantPruned:   1,
+			wantDeleted:  "[old.mp4]",
+			wantManifest: "[held.mp4 kept.mp4]",
+		},
+		{
+			name:     "held meetings do not count towards the limit",
+			perTopic: 1,
+			meetings: []manifestMeeting{
+				held(retentionMeeting("held", "Standup", 1, "held.mp4")),
+				retentionMeeting("s1", "Standup", 2, "s1.mp4"),
+			},
+			wantDeleted:  "[]",
+			wantManifest: "[held.mp4 s1.mp4]",
+		},
+		{
+			name: "job objects are never deleted",
+			days: 30,
+			meetings: []manifestMeeting{
+				retentionMeeting("old", "Standup", 40, "manifest.json", "status.json", "index.html", "old.mp4"),
+			},
+			wantPruned:   1,
+			wantDeleted:  "[old.mp4]",
+			wantManifest: "[manifest.json status.json index.html]",
+		},
+		{
+			name:   "dry run",
+			days:   30,
+			dryRun: true,
+			meetings: []manifestMeeting{
+				retentionMeeting("old", "Standup", 40, "old.mp4"),
+			},
+			wantDeleted:  "[]",
+			wantManifest: "[old.mp4]",
+		},
+		{
+			name: "files already gone are dropped from the manifest",
+			days: 30,
+			meetings: []manifestMeeting{
+				retentionMeeting("old", "Standup", 40, "gone.mp4", "old.mp4"),
+			},
+			missing:      []string{"gone.mp4"},
+			wantPruned:   1,
+			wantDeleted:  "[old.mp4]",
+			wantManifest: "[]",
+		},
+		{
+			name: "a failed delete keeps the files deleted so far out of the manifest",
+			days: 30,
+			meetings: []manifestMeeting{
+				retentionMeeting("new", "Standup", 1, "new.mp4"),
+				retentionMeeting("old", "Standup", 40, "first.mp4", "refused.mp4", "last.mp4"),
+				retentionMeeting("older", "Standup", 50, "older.mp4"),
+			},
+			failDeletes:  []string{"refused.mp4"},
+			wantPruned:   1,
+			wantDeleted:  "[first.mp4]",
+			wantManifest: "[new.mp4 refused.mp4 last.mp4 older.mp4]",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient, gcs := newTestStorage(t)
+			cfg := &config{
+				Bucket:               "archive",
+				ManifestFileName:     "manifest.json",
+				StatusObject:         "status.json",
+				IndexFileName:        "index.html",
+				RetentionDays:        tt.days,
+				RetentionMaxPerTopic: tt.perTopic,
+				RetentionAction:      retentionActionDelete,
+			}
+			raw, err := json.Marshal(&manifest{Bucket: cfg.Bucket, Meetings: tt.meetings})
+			if err != nil {
+				t.Fatal(err)
+			}
+			gcs.put(cfg.Bucket, cfg.ManifestFileName, raw)
+			missing := map[string]bool{}
+			for _, object := range tt.missing {
+				missing[object] = true
+			}
+			for _, mtg := range tt.meetings {
+				for _, file := range mtg.Files {
+					if !missing[file.Object] && file.Object != cfg.ManifestFileName {
+						gcs.put(cfg.Bucket, file.Object, []byte("content"))
+					}
+				}
+			}
+			for _, object := range tt.failDeletes {
+				gcs.failDeletes[object] = true
+			}
+
+			pruned, err := applyRetention(context.Background(), storageClient, cfg, tt.dryRun)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if pruned != tt.wantPruned {
+				t.Errorf("got %d pruned, want %d", pruned, tt.wantPruned)
+			}
+			if got := fmt.Sprint(gcs.deleted); got != tt.wantDeleted {
+				t.Errorf("got deletes %s, want %s", got, tt.wantDeleted)
+			}
+
+			m, err := loadManifest(context.Background(), storageClient, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var objects []string
+			for _, mtg := range m.Meetings {
+				for _, file := range mtg.Files {
+					objects = append(objects, file.Object)
+				}
+			}
+			if got := fmt.Sprint(objects); got != tt.wantManifest {
+				t.Errorf("got manifest %s, want %s", got, tt.wantManifest)
+			}
+			for _, object := range objects {
+				if _, ok := gcs.get(cfg.Bucket, object); !ok && !missing[object] {
+					t.Errorf("%s is in the manifest but was deleted", object)
+				}
+			}
+		})
+	}
+}
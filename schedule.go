@@ -0,0 +1,60 @@
+package zoombackup
+
+import (
+	"sort"
+	"time"
+)
+
+// capMeetings picks at most max meetings, taking turns between users so one
+// prolific user cannot fill every run. Each user's meetings are taken oldest
+// first and users with the oldest pending meeting go first in every round, so
+// without keeping state between runs a backlog drains gradually and the
+// recordings closest to Zoom's auto-deletion are archived first. The meetings
+// left over are returned as postponed. max 0 keeps every meeting.
+func capMeetings(meetings []meeting, max int) (selected, postponed []meeting) {
+	if max <= 0 || len(meetings) <= max {
+		return meetings, nil
+	}
+
+	queues := map[string][]meeting{}
+	var users []string
+	for _, mtg := range meetings {
+		user := mtg.UserID
+		if user == "" {
+			user = mtg.HostID
+		}
+		if _, ok := queues[user]; !ok {
+			users = append(users, user)
+		}
+		queues[user] = append(queues[user], mtg)
+	}
+	for _, user := range users {
+		queue := queues[user]
+		sort.SliceStable(queue, func(i, j int) bool {
+			return meetingStart(queue[i]).Before(meetingStart(queue[j]))
+		})
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		return meetingStart(queues[users[i]][0]).Before(meetingStart(queues[users[j]][0]))
+	})
+
+	for round := 0; len(selected)+len(postponed) < len(meetings); round++ {
+		for _, user := range users {
+			if queue := queues[user]; round < len(queue) {
+				if len(selected) < max {
+					selected = append(selected, queue[round])
+				} else {
+					postponed = append(postponed, queue[round])
+				}
+			}
+		}
+	}
+	return selected, postponed
+}
+
+// meetingStart parses the meeting's start time. Meetings without one sort
+// first.
+func meetingStart(mtg meeting) time.Time {
+	t, _ := time.Parse(time.RFC3339, mtg.StartTime)
+	return t
+}
@@ -54,7 +54,7 @@ fi
 gcloud functions deploy backup-zoom-meetings-$NAME \
     --project=$PROJECT_ID \
     --timeout=540s \
-    --entry-point ZoomBackup \
+    --entry-point ZoomBackupPubSub \
     --region us-central1 \
     --runtime go113 \
     --trigger-topic $ZOOM_TOPIC \
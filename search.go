@@ -0,0 +1,178 @@
+package zoombackup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// searchIndex is the inverted index of the archived transcripts the index
+// page searches. Terms map every word to the positions in Docs of the
+// transcripts that contain it.
+type searchIndex struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Docs        []searchDoc      `json:"docs"`
+	Terms       map[string][]int `json:"terms"`
+}
+
+// searchDoc is one transcript in the search index. Generation tells an
+// unchanged transcript apart, so its words are not read again.
+type searchDoc struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Topic      string `json:"topic,omitempty"`
+	Date       string `json:"date,omitempty"`
+	Generation int64  `json:"generation"`
+}
+
+// isTranscript reports whether the object holds a WebVTT transcript.
+func isTranscript(name string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(name, zstdExt), ".vtt")
+}
+
+// writeSearchIndex indexes the words of every archived transcript into
+// SEARCH_INDEX_NAME. Transcripts indexed by the previous run whose object is
+// unchanged keep their words without being read again.
+func writeSearchIndex(ctx context.Context, storageClient *storage.Client, cfg *config) error {
+	run := &backupRun{cfg: cfg, storageClient: storageClient}
+	obj := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.SearchIndexName))
+	previous := map[string]searchDoc{}
+	previousTerms := map[string][]string{}
+	if r, err := obj.NewReader(ctx); err == nil {
+		var old searchIndex
+		err = json.NewDecoder(r).Decode(&old)
+		_ = r.Close()
+		if err != nil {
+			log.Println("Rebuilding the search index, could not read it:", err)
+		}
+		for term, docs := range old.Terms {
+			for _, i := range docs {
+				if i >= 0 && i < len(old.Docs) {
+					previousTerms[old.Docs[i].Name] = append(previousTerms[old.Docs[i].Name], term)
+				}
+			}
+		}
+		for _, doc := range old.Docs {
+			previous[doc.Name] = doc
+		}
+	} else if err != storage.ErrObjectNotExist {
+		return fmt.Errorf("failed to read search index: %w", err)
+	}
+
+	query := &storage.Query{}
+	if cfg.Prefix != "" {
+		query.Prefix = cfg.Prefix + "/"
+	}
+	index := searchIndex{GeneratedAt: time.Now().In(cfg.location), Terms: map[string][]int{}}
+	it := storageClient.Bucket(cfg.Bucket).Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Bucket(%q).Objects: %v", cfg.Bucket, err)
+		}
+		if !isTranscript(attrs.Name) {
+			continue
+		}
+		entry := newIndexEntry(cfg.Bucket, cfg.location, attrs)
+		doc := searchDoc{Name: attrs.Name, URL: entry.URL, Topic: entry.Topic, Generation: attrs.Generation}
+		if !entry.Date.IsZero() {
+			doc.Date = entry.Date.Format("2006-01-02")
+		}
+		terms := previousTerms[attrs.Name]
+		if old, indexed := previous[attrs.Name]; !indexed || old.Generation != attrs.Generation {
+			terms, err = run.transcriptTerms(ctx, attrs.Name)
+			if err != nil {
+				log.Println("Could not index", attrs.Name, err)
+				continue
+			}
+		}
+		for _, term := range terms {
+			index.Terms[term] = append(index.Terms[term], len(index.Docs))
+		}
+		index.Docs = append(index.Docs, doc)
+	}
+
+	raw, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	wc := obj.NewWriter(ctx)
+	wc.ContentType = "application/json"
+	if _, err := bytes.NewReader(raw).WriteTo(wc); err != nil {
+		_ = wc.Close()
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	log.Printf("Indexed %d words of %d transcripts", len(index.Terms), len(index.Docs))
+	return nil
+}
+
+// transcriptTerms returns the distinct words spoken in the transcript, in
+// lower case and without the WebVTT header, cue numbers and timings.
+func (run *backupRun) transcriptTerms(ctx context.Context, name string) ([]string, error) {
+	content, err := run.openStored(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "WEBVTT") || strings.Contains(line, "-->") || isCueNumber(line) {
+			continue
+		}
+		for _, term := range searchTerms(line) {
+			seen[term] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	terms := make([]string, 0, len(seen))
+	for term := range seen {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms, nil
+}
+
+// searchTerms splits text into the lower case words the search matches,
+// leaving out single characters.
+func searchTerms(text string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(word)) > 1 {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+func isCueNumber(line string) bool {
+	for _, r := range line {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
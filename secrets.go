@@ -0,0 +1,150 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/secretmanager/v1"
+)
+
+// secretPrefix marks a setting that names a Secret Manager secret, e.g.
+// sm://projects/my-project/secrets/zoom-api-secret. Without a version the
+// latest one is used.
+const secretPrefix = "sm://"
+
+// secretResolver reads secrets from Secret Manager with the default
+// credentials of the environment, or from Vault, and remembers them until
+// their lease ends, so jobs sharing a secret only read it once.
+type secretResolver struct {
+	mu      sync.Mutex
+	service *secretmanager.Service
+	vault   *vaultClient
+	values  map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value string
+	// expires is zero for secrets that are kept for the life of the
+	// process.
+	expires time.Time
+}
+
+var secrets = &secretResolver{values: map[string]cachedSecret{}}
+
+// resolve returns value, or the secret it names.
+func (s *secretResolver) resolve(ctx context.Context, httpClient *http.Client, value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretPrefix):
+		return s.secretManager(ctx, strings.TrimPrefix(value, secretPrefix))
+	case strings.HasPrefix(value, vaultPrefix):
+		return s.vaultSecret(ctx, httpClient, strings.TrimPrefix(value, vaultPrefix))
+	}
+	return value, nil
+}
+
+func (s *secretResolver) cached(key string) (string, bool) {
+	secret, ok := s.values[key]
+	if !ok || !secret.expires.IsZero() && time.Now().After(secret.expires) {
+		return "", false
+	}
+	return secret.value, true
+}
+
+func (s *secretResolver) secretManager(ctx context.Context, name string) (string, error) {
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if secret, ok := s.cached(secretPrefix + name); ok {
+		return secret, nil
+	}
+	if s.service == nil {
+		service, err := secretmanager.NewService(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to create Secret Manager client: %w", err)
+		}
+		s.service = service
+	}
+	resp, err := s.service.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to access secret %s: %w", name, err)
+	}
+	secret, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret %s: %w", name, err)
+	}
+	s.values[secretPrefix+name] = cachedSecret{value: string(secret)}
+	return string(secret), nil
+}
+
+// vaultSecret reads the field after # of the Vault secret at name. Leased
+// secrets are read again once their lease has ended.
+func (s *secretResolver) vaultSecret(ctx context.Context, httpClient *http.Client, name string) (string, error) {
+	path, field := name, "value"
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		path, field = name[:i], name[i+1:]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if secret, ok := s.cached(vaultPrefix + name); ok {
+		return secret, nil
+	}
+	if s.vault == nil {
+		vault, err := newVaultClient(httpClient)
+		if err != nil {
+			return "", err
+		}
+		s.vault = vault
+	}
+	secret, lease, err := s.vault.read(ctx, path, field)
+	if err != nil {
+		return "", err
+	}
+	cached := cachedSecret{value: secret}
+	if lease > 0 {
+		cached.expires = time.Now().Add(lease)
+	}
+	s.values[vaultPrefix+name] = cached
+	return secret, nil
+}
+
+// resolveSecrets replaces the settings that name Secret Manager or Vault
+// secrets with the secrets.
+func resolveSecrets(ctx context.Context, cfg *config) error {
+	for key, dst := range map[string]*string{
+		"ZOOM_API_KEY":             &cfg.ZoomAPIKey,
+		"ZOOM_API_SECRET":          &cfg.ZoomAPISecret,
+		"ZOOM_ACCOUNT_ID":          &cfg.ZoomAccountID,
+		"ZOOM_CLIENT_ID":           &cfg.ZoomClientID,
+		"ZOOM_CLIENT_SECRET":       &cfg.ZoomClientSecret,
+		"ENCRYPTION_KEY":           &cfg.EncryptionKey,
+		"DRIVE_CREDENTIALS":        &cfg.DriveCredentials,
+		"DROPBOX_APP_SECRET":       &cfg.DropboxAppSecret,
+		"DROPBOX_REFRESH_TOKEN":    &cfg.DropboxRefreshToken,
+		"SFTP_PRIVATE_KEY":         &cfg.SFTPPrivateKey,
+		"SFTP_KEY_PASSPHRASE":      &cfg.SFTPKeyPassphrase,
+		"RCLONE_MANAGED_CONFIG":    &cfg.RcloneManagedConfig,
+		"WHISPER_API_KEY":          &cfg.WhisperAPIKey,
+		"NOTIFY_SLACK_WEBHOOK_URL": &cfg.NotifySlackWebhookURL,
+		"NOTIFY_WEBHOOK_URL":       &cfg.NotifyWebhookURL,
+		"HEALTHCHECK_START_URL":    &cfg.HealthcheckStartURL,
+		"HEALTHCHECK_URL":          &cfg.HealthcheckURL,
+		"HEALTHCHECK_FAIL_URL":     &cfg.HealthcheckFailURL,
+		"SENTRY_DSN":               &cfg.SentryDSN,
+	} {
+		value, err := secrets.resolve(ctx, cfg.client(), *dst)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		*dst = value
+	}
+	return nil
+}
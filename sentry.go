@@ -0,0 +1,195 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sentryLevelError = "error"
+	sentryLevelFatal = "fatal"
+
+	// sentryFlushTimeout bounds waiting for the events of a run to be sent.
+	sentryFlushTimeout = 10 * time.Second
+)
+
+// sentryClient reports errors to Sentry through its envelope endpoint. Events
+// are sent in the background, so a failing file does not wait for Sentry, and
+// flush waits for them at the end of the run. A nil client drops every
+// event.
+type sentryClient struct {
+	endpoint    string
+	auth        string
+	dsn         string
+	job         string
+	environment string
+	serverName  string
+	httpClient  *http.Client
+
+	wg sync.WaitGroup
+}
+
+// parseSentryDSN splits a DSN of the form https://KEY@HOST/PROJECT into the
+// envelope endpoint of the project and its public key.
+func parseSentryDSN(dsn string) (endpoint, key string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid SENTRY_DSN: %w", err)
+	}
+	project := strings.Trim(u.Path, "/")
+	if (u.Scheme != "https" && u.Scheme != "http") || u.User == nil || u.User.Username() == "" || project == "" {
+		return "", "", errors.New("SENTRY_DSN must look like https://KEY@HOST/PROJECT")
+	}
+	// Sentry hosted below a path keeps the project ID as its last segment.
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project), u.User.Username(), nil
+}
+
+// newSentryClient returns nil without SENTRY_DSN.
+func newSentryClient(cfg *config) *sentryClient {
+	if cfg.SentryDSN == "" {
+		return nil
+	}
+	endpoint, key, err := parseSentryDSN(cfg.SentryDSN)
+	if err != nil {
+		// validate rejected the DSN already.
+		log.Println(err)
+		return nil
+	}
+	hostname, _ := os.Hostname()
+	return &sentryClient{
+		endpoint:    endpoint,
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=zoom-backup/1.0, sentry_key=%s", key),
+		dsn:         cfg.SentryDSN,
+		job:         cfg.JobName,
+		environment: cfg.SentryEnvironment,
+		serverName:  hostname,
+		httpClient:  cfg.client(),
+	}
+}
+
+// captureFile reports the failure of one recording file of the meeting.
+func (c *sentryClient) captureFile(err error, mtg meeting, file string) {
+	c.capture(sentryLevelError, err, map[string]string{
+		"meeting_id": mtg.ID,
+		"file":       file,
+		"source":     mtg.source(),
+	}, map[string]interface{}{
+		"topic":      mtg.Topic,
+		"start_time": mtg.StartTime,
+		"host_email": mtg.HostEmail,
+	})
+}
+
+// captureFatal reports the error that stopped the run.
+func (c *sentryClient) captureFatal(err error) {
+	c.capture(sentryLevelFatal, err, nil, nil)
+}
+
+func (c *sentryClient) capture(level string, err error, tags map[string]string, extra map[string]interface{}) {
+	if c == nil {
+		return
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		log.Println("Could not report to Sentry:", err)
+		return
+	}
+	eventID := hex.EncodeToString(id)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	if c.job != "" {
+		tags["job"] = c.job
+	}
+	now := time.Now().UTC()
+	event := map[string]interface{}{
+		"event_id":    eventID,
+		"timestamp":   now.Format(time.RFC3339),
+		"level":       level,
+		"platform":    "go",
+		"logger":      "zoom-backup",
+		"server_name": c.serverName,
+		"message":     map[string]string{"formatted": err.Error()},
+		"tags":        tags,
+	}
+	if c.environment != "" {
+		event["environment"] = c.environment
+	}
+	if len(extra) > 0 {
+		event["extra"] = extra
+	}
+
+	var envelope bytes.Buffer
+	enc := json.NewEncoder(&envelope)
+	for _, item := range []interface{}{
+		map[string]string{"event_id": eventID, "sent_at": now.Format(time.RFC3339), "dsn": c.dsn},
+		map[string]string{"type": "event"},
+		event,
+	} {
+		if err := enc.Encode(item); err != nil {
+			log.Println("Could not report to Sentry:", err)
+			return
+		}
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), sentryFlushTimeout)
+		defer cancel()
+		if err := c.post(ctx, envelope.Bytes()); err != nil {
+			log.Println("Could not report to Sentry:", err)
+		}
+	}()
+}
+
+func (c *sentryClient) post(ctx context.Context, envelope []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", c.auth)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform request: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("invalid response code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// flush waits for the events sent so far, at most sentryFlushTimeout.
+func (c *sentryClient) flush() {
+	if c == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(sentryFlushTimeout):
+		log.Println("Gave up waiting for Sentry")
+	}
+}
@@ -0,0 +1,94 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	settingsModeCheck   = "check"
+	settingsModeEnforce = "enforce"
+)
+
+// checkRecordingSettings compares every user's cloud recording settings with
+// RECORDING_SETTINGS before their recordings are listed, so the artifacts the
+// archive expects keep being generated. In enforce mode differing settings are
+// changed through the API, otherwise they are only reported.
+func (run *backupRun) checkRecordingSettings(ctx context.Context, userIDs []string) {
+	var wg sync.WaitGroup
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			if err := run.checkUserRecordingSettings(ctx, userID); err != nil {
+				err = fmt.Errorf("failed to check recording settings of %s: %w", userID, err)
+				log.Println(err)
+				run.report.fail(err)
+			}
+		}(userID)
+	}
+	wg.Wait()
+}
+
+func (run *backupRun) checkUserRecordingSettings(ctx context.Context, userID string) error {
+	settingsPath := fmt.Sprintf(zoomUserSettingsPath, url.PathEscape(userID))
+	current := &struct {
+		Recording map[string]interface{} `json:"recording"`
+	}{}
+	if err := run.zoom.getJSON(ctx, settingsPath, current); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(run.cfg.RecordingSettings))
+	for key := range run.cfg.RecordingSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	drift := map[string]interface{}{}
+	for _, key := range keys {
+		want := settingValue(run.cfg.RecordingSettings[key])
+		if sameSetting(current.Recording[key], want) {
+			continue
+		}
+		log.Printf("Recording setting %s of %s is %v, expected %v", key, userID, current.Recording[key], want)
+		drift[key] = want
+	}
+	if len(drift) == 0 {
+		return nil
+	}
+	run.report.settingsDrifted(len(drift))
+
+	if run.cfg.RecordingSettingsMode != settingsModeEnforce {
+		return nil
+	}
+	if err := run.zoom.patchJSON(ctx, settingsPath, map[string]interface{}{"recording": drift}); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	log.Println("Updated", len(drift), "recording settings of", userID)
+	return nil
+}
+
+// settingValue turns a RECORDING_SETTINGS value into the JSON type Zoom uses:
+// a boolean, a number or a string.
+func settingValue(s string) interface{} {
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n
+	}
+	return s
+}
+
+func sameSetting(current, want interface{}) bool {
+	a, errA := json.Marshal(current)
+	b, errB := json.Marshal(want)
+	return errA == nil && errB == nil && string(a) == string(b)
+}
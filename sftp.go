@@ -0,0 +1,167 @@
+package zoombackup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpMirror copies archived recordings to a directory of an SSH server,
+// such as an on-prem server or NAS. It connects on first use and keeps the
+// connection for the rest of the run.
+type sftpMirror struct {
+	addr string
+	dir  string
+	ssh  *ssh.ClientConfig
+
+	mu     sync.Mutex
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+func newSFTPMirror(cfg *config) (*sftpMirror, error) {
+	signer, err := sftpSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cfg.SFTPHostKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SFTP_HOST_KEY: %w", err)
+	}
+	return &sftpMirror{
+		addr: net.JoinHostPort(cfg.SFTPHost, strconv.Itoa(cfg.SFTPPort)),
+		dir:  cfg.SFTPPath,
+		ssh: &ssh.ClientConfig{
+			User:            cfg.SFTPUser,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: ssh.FixedHostKey(hostKey),
+			Timeout:         cfg.Dialer.Timeout,
+		},
+	}, nil
+}
+
+func sftpSigner(cfg *config) (ssh.Signer, error) {
+	var signer ssh.Signer
+	var err error
+	if cfg.SFTPKeyPassphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(cfg.SFTPPrivateKey), []byte(cfg.SFTPKeyPassphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey([]byte(cfg.SFTPPrivateKey))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SFTP_PRIVATE_KEY: %w", err)
+	}
+	return signer, nil
+}
+
+func (m *sftpMirror) String() string {
+	return "SFTP " + m.addr
+}
+
+// connect returns the SFTP session, dialing the server when there is none
+// yet.
+func (m *sftpMirror) connect() (*sftp.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client != nil {
+		return m.client, nil
+	}
+	conn, err := ssh.Dial("tcp", m.addr, m.ssh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to start SFTP session: %w", err)
+	}
+	m.conn, m.client = conn, client
+	return client, nil
+}
+
+func (m *sftpMirror) exists(ctx context.Context, name string, size int64) (bool, error) {
+	client, err := m.connect()
+	if err != nil {
+		return false, err
+	}
+	info, err := client.Stat(m.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return info.Size() == size, nil
+}
+
+// upload writes the recording to a .part file, which is renamed to name
+// once complete, so an interrupted upload never looks like a recording.
+func (m *sftpMirror) upload(ctx context.Context, name string, size int64, contentType string, r io.Reader) error {
+	client, err := m.connect()
+	if err != nil {
+		return err
+	}
+	target := m.path(name)
+	if err := client.MkdirAll(path.Dir(target)); err != nil {
+		return fmt.Errorf("failed to create %s: %w", path.Dir(target), err)
+	}
+	part := target + ".part"
+	f, err := client.Create(part)
+	if err != nil {
+		return err
+	}
+	// Closing the file unblocks the copy when the run is cancelled.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+		case <-stop:
+		}
+	}()
+	if _, err := f.ReadFrom(r); err != nil {
+		_ = f.Close()
+		_ = client.Remove(part)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = client.Remove(part)
+		return err
+	}
+	if err := client.PosixRename(part, target); err != nil {
+		// Servers without the posix-rename extension refuse to replace
+		// an existing file.
+		_ = client.Remove(target)
+		if err := client.Rename(part, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *sftpMirror) path(name string) string {
+	return path.Join(m.dir, name)
+}
+
+// Close ends the SFTP session and the connection, if any.
+func (m *sftpMirror) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client == nil {
+		return nil
+	}
+	_ = m.client.Close()
+	err := m.conn.Close()
+	m.conn, m.client = nil, nil
+	return err
+}
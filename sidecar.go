@@ -0,0 +1,116 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"time"
+)
+
+// meetingSidecar is written as meeting.json into every meeting folder so the
+// archive keeps the meeting's context after the Zoom copy is deleted.
+type meetingSidecar struct {
+	UUID      string `json:"uuid"`
+	Source    string `json:"source"`
+	Topic     string `json:"topic"`
+	StartTime string `json:"start_time"`
+	Duration  int    `json:"duration"`
+	HostID    string `json:"host_id,omitempty"`
+	HostEmail string `json:"host_email,omitempty"`
+	HostName  string `json:"host_name,omitempty"`
+	ShareURL  string `json:"share_url,omitempty"`
+	// Passcode and PlayPasscode are only kept with
+	// MEETING_SIDECAR_PASSCODE.
+	Passcode     string `json:"passcode,omitempty"`
+	PlayPasscode string `json:"recording_play_passcode,omitempty"`
+	// Files describes every recording file Zoom reported, with the object
+	// it was archived as, if it was.
+	Files []sidecarFile `json:"files,omitempty"`
+	// RecordingFiles is the original list of recording files reported by
+	// Zoom, including the ones that were not archived.
+	RecordingFiles json.RawMessage `json:"recording_files,omitempty"`
+	ArchivedFiles  []string        `json:"archived_files"`
+	ArchivedAt     time.Time       `json:"archived_at"`
+}
+
+// sidecarFile is a recording file in the sidecar.
+type sidecarFile struct {
+	ID             string `json:"id,omitempty"`
+	RecordingType  string `json:"recording_type,omitempty"`
+	FileType       string `json:"file_type"`
+	RecordingStart string `json:"recording_start,omitempty"`
+	FileSize       int64  `json:"file_size,omitempty"`
+	PlayURL        string `json:"play_url,omitempty"`
+	Object         string `json:"object,omitempty"`
+}
+
+func (run *backupRun) writeMeetingSidecar(ctx context.Context, mtg meeting, files []archivedFile) error {
+	folder, err := meetingFolder(run.cfg, mtg)
+	if err != nil {
+		return err
+	}
+
+	sidecar := meetingSidecar{
+		UUID:          mtg.ID,
+		Source:        mtg.source(),
+		Topic:         mtg.Topic,
+		StartTime:     mtg.StartTime,
+		Duration:      mtg.Duration,
+		HostID:        mtg.HostID,
+		HostEmail:     mtg.HostEmail,
+		HostName:      mtg.HostName,
+		ShareURL:      mtg.ShareURL,
+		ArchivedFiles: []string{},
+		ArchivedAt:    time.Now().UTC(),
+	}
+	if run.cfg.MeetingSidecarPasscode {
+		sidecar.Passcode = mtg.Passcode
+		sidecar.PlayPasscode = mtg.PlayPasscode
+	}
+	objects := map[string]string{}
+	for _, file := range files {
+		sidecar.ArchivedFiles = append(sidecar.ArchivedFiles, file.attrs.Name)
+		objects[fileKey(file.recording)] = file.attrs.Name
+	}
+	if len(mtg.Zoom) > 0 {
+		original := &struct {
+			RecordingFiles json.RawMessage `json:"recording_files"`
+		}{}
+		if err := json.Unmarshal(mtg.Zoom, original); err != nil {
+			return err
+		}
+		sidecar.RecordingFiles = original.RecordingFiles
+
+		var reported []recordingFile
+		if len(original.RecordingFiles) > 0 {
+			if err := json.Unmarshal(original.RecordingFiles, &reported); err != nil {
+				return err
+			}
+		}
+		for _, file := range reported {
+			sidecar.Files = append(sidecar.Files, sidecarFile{
+				ID:             file.ID,
+				RecordingType:  file.RecordingType,
+				FileType:       file.FileType,
+				RecordingStart: file.RecordingStart,
+				FileSize:       file.FileSize,
+				PlayURL:        file.PlayURL,
+				Object:         objects[fileKey(file)],
+			})
+		}
+	}
+
+	name := run.cfg.objectName(path.Join(folder, run.cfg.MeetingSidecarName))
+	return writeJSON(run.meetingWriter(ctx, mtg, name), sidecar)
+}
+
+// meetingFolder is the folder, relative to GSTORAGE_PATH, that holds the
+// meeting's recordings: the folder NAMING_TEMPLATE renders for a file that
+// started with the meeting.
+func meetingFolder(cfg *config, mtg meeting) (string, error) {
+	name, err := getFileSaveName(cfg, mtg, recordingFile{RecordingStart: mtg.StartTime})
+	if err != nil {
+		return "", err
+	}
+	return path.Dir(name), nil
+}
@@ -0,0 +1,61 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iamcredentials/v1"
+)
+
+// urlSigner creates V4 signed URLs for archived objects. Signing goes through
+// the IAM Credentials API so it works with the keyless default credentials of
+// Cloud Functions, as long as the runtime identity may sign for
+// SIGNING_SERVICE_ACCOUNT (roles/iam.serviceAccountTokenCreator).
+type urlSigner struct {
+	serviceAccount string
+	iam            *iamcredentials.Service
+}
+
+func newURLSigner(ctx context.Context, cfg *config) (*urlSigner, error) {
+	if cfg.SigningServiceAccount == "" {
+		return nil, nil
+	}
+	iam, err := iamcredentials.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IAM credentials client: %w", err)
+	}
+	return &urlSigner{
+		serviceAccount: cfg.SigningServiceAccount,
+		iam:            iam,
+	}, nil
+}
+
+// objectURL returns a signed URL valid for expiry, or the plain public URL
+// used by the index when no signer is configured.
+func (s *urlSigner) objectURL(bucket, object string, expiry time.Duration) (string, error) {
+	if s == nil {
+		return fmt.Sprintf("http://%s/%s", bucket, (&url.URL{Path: object}).EscapedPath()), nil
+	}
+	return storage.SignedURL(bucket, object, &storage.SignedURLOptions{
+		GoogleAccessID: s.serviceAccount,
+		SignBytes:      s.signBytes,
+		Method:         "GET",
+		Expires:        time.Now().Add(expiry),
+		Scheme:         storage.SigningSchemeV4,
+	})
+}
+
+func (s *urlSigner) signBytes(b []byte) ([]byte, error) {
+	name := "projects/-/serviceAccounts/" + s.serviceAccount
+	resp, err := s.iam.Projects.ServiceAccounts.SignBlob(name, &iamcredentials.SignBlobRequest{
+		Payload: base64.StdEncoding.EncodeToString(b),
+	}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign blob as %s: %w", s.serviceAccount, err)
+	}
+	return base64.StdEncoding.DecodeString(resp.SignedBlob)
+}
@@ -0,0 +1,230 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// statusMaxErrors bounds the errors kept in the status object, the report
+// of the run has all of them.
+const statusMaxErrors = 20
+
+// statusNeverRun is the status of jobs without a status object yet.
+const statusNeverRun = "never-run"
+
+// runStatus is the outcome of a job's last run as served at /status for
+// uptime monitors and dashboards.
+type runStatus struct {
+	Job             string    `json:"job"`
+	Status          string    `json:"status"`
+	StartedAt       time.Time `json:"started_at,omitempty"`
+	FinishedAt      time.Time `json:"finished_at,omitempty"`
+	Fatal           string    `json:"fatal,omitempty"`
+	Users           int       `json:"users"`
+	Meetings        int       `json:"meetings"`
+	FilesArchived   int       `json:"files_archived"`
+	FilesFailed     int       `json:"files_failed"`
+	FilesSkipped    int       `json:"files_skipped"`
+	BytesArchived   int64     `json:"bytes_archived"`
+	MeetingsDeleted int       `json:"meetings_deleted"`
+	ErrorCount      int       `json:"error_count"`
+	Errors          []string  `json:"errors,omitempty"`
+	// LastSuccessAt is when the last run with status ok finished, which
+	// may be an earlier run than this one.
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	// ListedThrough is the start of the last run that listed its window
+	// and left nothing in it for later runs, where LIST_SINCE_LAST_SUCCESS
+	// lists from.
+	ListedThrough *time.Time `json:"listed_through,omitempty"`
+	// NextRunAt is when the next run is due, known only with RUN_INTERVAL.
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	// Overdue is set when serving the status once a whole RUN_INTERVAL has
+	// passed after NextRunAt without a run.
+	Overdue bool `json:"overdue,omitempty"`
+}
+
+// healthy tells uptime monitors whether the job needs attention.
+func (s *runStatus) healthy() bool {
+	return s.Status != "fatal" && !s.Overdue
+}
+
+func newRunStatus(cfg *config, report *runReport, previous *runStatus) *runStatus {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	s := &runStatus{
+		Job:             report.Job,
+		Status:          report.status(),
+		StartedAt:       report.StartedAt,
+		FinishedAt:      report.FinishedAt,
+		Fatal:           report.Fatal,
+		Users:           report.Users,
+		Meetings:        report.Meetings,
+		FilesArchived:   report.FilesArchived,
+		FilesFailed:     report.FilesFailed,
+		FilesSkipped:    report.FilesSkipped,
+		BytesArchived:   report.BytesArchived,
+		MeetingsDeleted: report.MeetingsDeleted,
+		ErrorCount:      len(report.Errors),
+	}
+	errs := report.Errors
+	if len(errs) > statusMaxErrors {
+		errs = errs[:statusMaxErrors]
+	}
+	s.Errors = append([]string(nil), errs...)
+
+	if s.Status == "ok" {
+		finished := s.FinishedAt
+		s.LastSuccessAt = &finished
+	} else if previous != nil {
+		s.LastSuccessAt = previous.LastSuccessAt
+	}
+	if report.listedCompletely() {
+		started := s.StartedAt
+		s.ListedThrough = &started
+	} else if previous != nil {
+		s.ListedThrough = previous.ListedThrough
+	}
+	if cfg.RunInterval > 0 {
+		next := s.StartedAt.Add(cfg.RunInterval)
+		s.NextRunAt = &next
+	}
+	return s
+}
+
+// loadStatus reads the status of the job's last run, nil when the job has
+// none yet. A status written by another job, e.g. before the job was renamed
+// or while it shared STATUS_OBJECT with another, is ignored so its
+// listed_through never narrows what this job lists.
+func loadStatus(ctx context.Context, storageClient *storage.Client, cfg *config) (*runStatus, error) {
+	r, err := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.StatusObject)).NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open status object: %w", err)
+	}
+	defer r.Close()
+
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status object: %w", err)
+	}
+	s := &runStatus{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal status object: %w", err)
+	}
+	if s.Job != cfg.JobName {
+		log.Printf("Ignoring %s, it holds the status of job %q rather than %q", cfg.StatusObject, s.Job, cfg.JobName)
+		return nil, nil
+	}
+	return s, nil
+}
+
+// listSince is where LIST_SINCE_LAST_SUCCESS starts listing: LIST_OVERLAP
+// before the start of the last run that left nothing in its window, however
+// long ago that was, or lookback for jobs without one. With deletions the
+// meetings still too young to delete stay within the window, so they are
+// deleted once they are old enough.
+func (run *backupRun) listSince(ctx context.Context, lookback time.Time) time.Time {
+	status, err := loadStatus(ctx, run.storageClient, run.cfg)
+	if err != nil {
+		log.Println("Could not load the run status, listing the last LOOKBACK_DAYS:", err)
+		return lookback
+	}
+	if status == nil || status.ListedThrough == nil {
+		return lookback
+	}
+	since := status.ListedThrough.Add(-run.cfg.ListOverlap)
+	if run.cfg.DeleteFromZoom {
+		if undeleted := time.Now().AddDate(0, 0, -run.cfg.DeleteAfterDays-1); undeleted.Before(since) {
+			since = undeleted
+		}
+	}
+	log.Printf("Listing recordings since %s, the last complete run started at %s", since.Format(time.RFC3339), status.ListedThrough.Format(time.RFC3339))
+	return since
+}
+
+// recordStatus stores the outcome of the run in STATUS_OBJECT. Like the
+// healthcheck pings it does not derive from the run's context so cancelled
+// runs are recorded too, and a failure is only logged.
+func recordStatus(storageClient *storage.Client, cfg *config, report *runReport) {
+	if cfg.StatusObject == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	previous, err := loadStatus(ctx, storageClient, cfg)
+	if err != nil {
+		log.Println("Could not load the previous run status:", err)
+	}
+	s := newRunStatus(cfg, report, previous)
+	obj := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.StatusObject))
+	if err := writeJSONObject(ctx, obj, s); err != nil {
+		log.Println("Could not write run status:", err)
+	}
+}
+
+// serveStatus answers GET /status with the last run of every job, or of the
+// one named by ?job=. It responds 503 when any of them ended fatally or is
+// overdue so uptime monitors can alert on the status code alone.
+func serveStatus(w http.ResponseWriter, r *http.Request, storageClient *storage.Client, jobs []*config) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if name := r.URL.Query().Get("job"); name != "" {
+		job, err := selectJob(jobs, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		jobs = []*config{job}
+	}
+
+	now := time.Now().UTC()
+	code := http.StatusOK
+	statuses := make([]*runStatus, 0, len(jobs))
+	for _, job := range jobs {
+		if job.StatusObject == "" {
+			continue
+		}
+		s, err := loadStatus(r.Context(), storageClient, job)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if s == nil {
+			s = &runStatus{Job: job.JobName, Status: statusNeverRun}
+		}
+		if s.NextRunAt != nil && job.RunInterval > 0 && now.After(s.NextRunAt.Add(job.RunInterval)) {
+			s.Overdue = true
+		}
+		if !s.healthy() {
+			code = http.StatusServiceUnavailable
+		}
+		statuses = append(statuses, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		log.Println("Could not write response:", err)
+	}
+}
+
+func isStatusRequest(r *http.Request) bool {
+	return strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/status")
+}
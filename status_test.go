@@ -0,0 +1,37 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestListSinceIgnoresOtherJobs(t *testing.T) {
+	lookback := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	listedThrough := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		job  string
+		want time.Time
+	}{
+		{"own status", "sales", listedThrough.Add(-time.Hour)},
+		{"another job's status", "support", lookback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClient, gcs := newTestStorage(t)
+			cfg := &config{JobName: "sales", Bucket: "archive", StatusObject: "status.json", ListOverlap: time.Hour}
+			raw, err := json.Marshal(&runStatus{Job: tt.job, Status: "ok", ListedThrough: &listedThrough})
+			if err != nil {
+				t.Fatal(err)
+			}
+			gcs.put(cfg.Bucket, cfg.StatusObject, raw)
+
+			run := &backupRun{cfg: cfg, storageClient: storageClient}
+			if got := run.listSince(context.Background(), lookback); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
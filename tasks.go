@@ -0,0 +1,399 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	cloudtasks "google.golang.org/api/cloudtasks/v2"
+	firestore "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	queueModeTasks = "tasks"
+
+	// tasksCollection holds the meetings whose files are being archived by
+	// Cloud Tasks below the job's document in QUEUE_COLLECTION.
+	tasksCollection = "tasks"
+
+	// tasksDispatchDeadline is the longest Cloud Tasks waits for an HTTP
+	// target.
+	tasksDispatchDeadline = "1800s"
+
+	// taskNameAttempts is how many names createFileTask tries for a file.
+	// Cloud Tasks refuses the name of a task that finished or was deleted
+	// for a while, so each one that is taken but gone moves on to the next.
+	taskNameAttempts = 20
+)
+
+// fileTask is the body of the Cloud Task that archives one recording file. A
+// task without a file only finishes the meeting.
+type fileTask struct {
+	Job     string `json:"job,omitempty"`
+	Meeting string `json:"meeting"`
+	File    string `json:"file,omitempty"`
+}
+
+// taskQueue fans the files of the meetings a run discovers out to Cloud
+// Tasks for QUEUE_MODE tasks, so a meeting with several large files is
+// archived by as many invocations instead of one that may time out. Every
+// meeting has a Firestore document listing the files archived so far; the
+// invocation that archives the last one claims the meeting and deletes it
+// from Zoom, and the document is removed once the meeting is done. Failed
+// tasks are retried by Cloud Tasks as configured on the queue.
+type taskQueue struct {
+	service *cloudtasks.Service
+}
+
+func newTaskQueue(ctx context.Context) (*taskQueue, error) {
+	service, err := cloudtasks.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Tasks client: %w", err)
+	}
+	return &taskQueue{service: service}, nil
+}
+
+// fileKey identifies a recording file of a meeting in tasks and their
+// Firestore document.
+func fileKey(f recordingFile) string {
+	if f.ID != "" {
+		return f.ID
+	}
+	return f.FileName()
+}
+
+// taskDocName is the Firestore document tracking the meeting's tasks.
+func (run *backupRun) taskDocName(meetingID string) string {
+	return run.queue.parent + "/" + tasksCollection + "/" + queueDocumentID(meetingID)
+}
+
+// fanOutMeetings tracks every meeting in Firestore and creates a task for
+// each of its files that was not archived yet. Meetings tracked by an earlier
+// run keep their document, so only what is missing is added again.
+func (run *backupRun) fanOutMeetings(ctx context.Context, meetings []meeting) error {
+	docs := run.queueDocs()
+	created := 0
+	for _, m := range meetings {
+		archived, err := run.trackMeeting(ctx, docs, m)
+		if err != nil {
+			return err
+		}
+		pending := 0
+		for _, f := range m.Files {
+			if archived[fileKey(f)] {
+				continue
+			}
+			pending++
+			ok, err := run.createFileTask(ctx, fileTask{Job: run.cfg.JobName, Meeting: m.ID, File: fileKey(f)})
+			if err != nil {
+				return err
+			}
+			if ok {
+				created++
+			}
+		}
+		// Every file is archived but the meeting was not finished, e.g.
+		// because its deletion failed.
+		if pending == 0 {
+			ok, err := run.createFileTask(ctx, fileTask{Job: run.cfg.JobName, Meeting: m.ID})
+			if err != nil {
+				return err
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+	log.Printf("Created %d tasks for %d meetings of job %s", created, len(meetings), run.cfg.JobName)
+	return nil
+}
+
+// trackMeeting creates the meeting's document, or reads the files archived
+// so far from the one an earlier run created.
+func (run *backupRun) trackMeeting(ctx context.Context, docs *firestore.ProjectsDatabasesDocumentsService, m meeting) (map[string]bool, error) {
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	_, err = docs.CreateDocument(run.queue.parent, tasksCollection, &firestore.Document{
+		Fields: map[string]firestore.Value{
+			"meeting":         {StringValue: string(raw)},
+			"topic":           {StringValue: m.Topic},
+			"enqueued_at":     *queueTimestamp(time.Now()),
+			"finishing_until": *queueTimestamp(time.Time{}),
+			"archived":        {ArrayValue: &firestore.ArrayValue{}},
+		},
+	}).DocumentId(queueDocumentID(m.ID)).Context(ctx).Do()
+	if err == nil {
+		return nil, nil
+	}
+	if !isQueueConflict(err) {
+		return nil, fmt.Errorf("failed to track %s: %w", m.ID, err)
+	}
+	doc, err := docs.Get(run.taskDocName(m.ID)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tracked meeting %s: %w", m.ID, err)
+	}
+	return archivedKeys(doc), nil
+}
+
+func archivedKeys(doc *firestore.Document) map[string]bool {
+	archived := map[string]bool{}
+	if v := doc.Fields["archived"].ArrayValue; v != nil {
+		for _, key := range v.Values {
+			archived[key.StringValue] = true
+		}
+	}
+	return archived
+}
+
+// createFileTask adds the task to TASKS_QUEUE. Tasks are named after the job,
+// meeting and file plus an attempt, so a task that is still queued is not
+// added twice and ok is false, while the file of one that failed and is gone
+// gets a task under the next attempt's name.
+func (run *backupRun) createFileTask(ctx context.Context, t fileTask) (bool, error) {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return false, err
+	}
+	req := &cloudtasks.HttpRequest{
+		Url:        run.cfg.TasksURL,
+		HttpMethod: http.MethodPost,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       base64.StdEncoding.EncodeToString(body),
+	}
+	if run.cfg.TasksServiceAccount != "" {
+		req.OidcToken = &cloudtasks.OidcToken{ServiceAccountEmail: run.cfg.TasksServiceAccount}
+	}
+
+	tasks := run.tasks.service.Projects.Locations.Queues.Tasks
+	for attempt := 0; attempt < taskNameAttempts; attempt++ {
+		name := taskName(run.cfg.TasksQueue, t, attempt)
+		_, err = tasks.Create(run.cfg.TasksQueue, &cloudtasks.CreateTaskRequest{
+			Task: &cloudtasks.Task{
+				Name:             name,
+				HttpRequest:      req,
+				DispatchDeadline: tasksDispatchDeadline,
+			},
+		}).Context(ctx).Do()
+		if e, ok := err.(*googleapi.Error); !ok || e.Code != http.StatusConflict {
+			break
+		}
+		// The name is taken. A task that still exists is queued or running,
+		// one that does not finished or was given up on.
+		_, err = tasks.Get(name).Context(ctx).Do()
+		if err == nil {
+			return false, nil
+		}
+		if !isGoogleNotFound(err) {
+			return false, fmt.Errorf("failed to look up task for %s of %s: %w", t.File, t.Meeting, err)
+		}
+		err = fmt.Errorf("all %d task names are taken", taskNameAttempts)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create task for %s of %s: %w", t.File, t.Meeting, err)
+	}
+	return true, nil
+}
+
+// taskName is the name of the file's task for the attempt.
+func taskName(queue string, t fileTask, attempt int) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(t.Job + "\x00" + t.Meeting + "\x00" + t.File))
+	if attempt == 0 {
+		return fmt.Sprintf("%s/tasks/zoom-backup-%016x", queue, h.Sum64())
+	}
+	return fmt.Sprintf("%s/tasks/zoom-backup-%016x-%d", queue, h.Sum64(), attempt)
+}
+
+// runFileTask archives the file of the run's task and, once every file of
+// the meeting is archived, finishes the meeting. Failures of the file are in
+// the report, which makes the task fail and Cloud Tasks retry it.
+func (run *backupRun) runFileTask(ctx context.Context) error {
+	t := run.cfg.fileTask
+	docs := run.queueDocs()
+	name := run.taskDocName(t.Meeting)
+	doc, err := docs.Get(name).Context(ctx).Do()
+	if isGoogleNotFound(err) {
+		log.Println("Meeting", t.Meeting, "was finished already")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tracked meeting %s: %w", t.Meeting, err)
+	}
+	var m meeting
+	if err := json.Unmarshal([]byte(doc.Fields["meeting"].StringValue), &m); err != nil {
+		return fmt.Errorf("failed to unmarshal tracked meeting %s: %w", t.Meeting, err)
+	}
+	// The audio of audio-only meetings is selected again, as it does not
+	// survive Firestore.
+	m = selectAudio(run.cfg, selectViews(run.cfg, []meeting{m}))[0]
+	run.report.meetingDiscovered()
+	run.emit(eventDiscovered, m, event{Bytes: meetingSize(m)})
+
+	if t.File != "" && !archivedKeys(doc)[t.File] {
+		recording, ok := findFile(m, t.File)
+		if !ok {
+			log.Println("Dropping task for unknown file", t.File, "of", m.ID)
+			return nil
+		}
+		if _, err := run.archiveFile(ctx, m, recording); err != nil {
+			// Other failures of the file are in the report already.
+			if err == errWindowClosed || ctx.Err() != nil {
+				return fmt.Errorf("stopped archiving %s of %s: %w", recording.FileName(), m.ID, err)
+			}
+			return nil
+		}
+		_, err := docs.Commit(run.queue.database, &firestore.CommitRequest{
+			Writes: []*firestore.Write{{
+				Transform: &firestore.DocumentTransform{
+					Document: name,
+					FieldTransforms: []*firestore.FieldTransform{{
+						FieldPath:             "archived",
+						AppendMissingElements: &firestore.ArrayValue{Values: []*firestore.Value{{StringValue: t.File}}},
+					}},
+				},
+				CurrentDocument: &firestore.Precondition{Exists: true},
+			}},
+		}).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to record %s of %s as archived: %w", t.File, m.ID, err)
+		}
+		if doc, err = docs.Get(name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to read tracked meeting %s: %w", m.ID, err)
+		}
+	}
+
+	archived := archivedKeys(doc)
+	for _, f := range m.Files {
+		if !archived[fileKey(f)] {
+			return nil
+		}
+	}
+	return run.finishTrackedMeeting(ctx, doc, m)
+}
+
+// finishTrackedMeeting exports and deletes a meeting whose files are all
+// archived. Of the invocations archiving its last files only the one that
+// claims the document finishes the meeting; the claim runs out after
+// QUEUE_LEASE in case that invocation dies.
+func (run *backupRun) finishTrackedMeeting(ctx context.Context, doc *firestore.Document, m meeting) error {
+	docs := run.queueDocs()
+	now := time.Now()
+	finishingUntil, _ := time.Parse(time.RFC3339Nano, doc.Fields["finishing_until"].TimestampValue)
+	if finishingUntil.After(now) {
+		return nil
+	}
+	_, err := docs.Patch(doc.Name, &firestore.Document{
+		Fields: map[string]firestore.Value{"finishing_until": *queueTimestamp(now.Add(run.cfg.QueueLease))},
+	}).UpdateMaskFieldPaths("finishing_until").CurrentDocumentUpdateTime(doc.UpdateTime).Context(ctx).Do()
+	if isQueueConflict(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to claim %s: %w", m.ID, err)
+	}
+
+	files, complete := run.trackedFiles(ctx, m)
+	complete = run.exportMeeting(ctx, m, files, complete)
+	if run.settleMeeting(ctx, m, files, complete) {
+		if _, err := docs.Delete(doc.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to remove tracked meeting %s: %w", m.ID, err)
+		}
+		return nil
+	}
+	_, err = docs.Patch(doc.Name, &firestore.Document{
+		Fields: map[string]firestore.Value{"finishing_until": *queueTimestamp(time.Time{})},
+	}).UpdateMaskFieldPaths("finishing_until").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to release %s: %w", m.ID, err)
+	}
+	return fmt.Errorf("meeting %s could not be finished", m.ID)
+}
+
+// trackedFiles looks up the objects other invocations archived the files of
+// the meeting to. complete is false when any is missing.
+func (run *backupRun) trackedFiles(ctx context.Context, m meeting) ([]archivedFile, bool) {
+	cfg := run.cfg
+	var files []archivedFile
+	complete := true
+	for _, recording := range m.Files {
+		fileSaveName, err := getFileSaveName(cfg, m, recording)
+		if err == nil {
+			var attrs *storage.ObjectAttrs
+			objectName := cfg.compressedName(cfg.objectName(fileSaveName), recording.FileType)
+			if attrs, err = run.storageClient.Bucket(cfg.Bucket).Object(objectName).Attrs(ctx); err == nil {
+				files = append(files, archivedFile{recording: recording, attrs: attrs})
+				continue
+			}
+		}
+		err = fmt.Errorf("Could not find archived %s of %s: %v", recording.FileName(), m.ID, err)
+		log.Println(err)
+		run.report.fail(err)
+		complete = false
+	}
+	return files, complete
+}
+
+func findFile(m meeting, key string) (recordingFile, bool) {
+	for _, f := range m.Files {
+		if fileKey(f) == key {
+			return f, true
+		}
+	}
+	return recordingFile{}, false
+}
+
+func isGoogleNotFound(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	return ok && e.Code == http.StatusNotFound
+}
+
+// serveTask runs the Cloud Task in the request body and responds 500 when it
+// failed, which makes Cloud Tasks retry it.
+func serveTask(w http.ResponseWriter, r *http.Request, storageClient *storage.Client, jobs []*config, events *eventStream) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t := &fileTask{}
+	if err := json.NewDecoder(r.Body).Decode(t); err != nil || t.Meeting == "" {
+		http.Error(w, "invalid task", http.StatusBadRequest)
+		return
+	}
+	job, err := selectJob(jobs, t.Job)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if job.QueueMode != queueModeTasks {
+		http.Error(w, "QUEUE_MODE tasks is not configured", http.StatusBadRequest)
+		return
+	}
+	job.fileTask = t
+
+	report := runBackup(r.Context(), storageClient, job, events)
+	report.log()
+	code := http.StatusOK
+	if report.failed() {
+		code = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Println("Could not write response:", err)
+	}
+}
+
+func isTaskRequest(r *http.Request) bool {
+	return strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/task")
+}
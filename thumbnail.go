@@ -0,0 +1,83 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// thumbnailExt replaces the extension of the recording a thumbnail shows.
+const thumbnailExt = ".thumb.jpg"
+
+// thumbnailName is the object name of the thumbnail of the archived object.
+func thumbnailName(object string) string {
+	return strings.TrimSuffix(object, path.Ext(object)) + thumbnailExt
+}
+
+// isThumbnail reports whether the object is a thumbnail written with
+// THUMBNAILS.
+func isThumbnail(name string) bool {
+	return strings.HasSuffix(name, thumbnailExt)
+}
+
+// writeThumbnails stores a thumbnail next to every archived MP4 of the
+// meeting. A recording without a thumbnail is still archived, so failures are
+// reported but do not keep the meeting on Zoom.
+func (run *backupRun) writeThumbnails(ctx context.Context, mtg meeting, files []archivedFile) {
+	for _, file := range files {
+		if file.recording.FileType != "MP4" {
+			continue
+		}
+		if err := run.writeThumbnail(ctx, mtg, file); err != nil {
+			err = fmt.Errorf("Could not write thumbnail of %s: %v", file.attrs.Name, err)
+			log.Println(err)
+			run.report.fail(err)
+		}
+	}
+}
+
+// writeThumbnail extracts the frame THUMBNAIL_OFFSET seconds into the
+// archived recording, or halfway through shorter meetings, with ffmpeg.
+func (run *backupRun) writeThumbnail(ctx context.Context, mtg meeting, file archivedFile) error {
+	offset := run.cfg.ThumbnailOffset
+	if seconds := mtg.Duration * 60; seconds > 0 && offset >= seconds {
+		offset = seconds / 2
+	}
+	content, err := run.openStored(ctx, file.attrs.Name)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	thumbnail, _, err := run.ffmpeg(ctx, content, ".jpg", func(in, out string) []string {
+		return []string{
+			"-nostdin", "-hide_banner", "-loglevel", "error", "-y",
+			"-ss", strconv.Itoa(offset),
+			"-i", in,
+			"-frames:v", "1",
+			"-vf", fmt.Sprintf("scale=%d:-2", run.cfg.ThumbnailWidth),
+			"-q:v", "4",
+			out,
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer thumbnail.Close()
+	raw, err := ioutil.ReadAll(thumbnail)
+	if err != nil {
+		return err
+	}
+	if len(raw) == 0 {
+		return fmt.Errorf("no frame %d seconds in", offset)
+	}
+	name := thumbnailName(file.attrs.Name)
+	if err := run.writeMeetingFile(ctx, mtg, name, "JPG", "image/jpeg", raw); err != nil {
+		return err
+	}
+	log.Println("Wrote thumbnail", name)
+	return nil
+}
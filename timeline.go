@@ -0,0 +1,142 @@
+package zoombackup
+
+import "time"
+
+// Outcomes of the meetings and files of a run.
+const (
+	outcomeArchived   = "archived"
+	outcomeDeleted    = "deleted"
+	outcomeFailed     = "failed"
+	outcomeIncomplete = "incomplete"
+	outcomeSkipped    = "skipped"
+	outcomeDeferred   = "deferred"
+	outcomePostponed  = "postponed"
+	outcomeQueued     = "queued"
+)
+
+// meetingTimeline records what became of a meeting during a run and when
+// each of its steps happened, so callers can tell which meetings and files
+// need attention and slow runs show where the time went. Steps that did not
+// happen are left out.
+type meetingTimeline struct {
+	MeetingUUID string `json:"meeting_uuid"`
+	Topic       string `json:"topic,omitempty"`
+	// Outcome is one of archived, deleted, failed, incomplete, deferred,
+	// postponed or queued once the run finished.
+	Outcome    string         `json:"outcome,omitempty"`
+	Discovered *time.Time     `json:"discovered,omitempty"`
+	Files      []fileTimeline `json:"files,omitempty"`
+	Deleted    *time.Time     `json:"deleted,omitempty"`
+	// Error is the last failure that was not tied to a file, e.g. of the
+	// deletion.
+	Error string `json:"error,omitempty"`
+}
+
+// fileTimeline records the steps of one recording file.
+type fileTimeline struct {
+	File string `json:"file"`
+	// FileID is Zoom's ID of the recording file.
+	FileID string `json:"file_id,omitempty"`
+	// Outcome is archived, failed or skipped.
+	Outcome         string     `json:"outcome,omitempty"`
+	Object          string     `json:"object,omitempty"`
+	Bytes           int64      `json:"bytes,omitempty"`
+	DownloadStarted *time.Time `json:"download_started,omitempty"`
+	Downloaded      *time.Time `json:"downloaded,omitempty"`
+	Uploaded        *time.Time `json:"uploaded,omitempty"`
+	Verified        *time.Time `json:"verified,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// timeline returns the timeline of the meeting, adding it in the order the
+// meetings are first seen. The caller holds r.mu.
+func (r *runReport) timeline(meetingUUID, topic string) *meetingTimeline {
+	if r.timelines == nil {
+		r.timelines = map[string]int{}
+	}
+	i, ok := r.timelines[meetingUUID]
+	if !ok {
+		i = len(r.Timelines)
+		r.timelines[meetingUUID] = i
+		r.Timelines = append(r.Timelines, &meetingTimeline{MeetingUUID: meetingUUID, Topic: topic})
+	}
+	return r.Timelines[i]
+}
+
+// file returns the timeline of the recording file, adding it if needed.
+func (t *meetingTimeline) file(name string) *fileTimeline {
+	for i := range t.Files {
+		if t.Files[i].File == name {
+			return &t.Files[i]
+		}
+	}
+	t.Files = append(t.Files, fileTimeline{File: name})
+	return &t.Files[len(t.Files)-1]
+}
+
+// settleOutcomes gives every meeting that was not deferred, postponed or
+// queued the outcome its files and deletion add up to. The caller holds
+// r.mu.
+func (r *runReport) settleOutcomes() {
+	for _, t := range r.Timelines {
+		if t.Outcome != "" {
+			continue
+		}
+		failed, skipped := t.Error != "", false
+		for _, f := range t.Files {
+			failed = failed || f.Outcome == outcomeFailed || f.Error != ""
+			skipped = skipped || f.Outcome == outcomeSkipped
+		}
+		switch {
+		case failed:
+			t.Outcome = outcomeFailed
+		case skipped:
+			t.Outcome = outcomeIncomplete
+		case t.Deleted != nil:
+			t.Outcome = outcomeDeleted
+		default:
+			t.Outcome = outcomeArchived
+		}
+	}
+}
+
+// recordTimeline adds the event to the timeline of its meeting.
+func (r *runReport) recordTimeline(e event) {
+	if e.MeetingUUID == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mtg := r.timeline(e.MeetingUUID, e.Topic)
+	at := e.Time
+
+	if e.File == "" {
+		switch e.Action {
+		case eventDiscovered:
+			mtg.Discovered = &at
+		case eventDeleted:
+			mtg.Deleted = &at
+		case eventFailed:
+			mtg.Error = e.Error
+		}
+		return
+	}
+
+	file := mtg.file(e.File)
+	if e.FileID != "" {
+		file.FileID = e.FileID
+	}
+	switch e.Action {
+	case eventDownloading:
+		file.DownloadStarted = &at
+	case eventDownloaded:
+		file.Downloaded = &at
+	case eventUploaded:
+		file.Uploaded = &at
+	case eventVerified:
+		file.Verified = &at
+	case eventFailed:
+		file.Error = e.Error
+	}
+}
@@ -0,0 +1,130 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// defaultZoomOAuthURL is where OAuth access tokens are requested, which
+	// ZOOM_OAUTH_URL replaces along with ZOOM_API_URL.
+	defaultZoomOAuthURL = "https://zoom.us/oauth/token"
+	// tokenMinValidity is how long a cached token must remain valid to be
+	// handed to a run.
+	tokenMinValidity = 20 * time.Minute
+)
+
+// usesOAuth reports whether the job authenticates through a Server-to-Server
+// OAuth app.
+func (cfg *config) usesOAuth() bool {
+	return cfg.ZoomClientID != ""
+}
+
+// zoomToken returns an access token for the job's Zoom account. JWTs are
+// signed locally; OAuth tokens come from the shared cache, which requests
+// them through httpClient.
+func zoomToken(ctx context.Context, cfg *config, httpClient *http.Client) (string, error) {
+	if cfg.usesOAuth() {
+		return zoomTokens.get(ctx, httpClient, cfg.ZoomOAuthURL, cfg.ZoomAccountID, cfg.ZoomClientID, cfg.ZoomClientSecret)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{
+		ExpiresAt: time.Now().Add(tokenExpiresIn).Unix(),
+		Issuer:    cfg.ZoomAPIKey,
+	}).SignedString([]byte(cfg.ZoomAPISecret))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return token, nil
+}
+
+type cachedToken struct {
+	value  string
+	expiry time.Time
+}
+
+// tokenCache shares OAuth access tokens between the jobs and runs of the
+// process, keyed by account and client. Concurrent refreshes of the same key
+// are collapsed into one request so parallel workers do not run into Zoom's
+// token rate limits.
+type tokenCache struct {
+	mu      sync.Mutex
+	tokens  map[string]cachedToken
+	refresh singleflight.Group
+}
+
+var zoomTokens = &tokenCache{tokens: map[string]cachedToken{}}
+
+func (c *tokenCache) get(ctx context.Context, httpClient *http.Client, tokenURL, accountID, clientID, clientSecret string) (string, error) {
+	key := tokenURL + "/" + accountID + "/" + clientID
+	c.mu.Lock()
+	token, ok := c.tokens[key]
+	c.mu.Unlock()
+	if ok && time.Until(token.expiry) > tokenMinValidity {
+		return token.value, nil
+	}
+
+	v, err, _ := c.refresh.Do(key, func() (interface{}, error) {
+		token, err := requestOAuthToken(ctx, httpClient, tokenURL, accountID, clientID, clientSecret)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.tokens[key] = token
+		c.mu.Unlock()
+		return token.value, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// requestOAuthToken requests an account credentials token from Zoom's
+// tokenURL.
+func requestOAuthToken(ctx context.Context, httpClient *http.Client, tokenURL, accountID, clientID, clientSecret string) (cachedToken, error) {
+	requested := time.Now()
+	query := url.Values{"grant_type": {"account_credentials"}, "account_id": {accountID}}
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	req.SetBasicAuth(clientID, clientSecret)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to request OAuth token: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode/200 != 1 {
+		return cachedToken{}, fmt.Errorf("invalid OAuth token response code: %d -- %s", resp.StatusCode, buf.String())
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &response); err != nil {
+		return cachedToken{}, fmt.Errorf("failed to unmarshal OAuth token: %w", err)
+	}
+	if response.AccessToken == "" {
+		return cachedToken{}, fmt.Errorf("OAuth token response has no access token")
+	}
+	return cachedToken{
+		value:  response.AccessToken,
+		expiry: requested.Add(time.Duration(response.ExpiresIn) * time.Second),
+	}, nil
+}
@@ -0,0 +1,104 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// transcodes reports whether the recording is re-encoded with TRANSCODE
+// before it is uploaded. Recordings of critical meetings are kept as Zoom
+// serves them, which is what their verification checks.
+func (cfg *config) transcodes(mtg meeting, recording recordingFile) bool {
+	return cfg.Transcode && strings.ToUpper(recording.FileType) == "MP4" && !cfg.isCritical(mtg)
+}
+
+// ffmpegArgs are the arguments that re-encode in to out at TRANSCODE_HEIGHT
+// lines at most, keeping smaller recordings' size.
+func (cfg *config) ffmpegArgs(in, out string) []string {
+	args := []string{
+		"-nostdin", "-hide_banner", "-loglevel", "error", "-y",
+		"-i", in,
+		"-vf", fmt.Sprintf("scale=-2:'min(%d,ih)'", cfg.TranscodeHeight),
+		"-c:v", cfg.TranscodeCodec,
+	}
+	if cfg.TranscodeVideoBitrate != "" {
+		args = append(args, "-b:v", cfg.TranscodeVideoBitrate)
+	} else {
+		args = append(args, "-crf", strconv.Itoa(cfg.TranscodeCRF))
+	}
+	if cfg.TranscodeCodec == "libx265" {
+		// Apple players only recognize H.265 in MP4 with this tag.
+		args = append(args, "-tag:v", "hvc1")
+	}
+	return append(args,
+		"-c:a", "aac", "-b:a", cfg.TranscodeAudioBitrate,
+		"-movflags", "+faststart",
+		out,
+	)
+}
+
+// transcode re-encodes the downloaded recording with ffmpeg and returns the
+// result along with its size.
+func (run *backupRun) transcode(ctx context.Context, body io.Reader) (io.ReadCloser, int64, error) {
+	return run.ffmpeg(ctx, body, ".mp4", run.cfg.ffmpegArgs)
+}
+
+// ffmpeg runs ffmpeg with the arguments args returns for the downloaded
+// recording and the file, with extension ext, to write. ffmpeg needs to seek
+// in MP4 files, so the download and the result are temporary files, removed
+// when the returned result is closed.
+func (run *backupRun) ffmpeg(ctx context.Context, body io.Reader, ext string, args func(in, out string) []string) (io.ReadCloser, int64, error) {
+	in, err := ioutil.TempFile("", "zoom-backup-*.mp4")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.Remove(in.Name())
+	_, err = io.Copy(in, body)
+	if closeErr := in.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to save download: %w", err)
+	}
+
+	out := strings.TrimSuffix(in.Name(), ".mp4") + "-out" + ext
+	cmd := exec.CommandContext(ctx, run.cfg.FFmpegBinary, args(in.Name(), out)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		_ = os.Remove(out)
+		return nil, 0, fmt.Errorf("ffmpeg: %w -- %s", err, strings.TrimSpace(stderr.String()))
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		_ = os.Remove(out)
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(out)
+		return nil, 0, err
+	}
+	return &removeOnClose{f}, info.Size(), nil
+}
+
+// removeOnClose deletes a temporary file once it has been read.
+type removeOnClose struct {
+	*os.File
+}
+
+func (f *removeOnClose) Close() error {
+	err := f.File.Close()
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,240 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	speech "google.golang.org/api/speech/v1"
+)
+
+const (
+	// transcribeGoogle transcribes with Google Cloud Speech-to-Text.
+	transcribeGoogle = "google"
+	// transcribeWhisper transcribes with an OpenAI compatible Whisper
+	// endpoint.
+	transcribeWhisper = "whisper"
+
+	defaultWhisperURL = "https://api.openai.com/v1/audio/transcriptions"
+
+	transcriptName = "transcript.vtt"
+
+	// speechPollInterval is the wait between checks of a Speech-to-Text
+	// operation.
+	speechPollInterval = 15 * time.Second
+)
+
+// transcribeMeeting stores transcript.vtt, generated from the meeting's
+// audio with TRANSCRIBE, in the meeting folder. The audio recording is used
+// when it was archived, else the first video.
+func (run *backupRun) transcribeMeeting(ctx context.Context, mtg meeting, files []archivedFile) error {
+	var source *archivedFile
+	for i, file := range files {
+		if file.recording.FileType == "M4A" {
+			source = &files[i]
+			break
+		}
+		if file.recording.FileType == "MP4" && source == nil {
+			source = &files[i]
+		}
+	}
+	if source == nil {
+		return nil
+	}
+	folder, err := meetingFolder(run.cfg, mtg)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Transcribing", source.attrs.Name)
+	content, err := run.openStored(ctx, source.attrs.Name)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	// Speech only needs a mono, low bitrate track, which keeps long
+	// meetings below the upload limits of the services.
+	audio, size, err := run.ffmpeg(ctx, content, ".ogg", func(in, out string) []string {
+		return []string{
+			"-nostdin", "-hide_banner", "-loglevel", "error", "-y",
+			"-i", in,
+			"-vn", "-ac", "1", "-ar", "16000",
+			"-c:a", "libopus", "-b:a", "24k",
+			out,
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer audio.Close()
+
+	var vtt []byte
+	switch run.cfg.Transcribe {
+	case transcribeGoogle:
+		vtt, err = run.transcribeGoogle(ctx, folder, audio)
+	case transcribeWhisper:
+		vtt, err = run.transcribeWhisper(ctx, audio, size)
+	}
+	if err != nil {
+		return err
+	}
+
+	name := run.cfg.objectName(path.Join(folder, transcriptName))
+	if err := run.writeMeetingFile(ctx, mtg, name, "VTT", run.cfg.contentType("VTT"), vtt); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	log.Println("Wrote transcript", name)
+	return nil
+}
+
+// transcribeGoogle uploads the audio next to the meeting, where
+// Speech-to-Text reads it from, and waits for the transcription.
+func (run *backupRun) transcribeGoogle(ctx context.Context, folder string, audio io.Reader) ([]byte, error) {
+	service, err := speech.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Speech-to-Text client: %w", err)
+	}
+
+	obj := run.storageClient.Bucket(run.cfg.Bucket).Object(run.cfg.objectName(path.Join(folder, "transcribe.ogg")))
+	wc := obj.NewWriter(ctx)
+	wc.ContentType = "audio/ogg"
+	if _, err := io.Copy(wc, audio); err != nil {
+		_ = wc.Close()
+		return nil, fmt.Errorf("failed to upload audio: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to upload audio: %w", err)
+	}
+	defer func() {
+		if err := obj.Delete(context.Background()); err != nil {
+			log.Println("Could not delete", obj.ObjectName(), err)
+		}
+	}()
+
+	op, err := service.Speech.Longrunningrecognize(&speech.LongRunningRecognizeRequest{
+		Audio: &speech.RecognitionAudio{Uri: fmt.Sprintf("gs://%s/%s", obj.BucketName(), obj.ObjectName())},
+		Config: &speech.RecognitionConfig{
+			Encoding:                   "OGG_OPUS",
+			SampleRateHertz:            16000,
+			LanguageCode:               run.cfg.TranscribeLanguage,
+			EnableAutomaticPunctuation: true,
+			EnableWordTimeOffsets:      true,
+		},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transcription: %w", err)
+	}
+	for name := op.Name; !op.Done; {
+		select {
+		case <-time.After(speechPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if op, err = service.Operations.Get(name).Context(ctx).Do(); err != nil {
+			return nil, fmt.Errorf("failed to check transcription %s: %w", name, err)
+		}
+	}
+	if op.Error != nil {
+		return nil, fmt.Errorf("transcription failed: %s", op.Error.Message)
+	}
+	var response speech.LongRunningRecognizeResponse
+	if err := json.Unmarshal(op.Response, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transcription: %w", err)
+	}
+	return speechVTT(response.Results), nil
+}
+
+// speechVTT writes one WebVTT cue per Speech-to-Text result, timed by its
+// first and last word.
+func speechVTT(results []*speech.SpeechRecognitionResult) []byte {
+	vtt := bytes.NewBufferString("WEBVTT\n")
+	cue := 0
+	for _, result := range results {
+		if len(result.Alternatives) == 0 {
+			continue
+		}
+		alt := result.Alternatives[0]
+		text := strings.TrimSpace(alt.Transcript)
+		if text == "" || len(alt.Words) == 0 {
+			continue
+		}
+		start, _ := time.ParseDuration(alt.Words[0].StartTime)
+		end, _ := time.ParseDuration(alt.Words[len(alt.Words)-1].EndTime)
+		cue++
+		fmt.Fprintf(vtt, "\n%d\n%s --> %s\n%s\n", cue, vttTimestamp(start), vttTimestamp(end), text)
+	}
+	return vtt.Bytes()
+}
+
+// vttTimestamp formats d as hh:mm:ss.ttt.
+func vttTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// transcribeWhisper posts the audio to WHISPER_URL and returns the WebVTT
+// transcript it responds with.
+func (run *backupRun) transcribeWhisper(ctx context.Context, audio io.Reader, size int64) ([]byte, error) {
+	payload := bytes.NewBuffer(make([]byte, 0, size+1024))
+	form := multipart.NewWriter(payload)
+	part, err := form.CreateFormFile("file", "audio.ogg")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+	fields := map[string]string{
+		"model":           run.cfg.WhisperModel,
+		"response_format": "vtt",
+	}
+	if lang := strings.SplitN(run.cfg.TranscribeLanguage, "-", 2)[0]; lang != "" {
+		fields["language"] = strings.ToLower(lang)
+	}
+	for key, value := range fields {
+		if err := form.WriteField(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", run.cfg.WhisperURL, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if run.cfg.WhisperAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+run.cfg.WhisperAPIKey)
+	}
+	// Transcribing takes a good share of the meeting's length.
+	client := *run.cfg.client()
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("Whisper responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if !bytes.HasPrefix(body, []byte("WEBVTT")) {
+		return nil, errors.New("Whisper did not respond with WebVTT")
+	}
+	return body, nil
+}
@@ -0,0 +1,93 @@
+package zoombackup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	dialNetworkAny  = "tcp"
+	dialNetworkIPv4 = "tcp4"
+	dialNetworkIPv6 = "tcp6"
+)
+
+// dialerConfig controls how connections to Zoom and its download CDN are
+// made. Some IPv6-only or NAT'd environments hang with the defaults, so the
+// address family, DNS resolver and timeouts can be pinned. Proxies come from
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+type dialerConfig struct {
+	// Network is tcp (dual-stack), tcp4 or tcp6.
+	Network string
+	// Resolver is an optional host:port of a DNS server used instead of the
+	// system resolver.
+	Resolver string
+	Timeout  time.Duration
+	// FallbackDelay is how long a dual-stack dial waits for the preferred
+	// family before racing the other one. Negative disables the fallback.
+	FallbackDelay time.Duration
+	// APITimeout bounds a request to the Zoom API including its body.
+	// Downloads have their own timeout per file instead.
+	APITimeout          time.Duration
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds waiting for the headers of a response,
+	// downloads included. 0 waits as long as the request may take.
+	ResponseHeaderTimeout time.Duration
+}
+
+func newHTTPClient(dc *dialerConfig) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:       dc.Timeout,
+		FallbackDelay: dc.FallbackDelay,
+	}
+	if dc.Resolver != "" {
+		resolver := dc.Resolver
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: dc.Timeout}
+				return d.DialContext(ctx, network, resolver)
+			},
+		}
+	}
+
+	network := dc.Network
+	if network == "" {
+		network = dialNetworkAny
+	}
+
+	return &http.Client{
+		Timeout: dc.APITimeout,
+		Transport: &deprecationTransport{
+			base: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+					return dialer.DialContext(ctx, network, addr)
+				},
+				TLSHandshakeTimeout:   dc.TLSHandshakeTimeout,
+				ResponseHeaderTimeout: dc.ResponseHeaderTimeout,
+			},
+			tracker: zoomDeprecations,
+		},
+	}
+}
+
+func (dc *dialerConfig) validate() error {
+	switch dc.Network {
+	case "", dialNetworkAny, dialNetworkIPv4, dialNetworkIPv6:
+	default:
+		return fmt.Errorf("DIAL_NETWORK must be %s, %s or %s", dialNetworkAny, dialNetworkIPv4, dialNetworkIPv6)
+	}
+	if dc.Resolver != "" {
+		if _, _, err := net.SplitHostPort(dc.Resolver); err != nil {
+			return fmt.Errorf("DNS_RESOLVER must be host:port: %w", err)
+		}
+	}
+	if dc.APITimeout < 0 || dc.TLSHandshakeTimeout < 0 || dc.ResponseHeaderTimeout < 0 {
+		return errors.New("API_TIMEOUT, TLS_HANDSHAKE_TIMEOUT and RESPONSE_HEADER_TIMEOUT cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package zoombackup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+const (
+	zoomGroupsPath       = "/groups?page_size=300"
+	zoomGroupMembersPath = "/groups/%s/members?page_size=300"
+	zoomUserPath         = "/users/%s"
+)
+
+type zoomGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type groupsResponse struct {
+	NextPageToken string      `json:"next_page_token"`
+	Groups        []zoomGroup `json:"groups"`
+}
+
+type groupMember struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+type groupMembersResponse struct {
+	NextPageToken string        `json:"next_page_token"`
+	Members       []groupMember `json:"members"`
+}
+
+type zoomUser struct {
+	ID               string `json:"id"`
+	Email            string `json:"email"`
+	FirstName        string `json:"first_name"`
+	LastName         string `json:"last_name"`
+	DisplayName      string `json:"display_name"`
+	RoleID           string `json:"role_id"`
+	CustomAttributes []struct {
+		Key   string `json:"key"`
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"custom_attributes"`
+}
+
+// resolveUserIDs returns the IDs of every user whose recordings should be
+// backed up: the configured ZOOM_USER_ID plus the current members of each
+// configured Zoom group, or the users a Pub/Sub trigger asked for. Duplicates
+// are removed while keeping the order, and users opted out of archiving by
+// ZOOM_EXCLUDE_USERS, ZOOM_EXCLUDE_GROUP_IDS, role or custom attribute are
+// dropped.
+func resolveUserIDs(ctx context.Context, zoom *zoomClient, cfg *config) ([]string, error) {
+	excluded := map[string]bool{}
+	for _, ref := range cfg.ExcludeUsers {
+		excluded[strings.ToLower(ref)] = true
+		// Users given by ID, like ZOOM_USER_ID, are compared by ID, so
+		// emails are looked up to theirs and IDs to their emails.
+		user := &zoomUser{}
+		err := zoom.getJSON(ctx, fmt.Sprintf(zoomUserPath, url.PathEscape(ref)), user)
+		if errors.Is(err, errZoomNotFound) {
+			log.Println("Excluded user", ref, "is not on Zoom")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch excluded user %s: %w", ref, err)
+		}
+		excluded[strings.ToLower(user.ID)] = true
+		if user.Email != "" {
+			excluded[strings.ToLower(user.Email)] = true
+		}
+	}
+	groups := cfg.ZoomGroupIDs
+	if len(cfg.triggerUsers) > 0 {
+		groups = nil
+	}
+	if len(groups) > 0 || len(cfg.ExcludeGroupIDs) > 0 {
+		ids, err := zoom.resolveGroups(ctx, append(append([]string(nil), groups...), cfg.ExcludeGroupIDs...))
+		if err != nil {
+			return nil, err
+		}
+		groups = ids[:len(groups)]
+		for _, groupID := range ids[len(groups):] {
+			members, err := zoom.listGroupMembers(ctx, groupID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch members of excluded group %s: %w", groupID, err)
+			}
+			for _, member := range members {
+				excluded[strings.ToLower(member.ID)] = true
+				if member.Email != "" {
+					excluded[strings.ToLower(member.Email)] = true
+				}
+			}
+		}
+	}
+
+	var candidates []string
+	seen := map[string]bool{}
+	add := func(id, email string) {
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		if excluded[strings.ToLower(id)] || (email != "" && excluded[strings.ToLower(email)]) {
+			log.Println("Skipping user", id, email, "(excluded)")
+			return
+		}
+		candidates = append(candidates, id)
+	}
+
+	if len(cfg.triggerUsers) > 0 {
+		for _, id := range cfg.triggerUsers {
+			add(id, "")
+		}
+	} else {
+		add(cfg.ZoomUserID, "")
+	}
+	for _, groupID := range groups {
+		members, err := zoom.listGroupMembers(ctx, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch members of group %s: %w", groupID, err)
+		}
+		for _, member := range members {
+			add(member.ID, member.Email)
+		}
+	}
+
+	if len(cfg.ExcludeRoleIDs) == 0 && len(cfg.ExcludeAttributes) == 0 {
+		return candidates, nil
+	}
+
+	var userIDs []string
+	for _, id := range candidates {
+		user := &zoomUser{}
+		if err := zoom.getJSON(ctx, fmt.Sprintf(zoomUserPath, url.PathEscape(id)), user); err != nil {
+			return nil, fmt.Errorf("failed to fetch user %s: %w", id, err)
+		}
+		if reason := exclusionReason(user, cfg); reason != "" {
+			log.Println("Skipping user", id, user.Email, reason)
+			continue
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	return userIDs, nil
+}
+
+// exclusionReason explains why the user is opted out of archiving, or returns
+// an empty string when the user should be backed up.
+func exclusionReason(user *zoomUser, cfg *config) string {
+	for _, roleID := range cfg.ExcludeRoleIDs {
+		if user.RoleID == roleID {
+			return fmt.Sprintf("(role %s is excluded)", roleID)
+		}
+	}
+	for _, attr := range user.CustomAttributes {
+		for name, value := range cfg.ExcludeAttributes {
+			if (strings.EqualFold(attr.Name, name) || attr.Key == name) && strings.EqualFold(attr.Value, value) {
+				return fmt.Sprintf("(custom attribute %s=%s is excluded)", name, value)
+			}
+		}
+	}
+	return ""
+}
+
+// resolveGroups turns group IDs or names, e.g. Sales, into group IDs in the
+// same order. Names are matched regardless of case.
+func (c *zoomClient) resolveGroups(ctx context.Context, refs []string) ([]string, error) {
+	groups, err := c.listGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		for _, group := range groups {
+			if group.ID == ref {
+				ids[i] = group.ID
+				break
+			}
+			if ids[i] == "" && strings.EqualFold(group.Name, ref) {
+				ids[i] = group.ID
+			}
+		}
+		if ids[i] == "" {
+			return nil, fmt.Errorf("no Zoom group has the ID or name %q", ref)
+		}
+	}
+	return ids, nil
+}
+
+// listGroups lists every group of the account, following next_page_token.
+func (c *zoomClient) listGroups(ctx context.Context) ([]zoomGroup, error) {
+	var groups []zoomGroup
+	nextPageToken := ""
+	for {
+		reqPath := zoomGroupsPath
+		if nextPageToken != "" {
+			reqPath += "&next_page_token=" + url.QueryEscape(nextPageToken)
+		}
+
+		response := &groupsResponse{}
+		if err := c.getJSON(ctx, reqPath, response); err != nil {
+			return nil, err
+		}
+		groups = append(groups, response.Groups...)
+
+		if response.NextPageToken == "" {
+			return groups, nil
+		}
+		nextPageToken = response.NextPageToken
+	}
+}
+
+func (c *zoomClient) listGroupMembers(ctx context.Context, groupID string) ([]groupMember, error) {
+	var members []groupMember
+	nextPageToken := ""
+	for {
+		reqPath := fmt.Sprintf(zoomGroupMembersPath, url.PathEscape(groupID))
+		if nextPageToken != "" {
+			reqPath += "&next_page_token=" + url.QueryEscape(nextPageToken)
+		}
+
+		response := &groupMembersResponse{}
+		if err := c.getJSON(ctx, reqPath, response); err != nil {
+			return nil, err
+		}
+		members = append(members, response.Members...)
+
+		if response.NextPageToken == "" {
+			return members, nil
+		}
+		nextPageToken = response.NextPageToken
+	}
+}
@@ -0,0 +1,50 @@
+package zoombackup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestResolveUserIDs(t *testing.T) {
+	api := http.NewServeMux()
+	api.HandleFunc("/groups", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("next_page_token") == "" {
+			fmt.Fprint(w, `{"next_page_token":"2","groups":[{"id":"g1","name":"Sales"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"groups":[{"id":"g2","name":"Contractors"}]}`)
+	})
+	api.HandleFunc("/groups/g1/members", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"members":[{"id":"u2","email":"bo@example.com"},{"id":"u3","email":"cy@example.com"},{"id":"u4","email":"di@example.com"}]}`)
+	})
+	api.HandleFunc("/groups/g2/members", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"members":[{"id":"u3","email":"cy@example.com"}]}`)
+	})
+	api.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/al@example.com":
+			fmt.Fprint(w, `{"id":"u1","email":"al@example.com"}`)
+		case "/users/u4":
+			fmt.Fprint(w, `{"id":"u4","email":"di@example.com"}`)
+		default:
+			http.Error(w, `{"code":1001,"message":"User does not exist"}`, http.StatusNotFound)
+		}
+	})
+	c, _ := newTestZoom(t, api)
+
+	cfg := &config{
+		ZoomUserID:      "u1",
+		ZoomGroupIDs:    []string{"sales"},
+		ExcludeUsers:    []string{"al@example.com", "u4", "gone@example.com"},
+		ExcludeGroupIDs: []string{"Contractors"},
+	}
+	ids, err := resolveUserIDs(context.Background(), c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[u2]" {
+		t.Errorf("got users %v, want [u2]", ids)
+	}
+}
@@ -0,0 +1,177 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/envy"
+)
+
+// vaultPrefix marks a setting that names a field of a Vault secret, e.g.
+// vault://secret/data/zoom#api_secret. Without a field, value is read.
+const vaultPrefix = "vault://"
+
+// vaultClient reads secrets through Vault's HTTP API. It logs in with
+// VAULT_TOKEN or the AppRole VAULT_ROLE_ID and VAULT_SECRET_ID, renews its
+// token before it expires and logs in again when renewing fails, so
+// long-running processes keep access.
+type vaultClient struct {
+	addr      string
+	namespace string
+	roleID    string
+	secretID  string
+
+	httpClient *http.Client
+
+	token       string
+	tokenExpiry time.Time
+	tokenTTL    time.Duration
+}
+
+func newVaultClient(httpClient *http.Client) (*vaultClient, error) {
+	v := &vaultClient{
+		httpClient: httpClient,
+		addr:       strings.TrimSuffix(envy.Get("VAULT_ADDR", ""), "/"),
+		namespace:  envy.Get("VAULT_NAMESPACE", ""),
+		token:      envy.Get("VAULT_TOKEN", ""),
+		roleID:     envy.Get("VAULT_ROLE_ID", ""),
+		secretID:   envy.Get("VAULT_SECRET_ID", ""),
+	}
+	if v.addr == "" {
+		return nil, errors.New("VAULT_ADDR is required to read vault:// secrets")
+	}
+	if v.token == "" && v.roleID == "" {
+		return nil, errors.New("VAULT_TOKEN or VAULT_ROLE_ID is required to read vault:// secrets")
+	}
+	return v, nil
+}
+
+// read returns a field of the secret at path along with how long Vault
+// allows it to be used, 0 when it does not expire.
+func (v *vaultClient) read(ctx context.Context, path, field string) (string, time.Duration, error) {
+	if err := v.ensureToken(ctx); err != nil {
+		return "", 0, err
+	}
+	var resp struct {
+		LeaseDuration int                    `json:"lease_duration"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err := v.do(ctx, "GET", path, nil, &resp); err != nil {
+		return "", 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	data := resp.Data
+	// KV version 2 nests the secret in data.data.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[field].(string)
+	if !ok {
+		return "", 0, fmt.Errorf("secret %s has no field %q", path, field)
+	}
+	return value, time.Duration(resp.LeaseDuration) * time.Second, nil
+}
+
+// ensureToken renews the token once two thirds of its TTL have passed and
+// logs in with the AppRole when there is no usable token.
+func (v *vaultClient) ensureToken(ctx context.Context) error {
+	if v.token != "" && v.tokenExpiry.IsZero() {
+		// Learn the TTL of VAULT_TOKEN the first time it is used.
+		return v.lookupToken(ctx)
+	}
+	if v.token != "" && time.Until(v.tokenExpiry) > v.tokenTTL/3 {
+		return nil
+	}
+	if v.token != "" {
+		var resp vaultAuthResponse
+		err := v.do(ctx, "POST", "auth/token/renew-self", nil, &resp)
+		if err == nil {
+			v.setToken(resp.Auth.ClientToken, resp.Auth.LeaseDuration)
+			return nil
+		}
+		if v.roleID == "" {
+			return fmt.Errorf("failed to renew Vault token: %w", err)
+		}
+		log.Println("Failed to renew Vault token, logging in again:", err)
+	}
+	var resp vaultAuthResponse
+	login := map[string]string{"role_id": v.roleID, "secret_id": v.secretID}
+	v.token = ""
+	if err := v.do(ctx, "POST", "auth/approle/login", login, &resp); err != nil {
+		return fmt.Errorf("failed to log in to Vault: %w", err)
+	}
+	v.setToken(resp.Auth.ClientToken, resp.Auth.LeaseDuration)
+	return nil
+}
+
+func (v *vaultClient) lookupToken(ctx context.Context) error {
+	var resp struct {
+		Data struct {
+			TTL int `json:"ttl"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, "GET", "auth/token/lookup-self", nil, &resp); err != nil {
+		return fmt.Errorf("failed to look up Vault token: %w", err)
+	}
+	v.setToken(v.token, resp.Data.TTL)
+	return nil
+}
+
+type vaultAuthResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+// setToken remembers token and when it expires; tokens without a TTL are
+// treated as never expiring.
+func (v *vaultClient) setToken(token string, ttlSeconds int) {
+	v.token = token
+	v.tokenTTL = time.Duration(ttlSeconds) * time.Second
+	if v.tokenTTL == 0 {
+		v.tokenExpiry = time.Now().AddDate(100, 0, 0)
+		return
+	}
+	v.tokenExpiry = time.Now().Add(v.tokenTTL)
+}
+
+func (v *vaultClient) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, v.addr+"/v1/"+strings.TrimPrefix(path, "/"), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform request: %w", err)
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("invalid response code: %d -- %s", resp.StatusCode, respBody)
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,248 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+const (
+	// criticalVerifyReread reads the uploaded object back and compares it
+	// with what was downloaded.
+	criticalVerifyReread = "reread"
+	// criticalVerifyDownload downloads the recording from Zoom a second time
+	// and compares the two downloads.
+	criticalVerifyDownload = "download"
+)
+
+// isCritical reports whether the meeting matches CRITICAL_TOPIC_PATTERN.
+func (cfg *config) isCritical(mtg meeting) bool {
+	return cfg.criticalTopic != nil && cfg.criticalTopic.MatchString(mtg.Topic)
+}
+
+// verifyMeeting makes sure every archived file of a critical meeting holds
+// exactly what Zoom serves before its recordings are deleted.
+func (run *backupRun) verifyMeeting(ctx context.Context, mtg meeting, files []archivedFile) error {
+	for _, file := range files {
+		want, got := file.sha256, []byte(nil)
+		var err error
+		switch {
+		case want == nil:
+			// Archived by an earlier run, so only a fresh download can
+			// vouch for the object.
+			if want, err = run.zoomDigest(ctx, file); err == nil {
+				got, err = run.storedDigest(ctx, file)
+			}
+		case run.cfg.CriticalVerify == criticalVerifyDownload:
+			got, err = run.zoomDigest(ctx, file)
+		default:
+			got, err = run.storedDigest(ctx, file)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", file.attrs.Name, err)
+		}
+		if !bytes.Equal(want, got) {
+			return fmt.Errorf("%s does not match the recording Zoom serves (sha256 %x, expected %x)", file.attrs.Name, got, want)
+		}
+		log.Println("Verified", file.attrs.Name)
+		run.emit(eventVerified, mtg, event{File: file.recording.FileName(), FileID: file.recording.ID, Object: file.attrs.Name, Bytes: file.attrs.Size})
+	}
+	return nil
+}
+
+// storedDigest hashes the content of the archived object as it was
+// downloaded, undoing COMPRESSION.
+func (run *backupRun) storedDigest(ctx context.Context, file archivedFile) ([]byte, error) {
+	content, err := run.openStored(ctx, file.attrs.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// zoomDigest downloads the recording from Zoom again and hashes it.
+func (run *backupRun) zoomDigest(ctx context.Context, file archivedFile) ([]byte, error) {
+	if err := run.limits.download.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer run.limits.download.Release(1)
+
+	body, _, err := run.zoom.downloadFile(ctx, file.recording.DownloadURL, downloadTimeout(run.cfg, file.recording.FileSize))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+type verifyOptions struct {
+	days int
+	deep bool
+	out  io.Writer
+}
+
+func verifyCommand(args []string) int {
+	fs := flag.NewFlagSet("zoom-backup verify", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: zoom-backup verify [flags]")
+		fmt.Fprintln(fs.Output(), "Cross-checks the recordings Zoom still has against the archive without modifying either.")
+		fs.PrintDefaults()
+	}
+	job := fs.String("job", "", "job to verify; required with several jobs")
+	days := fs.Int("days", 30, "check the recordings of the last this many days")
+	deep := fs.Bool("deep", false, "download every recording from Zoom again and compare its sha256 with the archived content")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *days < 1 {
+		log.Println("-days must be at least 1")
+		return 2
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	storageClient, jobs, err := setupCLI(ctx, func(*config) error { return nil })
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	cfg, err := selectJob(jobs, *job)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	problems, err := verifyArchive(ctx, storageClient, cfg, verifyOptions{days: *days, deep: *deep, out: os.Stdout})
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	if problems > 0 {
+		return 1
+	}
+	return 0
+}
+
+// verifyArchive looks up the object every recording Zoom lists for the last
+// days should have been archived to and prints one line per file that is
+// missing, has a different size or no longer matches its checksum. It
+// returns how many problems it found.
+func verifyArchive(ctx context.Context, storageClient *storage.Client, cfg *config, opts verifyOptions) (int, error) {
+	zoom, err := newZoomClient(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+	userIDs, err := resolveUserIDs(ctx, zoom, cfg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve users: %w", err)
+	}
+	var meetings []meeting
+	for _, userID := range userIDs {
+		userMeetings, err := zoom.listRecordingsSince(ctx, userID, opts.days, nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch recordings for %s: %w", userID, err)
+		}
+		meetings = append(meetings, userMeetings...)
+	}
+	meetings = selectAudio(cfg, selectViews(cfg, filterTopics(cfg, filterSources(meetings, cfg.RecordingSources))))
+
+	m, err := loadManifest(ctx, storageClient, cfg)
+	if err != nil {
+		return 0, err
+	}
+	manifestFiles := map[string]manifestFile{}
+	for _, mtg := range m.Meetings {
+		for _, file := range mtg.Files {
+			manifestFiles[file.Object] = file
+		}
+	}
+
+	run := &backupRun{cfg: cfg, storageClient: storageClient, zoom: zoom, limits: newStageLimits(cfg)}
+	checked, problems := 0, 0
+	report := func(problem, objectName string, mtg meeting, recording recordingFile, detail string) {
+		problems++
+		fmt.Fprintf(opts.out, "%s\t%s\t%s %s: %s\n", problem, objectName, mtg.Topic, recording.FileName(), detail)
+	}
+	for _, mtg := range meetings {
+		for _, recording := range mtg.Files {
+			if err := ctx.Err(); err != nil {
+				return problems, err
+			}
+			if checkFileSize(cfg, recording) != nil {
+				continue
+			}
+			fileSaveName, err := getFileSaveName(cfg, mtg, recording)
+			if err != nil {
+				return problems, fmt.Errorf("failed to get file save name: %w", err)
+			}
+			objectName := cfg.compressedName(cfg.objectName(fileSaveName), recording.FileType)
+			checked++
+
+			attrs, err := run.contentObject(objectName).Attrs(ctx)
+			if err == storage.ErrObjectNotExist {
+				report("missing", objectName, mtg, recording, "not in the bucket")
+				continue
+			}
+			if err != nil {
+				report("unreadable", objectName, mtg, recording, err.Error())
+				continue
+			}
+			transcoded := attrs.Metadata["transcoded"] != ""
+			stored := attrs.ContentEncoding == "" && !strings.HasSuffix(objectName, zstdExt) && !transcoded
+			if stored && recording.FileSize > 0 && attrs.Size != recording.FileSize {
+				report("size_mismatch", objectName, mtg, recording, fmt.Sprintf("%d bytes, Zoom reports %d", attrs.Size, recording.FileSize))
+				continue
+			}
+			if file, ok := manifestFiles[objectName]; ok {
+				if got := hex.EncodeToString(attrs.MD5); file.MD5 != "" && got != "" && got != file.MD5 {
+					report("checksum_mismatch", objectName, mtg, recording, fmt.Sprintf("md5 %s, the manifest recorded %s", got, file.MD5))
+					continue
+				}
+				if got := fmt.Sprintf("%08x", attrs.CRC32C); file.CRC32C != "" && got != file.CRC32C {
+					report("checksum_mismatch", objectName, mtg, recording, fmt.Sprintf("crc32c %s, the manifest recorded %s", got, file.CRC32C))
+					continue
+				}
+			}
+			if opts.deep && !transcoded {
+				file := archivedFile{recording: recording, attrs: attrs}
+				want, err := run.zoomDigest(ctx, file)
+				if err != nil {
+					report("unreadable", objectName, mtg, recording, fmt.Sprintf("failed to download from Zoom: %v", err))
+					continue
+				}
+				got, err := run.storedDigest(ctx, file)
+				if err != nil {
+					report("unreadable", objectName, mtg, recording, err.Error())
+					continue
+				}
+				if !bytes.Equal(want, got) {
+					report("checksum_mismatch", objectName, mtg, recording, fmt.Sprintf("sha256 %x, Zoom serves %x", got, want))
+					continue
+				}
+			}
+		}
+	}
+
+	log.Printf("Verified %d files of %d meetings, %d problems", checked, len(meetings), problems)
+	return problems, nil
+}
@@ -0,0 +1,59 @@
+package zoombackup
+
+import "log"
+
+// selectViews keeps only the MP4 files of the recording types in
+// RECORDING_VIEWS, e.g. just shared_screen_with_speaker_view of a meeting Zoom
+// also recorded as gallery_view, and of those only the type that comes first
+// in RECORDING_VIEW_PREFERENCE. Meetings without a file of any of the listed
+// types keep all of theirs, so nothing goes unarchived because it was recorded
+// in another layout.
+func selectViews(cfg *config, meetings []meeting) []meeting {
+	if len(cfg.RecordingViews) == 0 && len(cfg.RecordingViewPreference) == 0 {
+		return meetings
+	}
+	for i, mtg := range meetings {
+		kept := filterViews(mtg.Files, cfg.RecordingViews)
+		if view := preferredView(kept, cfg.RecordingViewPreference); view != "" {
+			kept = filterViews(kept, []string{view})
+		}
+		if len(kept) == 0 || len(kept) == len(mtg.Files) {
+			continue
+		}
+		log.Printf("Archiving %d of the %d views of %s %s", len(kept), len(mtg.Files), mtg.ID, mtg.Topic)
+		meetings[i].Files = kept
+	}
+	return meetings
+}
+
+// filterViews returns the files of the recording types in views, or all
+// files when views is empty.
+func filterViews(files []recordingFile, views []string) []recordingFile {
+	if len(views) == 0 {
+		return files
+	}
+	allowed := map[string]bool{}
+	for _, view := range views {
+		allowed[view] = true
+	}
+	var kept []recordingFile
+	for _, file := range files {
+		if allowed[file.RecordingType] {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// preferredView is the first recording type of preference that any of the
+// files has, or "" when none has any.
+func preferredView(files []recordingFile, preference []string) string {
+	for _, view := range preference {
+		for _, file := range files {
+			if file.RecordingType == view {
+				return view
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+)
+
+const (
+	recordingSourceMeeting = "meeting"
+	recordingSourceWebinar = "webinar"
+
+	zoomPastWebinarQAPath    = "/past_webinars/%s/qa"
+	zoomPastWebinarPollsPath = "/past_webinars/%s/polls"
+)
+
+// isWebinar tells webinar recordings apart from meeting recordings by the
+// type Zoom reports in the recordings list: 5 webinar, 6 recurring webinar
+// without a fixed time, 9 recurring webinar with a fixed time.
+func (m meeting) isWebinar() bool {
+	switch m.Type {
+	case 5, 6, 9:
+		return true
+	}
+	return false
+}
+
+// source is the RECORDING_SOURCES entry that selects the meeting.
+func (m meeting) source() string {
+	if m.isWebinar() {
+		return recordingSourceWebinar
+	}
+	return recordingSourceMeeting
+}
+
+// filterSources keeps the meetings whose kind is listed in RECORDING_SOURCES.
+func filterSources(meetings []meeting, sources []string) []meeting {
+	selected := map[string]bool{}
+	for _, source := range sources {
+		selected[source] = true
+	}
+	var kept []meeting
+	for _, m := range meetings {
+		if selected[m.source()] {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// filterTopics keeps the meetings whose topic matches TOPIC_INCLUDE_PATTERN,
+// if set, and does not match TOPIC_EXCLUDE_PATTERN.
+func filterTopics(cfg *config, meetings []meeting) []meeting {
+	if cfg.topicInclude == nil && cfg.topicExclude == nil {
+		return meetings
+	}
+	var kept []meeting
+	for _, m := range meetings {
+		if cfg.topicInclude != nil && !cfg.topicInclude.MatchString(m.Topic) {
+			continue
+		}
+		if cfg.topicExclude != nil && cfg.topicExclude.MatchString(m.Topic) {
+			continue
+		}
+		kept = append(kept, m)
+	}
+	if skipped := len(meetings) - len(kept); skipped > 0 {
+		log.Printf("Leaving %d meetings on Zoom whose topics are not selected", skipped)
+	}
+	return kept
+}
+
+// exportWebinarReports stores the webinar's Q&A and poll results as qa.json
+// and polls.json in the meeting folder, exactly as returned by Zoom.
+func (run *backupRun) exportWebinarReports(ctx context.Context, mtg meeting) error {
+	folder, err := meetingFolder(run.cfg, mtg)
+	if err != nil {
+		return err
+	}
+	for _, export := range []struct {
+		enabled bool
+		url     string
+		name    string
+	}{
+		{run.cfg.WebinarQAExport, zoomPastWebinarQAPath, "qa.json"},
+		{run.cfg.WebinarPollsExport, zoomPastWebinarPollsPath, "polls.json"},
+	} {
+		if !export.enabled {
+			continue
+		}
+		var report json.RawMessage
+		if err := run.zoom.getJSON(ctx, fmt.Sprintf(export.url, meetingPathID(mtg.ID)), &report); err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", export.name, err)
+		}
+		name := run.cfg.objectName(path.Join(folder, export.name))
+		raw, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := run.writeMeetingFile(ctx, mtg, name, "JSON", run.cfg.contentType("JSON"), raw); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
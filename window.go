@@ -0,0 +1,189 @@
+package zoombackup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// hourWindow is a daily range of wall-clock minutes in TIMEZONE. A window
+// whose end is before its start wraps around midnight.
+type hourWindow struct {
+	start, end int
+}
+
+// parseHourWindows parses ALLOWED_HOURS entries like 22:00-06:00.
+func parseHourWindows(entries []string) ([]hourWindow, error) {
+	var windows []hourWindow
+	for _, entry := range entries {
+		parts := strings.Split(strings.Replace(entry, "–", "-", 1), "-")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid ALLOWED_HOURS entry %q, expected HH:MM-HH:MM", entry)
+		}
+		start, err := parseClock(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ALLOWED_HOURS entry %q: %w", entry, err)
+		}
+		end, err := parseClock(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ALLOWED_HOURS entry %q: %w", entry, err)
+		}
+		windows = append(windows, hourWindow{start: start, end: end})
+	}
+	return windows, nil
+}
+
+func parseClock(s string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+func (w hourWindow) contains(minute int) bool {
+	if w.start <= w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// withinAllowedHours reports whether t falls into any of ALLOWED_HOURS, which
+// is always the case when none are configured.
+func (cfg *config) withinAllowedHours(t time.Time) bool {
+	if len(cfg.allowedHours) == 0 {
+		return true
+	}
+	t = t.In(cfg.location)
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range cfg.allowedHours {
+		if w.contains(minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkpoint remembers the meetings a run left unfinished when ALLOWED_HOURS
+// ended, with the objects that were already archived, so the next run in the
+// window picks up where it stopped.
+type checkpoint struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	// Meetings maps meeting UUIDs to the names of their archived objects.
+	Meetings map[string][]string `json:"meetings"`
+}
+
+// windowState tracks the ALLOWED_HOURS of one run.
+type windowState struct {
+	mu sync.Mutex
+	// resume is the checkpoint left by the previous run. It is not modified.
+	resume   *checkpoint
+	deferred map[string][]string
+	closed   bool
+}
+
+func loadCheckpoint(ctx context.Context, storageClient *storage.Client, cfg *config) (*checkpoint, error) {
+	cp := &checkpoint{Meetings: map[string][]string{}}
+	r, err := storageClient.Bucket(cfg.Bucket).Object(cfg.objectName(cfg.CheckpointObject)).NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	defer r.Close()
+
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(raw, cp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	}
+	if cp.Meetings == nil {
+		cp.Meetings = map[string][]string{}
+	}
+	return cp, nil
+}
+
+// windowClosed reports whether ALLOWED_HOURS have ended, logging it once.
+// Once closed it stays closed for the rest of the run.
+func (run *backupRun) windowClosed() bool {
+	w := run.window
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed && !run.cfg.withinAllowedHours(time.Now()) {
+		log.Println("ALLOWED_HOURS ended, not starting any more transfers this run")
+		w.closed = true
+	}
+	return w.closed
+}
+
+// archivedBefore reports whether the previous run left object archived for
+// the meeting.
+func (run *backupRun) archivedBefore(meetingID, object string) bool {
+	for _, name := range run.window.resume.Meetings[meetingID] {
+		if name == object {
+			return true
+		}
+	}
+	return false
+}
+
+// deferMeeting records that the meeting was stopped by the end of
+// ALLOWED_HOURS after the given files were archived.
+func (run *backupRun) deferMeeting(mtg meeting, files []archivedFile) {
+	w := run.window
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var names []string
+	for _, file := range files {
+		names = append(names, file.attrs.Name)
+	}
+	w.deferred[mtg.ID] = names
+	run.report.meetingDeferred(mtg)
+}
+
+func (run *backupRun) isDeferred(meetingID string) bool {
+	w := run.window
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.deferred[meetingID]
+	return ok
+}
+
+// saveCheckpoint stores the meetings deferred by this run for the next one
+// and removes the checkpoint once nothing is left over.
+func (run *backupRun) saveCheckpoint(ctx context.Context) error {
+	w := run.window
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	obj := run.storageClient.Bucket(run.cfg.Bucket).Object(run.cfg.objectName(run.cfg.CheckpointObject))
+	if len(w.deferred) == 0 {
+		if len(w.resume.Meetings) == 0 {
+			return nil
+		}
+		if err := obj.Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+			return err
+		}
+		return nil
+	}
+	return writeJSONObject(ctx, obj, checkpoint{UpdatedAt: time.Now().UTC(), Meetings: w.deferred})
+}
@@ -0,0 +1,478 @@
+package zoombackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Errors of the Zoom API callers can check for with errors.Is to retry or
+// skip instead of failing the run.
+var (
+	errZoomRateLimited  = errors.New("rate limited by Zoom")
+	errZoomNotFound     = errors.New("not found on Zoom")
+	errZoomUnauthorized = errors.New("not authorized by Zoom")
+)
+
+// zoomError is an unexpected response of the Zoom API.
+type zoomError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is how long the response asked to wait before trying
+	// again, 0 without a Retry-After header.
+	RetryAfter time.Duration
+}
+
+func (e *zoomError) Error() string {
+	return fmt.Sprintf("invalid response code: %d -- %s", e.StatusCode, e.Body)
+}
+
+// Is matches the response to errZoomRateLimited, errZoomNotFound or
+// errZoomUnauthorized by its status code.
+func (e *zoomError) Is(target error) bool {
+	switch target {
+	case errZoomRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case errZoomNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case errZoomUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+const (
+	// zoomRateLimitRetries is how often an API request rate limited by Zoom
+	// is sent again.
+	zoomRateLimitRetries = 3
+	// zoomRateLimitBackoff is multiplied by the attempt when a rate limited
+	// response does not say how long to wait.
+	zoomRateLimitBackoff = 2 * time.Second
+	// maxRetryAfter caps the wait Zoom can ask for.
+	maxRetryAfter = 5 * time.Minute
+)
+
+// retryAfter returns the wait the Retry-After header of a response asks for,
+// in seconds or as a date, and fallback without one.
+func retryAfter(header http.Header, fallback time.Duration) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return fallback
+	}
+	wait := fallback
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		wait = time.Until(at)
+	}
+	if wait < 0 {
+		return 0
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
+
+// downloadRetryDelay returns how long to wait before the attempt-th retry of
+// a download that failed with err, and false when retrying cannot help
+// because Zoom refused the token or no longer has the file. Rate limited
+// downloads wait at least as long as Zoom asked.
+func downloadRetryDelay(err error, attempt int) (time.Duration, bool) {
+	if errors.Is(err, errZoomUnauthorized) || errors.Is(err, errZoomNotFound) {
+		return 0, false
+	}
+	delay := time.Duration(attempt) * downloadRetryBackoff
+	var zerr *zoomError
+	if errors.As(err, &zerr) && zerr.RetryAfter > delay {
+		delay = zerr.RetryAfter
+	}
+	return delay, true
+}
+
+// defaultZoomAPIURL is the base URL of the Zoom API, which ZOOM_API_URL
+// replaces to run against a fake or recorded Zoom.
+const defaultZoomAPIURL = "https://api.zoom.us/v2"
+
+// DOWNLOAD_AUTH passes the token of downloads in the Authorization header or
+// as the access_token query parameter.
+const (
+	downloadAuthHeader = "header"
+	downloadAuthQuery  = "query"
+
+	maxDownloadRedirects = 10
+)
+
+// zoomClient calls the Zoom API with the access token of a job.
+type zoomClient struct {
+	token string
+	// downloadAuth is how downloads pass the token, DOWNLOAD_AUTH.
+	downloadAuth string
+	// baseURL is prepended to the paths of API requests.
+	baseURL    string
+	httpClient *http.Client
+	// api bounds the API requests in flight to API_CONCURRENCY, shared by
+	// everything a run asks Zoom. Clients without it are not limited.
+	api *semaphore.Weighted
+}
+
+func newZoomClient(ctx context.Context, cfg *config) (*zoomClient, error) {
+	return newZoomClientWith(ctx, cfg, cfg.client())
+}
+
+// newZoomClientWith is newZoomClient sending every request, the one for the
+// OAuth token included, through httpClient, e.g. that of an httptest server
+// standing in for ZOOM_API_URL and ZOOM_OAUTH_URL.
+func newZoomClientWith(ctx context.Context, cfg *config, httpClient *http.Client) (*zoomClient, error) {
+	token, err := zoomToken(ctx, cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return &zoomClient{token: token, downloadAuth: cfg.DownloadAuth, baseURL: cfg.ZoomAPIURL, httpClient: httpClient}, nil
+}
+
+// do performs an authenticated request for the API path and returns the
+// response body, or a *zoomError along with the body when the request did
+// not succeed.
+func (c *zoomClient) do(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return buf.Bytes(), &zoomError{StatusCode: resp.StatusCode, Body: buf.String(), RetryAfter: retryAfter(resp.Header, 0)}
+	}
+	return buf.Bytes(), nil
+}
+
+// stream performs an authenticated GET of the API path and returns the
+// response body unread, so large responses can be decoded as they arrive.
+// The caller closes it, which also gives back the request's API slot.
+// Requests that did not succeed return a *zoomError.
+func (c *zoomClient) stream(ctx context.Context, path string) (io.ReadCloser, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(ctx, "GET", path, nil)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(resp.Body)
+		_ = resp.Body.Close()
+		release()
+		return nil, &zoomError{StatusCode: resp.StatusCode, Body: buf.String(), RetryAfter: retryAfter(resp.Header, 0)}
+	}
+	return &cancelOnClose{ReadCloser: resp.Body, cancel: release}, nil
+}
+
+// acquire takes one of the client's API slots, if it is limited, and returns
+// the function giving it back, which does so only once.
+func (c *zoomClient) acquire(ctx context.Context) (func(), error) {
+	if c.api == nil {
+		return func() {}, nil
+	}
+	if err := c.api.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() { once.Do(func() { c.api.Release(1) }) }, nil
+}
+
+// send performs an authenticated request for the API path with body, if
+// set, as JSON. Requests rate limited by Zoom are sent again after the wait
+// of their Retry-After header, at most zoomRateLimitRetries times.
+func (c *zoomClient) send(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		var payload io.Reader
+		if body != nil {
+			payload = bytes.NewReader(raw)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
+		}
+		req.Header.Add("Accept", "application/json")
+		req.Header.Add("Authorization", "Bearer "+c.token)
+		if body != nil {
+			req.Header.Add("Content-Type", "application/json")
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to perform request: %w", err)
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= zoomRateLimitRetries {
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+		wait := retryAfter(resp.Header, time.Duration(attempt+1)*zoomRateLimitBackoff)
+		log.Println("Rate limited by Zoom, retrying", method, path, "in", wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// getJSON performs a GET of the API path and decodes the JSON response body
+// into v.
+func (c *zoomClient) getJSON(ctx context.Context, path string, v interface{}) error {
+	body, err := c.do(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// patchJSON sends body as JSON in a PATCH of the API path.
+func (c *zoomClient) patchJSON(ctx context.Context, path string, body interface{}) error {
+	_, err := c.do(ctx, "PATCH", path, body)
+	return err
+}
+
+// zoomListWindowDays is the longest date range Zoom lists recordings for in
+// one request.
+const zoomListWindowDays = 30
+
+// listRecordingsSince lists the user's recordings of the last days.
+func (c *zoomClient) listRecordingsSince(ctx context.Context, zoomUserID string, days int, raw func(from time.Time, page int, body []byte)) ([]meeting, error) {
+	now := time.Now()
+	return c.listRecordingsBetween(ctx, zoomUserID, false, now.AddDate(0, 0, -days), now, raw)
+}
+
+// listRecordingsBetween lists the user's recordings between the two times,
+// or the ones in the user's trash, one window Zoom accepts at a time. Every
+// response body is handed to raw, if set, along with the start of its
+// window and its page; without raw the responses are decoded as they arrive
+// instead of being held in memory.
+func (c *zoomClient) listRecordingsBetween(ctx context.Context, zoomUserID string, trash bool, oldest, newest time.Time, raw func(from time.Time, page int, body []byte)) ([]meeting, error) {
+	var meetings []meeting
+	// Zoom's date ranges are whole days, so adjacent windows overlap.
+	seen := map[string]bool{}
+	for to := newest; to.After(oldest); to = to.AddDate(0, 0, -zoomListWindowDays) {
+		from := to.AddDate(0, 0, -zoomListWindowDays)
+		if from.Before(oldest) {
+			from = oldest
+		}
+		var pageRaw func(page int, body []byte)
+		if raw != nil {
+			pageRaw = func(page int, body []byte) { raw(from, page, body) }
+		}
+		windowMeetings, err := c.listRecordings(ctx, zoomUserID, trash, from, to, pageRaw)
+		if err != nil {
+			return nil, err
+		}
+		for _, mtg := range windowMeetings {
+			if !seen[mtg.ID] {
+				seen[mtg.ID] = true
+				meetings = append(meetings, mtg)
+			}
+		}
+	}
+	return meetings, nil
+}
+
+// listRecordings lists the user's recordings between the two dates, which
+// Zoom allows to be at most a month apart, from the trash if trash is set,
+// following next_page_token through every page. With raw every page's body
+// is handed to it as well, for DEBUG_RESPONSES; otherwise the pages are
+// decoded as they stream in.
+func (c *zoomClient) listRecordings(ctx context.Context, zoomUserID string, trash bool, from, to time.Time, raw func(page int, body []byte)) ([]meeting, error) {
+	listPath := zoomRecordingsPath
+	if trash {
+		listPath = zoomTrashRecordingsPath
+	}
+	listPath = fmt.Sprintf(listPath, zoomUserID, from.Format(ymdFormat), to.Format(ymdFormat))
+	var meetings []meeting
+	nextPageToken := ""
+	for page := 0; ; page++ {
+		reqPath := listPath
+		if nextPageToken != "" {
+			reqPath += "&next_page_token=" + url.QueryEscape(nextPageToken)
+		}
+		pageMeetings, token, err := c.listRecordingsPage(ctx, reqPath, page, raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list recordings: %w", err)
+		}
+		meetings = append(meetings, pageMeetings...)
+		if token == "" {
+			return meetings, nil
+		}
+		nextPageToken = token
+	}
+}
+
+// listRecordingsPage fetches one page of a recordings list and returns its
+// meetings and the token of the next page.
+func (c *zoomClient) listRecordingsPage(ctx context.Context, reqPath string, page int, raw func(page int, body []byte)) ([]meeting, string, error) {
+	if raw == nil {
+		body, err := c.stream(ctx, reqPath)
+		if err != nil {
+			return nil, "", err
+		}
+		defer body.Close()
+		return parseRecordingList(body)
+	}
+	body, err := c.do(ctx, "GET", reqPath, nil)
+	if body != nil {
+		raw(page, body)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return parseRecordingList(bytes.NewReader(body))
+}
+
+// getMeetingRecordings fetches the recordings of one meeting by its UUID. The
+// raw response body is returned as well, for DEBUG_RESPONSES.
+func (c *zoomClient) getMeetingRecordings(ctx context.Context, meetingID string) (meeting, []byte, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf(zoomMeetingRecordingsPath, meetingPathID(meetingID)), nil)
+	if err != nil {
+		return meeting{}, body, fmt.Errorf("failed to get recordings: %w", err)
+	}
+	// The meeting has the shape of an entry of the recordings list.
+	list := append(append([]byte(`{"meetings":[`), body...), "]}"...)
+	meetings, _, err := parseRecordingList(bytes.NewReader(list))
+	if err != nil {
+		return meeting{}, body, err
+	}
+	mtg := meetings[0]
+	mtg.UserID = mtg.HostID
+	return mtg, body, nil
+}
+
+// downloadFile starts downloading a recording file and returns its body
+// along with its Content-Length, which is -1 when unknown. The whole
+// download, including reading the body, has to finish within timeout.
+func (c *zoomClient) downloadFile(ctx context.Context, fileURL string, timeout time.Duration) (io.ReadCloser, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	resp, err := c.downloadRequest(ctx, fileURL, "")
+	if err != nil {
+		cancel()
+		return nil, 0, err
+	}
+	return &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}, resp.ContentLength, nil
+}
+
+// downloadRequest requests a recording file, only the bytes of byteRange
+// unless it is empty. The token is sent in the Authorization header, or with
+// DOWNLOAD_AUTH query as the access_token parameter. Zoom answers with a
+// redirect to a signed URL, which is followed without the header: the
+// signature authorizes the download and storage behind it rejects requests
+// that carry both.
+func (c *zoomClient) downloadRequest(ctx context.Context, fileURL, byteRange string) (*http.Response, error) {
+	recURL, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download URL: %w", err)
+	}
+	if c.downloadAuth == downloadAuthQuery {
+		query := recURL.Query()
+		query.Set("access_token", c.token)
+		recURL.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", recURL.String(), nil)
+	if err != nil {
+		err = fmt.Errorf("failed to create new HTTP request for recording download: %w", err)
+		return nil, err
+	}
+	if c.downloadAuth != downloadAuthQuery {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Add("Accept", "application/json")
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	// The per file timeout replaces the client's, which is too short for
+	// large recordings.
+	client := *c.httpClient
+	client.Timeout = 0
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxDownloadRedirects {
+			return fmt.Errorf("stopped after %d redirects", len(via))
+		}
+		req.Header.Del("Authorization")
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error names the URL, which must not reveal the token.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		err = fmt.Errorf("failed to perform request to download recording: %w", err)
+		return nil, err
+	}
+
+	if resp.StatusCode/200 != 1 {
+		_ = resp.Body.Close()
+		err = fmt.Errorf("failed to download recording: %w", &zoomError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header, 0)})
+		return nil, err
+	}
+	return resp, nil
+}
+
+// cancelOnClose releases the download's context, or the API slot of a
+// streamed response, along with its body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// deleteRecordings deletes all recordings of the meeting.
+func (c *zoomClient) deleteRecordings(ctx context.Context, meetingID string) error {
+	if _, err := c.do(ctx, "DELETE", fmt.Sprintf(zoomMeetingRecordingsPath, meetingPathID(meetingID)), nil); err != nil {
+		return fmt.Errorf("failed to delete recordings: %w", err)
+	}
+	return nil
+}
+
+// deleteRecordingFile deletes one recording file of the meeting.
+func (c *zoomClient) deleteRecordingFile(ctx context.Context, meetingID, recordingID string) error {
+	if _, err := c.do(ctx, "DELETE", fmt.Sprintf(zoomDeleteRecordingFilePath, meetingPathID(meetingID), url.PathEscape(recordingID)), nil); err != nil {
+		return fmt.Errorf("failed to delete recording file: %w", err)
+	}
+	return nil
+}
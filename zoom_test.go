@@ -0,0 +1,405 @@
+package zoombackup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/gobuffalo/envy"
+	"golang.org/x/sync/semaphore"
+)
+
+// newTestZoom starts a fake Zoom serving both the OAuth token and the API
+// routes of api, and returns a client authenticated against it.
+func newTestZoom(t *testing.T, api *http.ServeMux) (*zoomClient, *httptest.Server) {
+	t.Helper()
+	srv := newTestZoomServer(t, api)
+	cfg := &config{
+		// The account is unique per test so tokens are not shared through
+		// the process wide cache.
+		ZoomAccountID:    t.Name(),
+		ZoomClientID:     "client",
+		ZoomClientSecret: "secret",
+		ZoomAPIURL:       srv.URL + "/v2",
+		ZoomOAuthURL:     srv.URL + "/oauth/token",
+		DownloadAuth:     downloadAuthHeader,
+	}
+	c, err := newZoomClientWith(context.Background(), cfg, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, srv
+}
+
+// newTestBackup starts a fake Zoom serving api and a fake Cloud Storage, and
+// returns a job archiving the recordings of user "me" from one into the
+// bucket "archive" of the other, with the defaults of every other setting.
+func newTestBackup(t *testing.T, api *http.ServeMux) (*config, *storage.Client, *fakeGCS) {
+	t.Helper()
+	srv := newTestZoomServer(t, api)
+	cfg := loadTestConfig(t, map[string]string{
+		"ZOOM_ACCOUNT_ID":    t.Name(),
+		"ZOOM_CLIENT_ID":     "client",
+		"ZOOM_CLIENT_SECRET": "secret",
+		"ZOOM_API_URL":       srv.URL + "/v2",
+		"ZOOM_OAUTH_URL":     srv.URL + "/oauth/token",
+		"ZOOM_USER_ID":       "me",
+		"GSTORAGE_BUCKET":    "archive",
+	})
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.httpClient = srv.Client()
+	storageClient, gcs := newTestStorage(t)
+	return cfg, storageClient, gcs
+}
+
+// loadTestConfig loads the configuration from env on top of the process
+// environment, which it leaves untouched.
+func loadTestConfig(t *testing.T, env map[string]string) *config {
+	t.Helper()
+	var cfg *config
+	var err error
+	envy.Temp(func() {
+		for key, value := range env {
+			envy.Set(key, value)
+		}
+		cfg, err = loadConfig()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// newTestZoomServer starts a fake Zoom serving both the OAuth token and the
+// API routes of api.
+func newTestZoomServer(t *testing.T, api *http.ServeMux) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if r.Method != http.MethodPost || !ok || id != "client" || secret != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if got := r.URL.Query().Get("account_id"); got != t.Name() {
+			http.Error(w, "unknown account "+got, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"token","expires_in":3600}`)
+	})
+	mux.Handle("/v2/", http.StripPrefix("/v2", api))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func requireToken(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func TestListRecordingsFollowsPages(t *testing.T) {
+	api := http.NewServeMux()
+	var tokens []string
+	api.HandleFunc("/users/me/recordings", func(w http.ResponseWriter, r *http.Request) {
+		if !requireToken(w, r) {
+			return
+		}
+		token := r.URL.Query().Get("next_page_token")
+		tokens = append(tokens, token)
+		switch token {
+		case "":
+			fmt.Fprint(w, `{"page_size":1,"next_page_token":"page 2","meetings":[
+				{"uuid":"a","topic":"First","recording_files":[{"id":"a1","file_type":"MP4","status":"completed"}]}]}`)
+		case "page 2":
+			fmt.Fprint(w, `{"page_size":1,"meetings":[
+				{"uuid":"b","topic":"Second","recording_files":[{"id":"b1","file_type":"MP4","status":"completed"},{"id":"b2","file_type":"MP4","status":"processing"}]}],"next_page_token":""}`)
+		default:
+			http.Error(w, "unknown page", http.StatusBadRequest)
+		}
+	})
+	c, _ := newTestZoom(t, api)
+
+	var pages []int
+	meetings, err := c.listRecordingsSince(context.Background(), "me", 10, func(from time.Time, page int, body []byte) {
+		pages = append(pages, page)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meetings) != 2 || meetings[0].ID != "a" || meetings[1].ID != "b" {
+		t.Fatalf("got meetings %+v, want a and b", meetings)
+	}
+	if len(meetings[1].Files) != 1 || !meetings[1].Processing {
+		t.Errorf("got files %+v and processing %t for b, want b1 and processing", meetings[1].Files, meetings[1].Processing)
+	}
+	if fmt.Sprint(tokens) != "[ page 2]" {
+		t.Errorf("got page tokens %q", tokens)
+	}
+	if fmt.Sprint(pages) != "[0 1]" {
+		t.Errorf("got raw pages %v, want [0 1]", pages)
+	}
+
+	// Without raw the pages are streamed.
+	meetings, err = c.listRecordingsSince(context.Background(), "me", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meetings) != 2 {
+		t.Fatalf("got %d streamed meetings, want 2", len(meetings))
+	}
+}
+
+func TestDownloadFileDropsAuthorizationOnRedirect(t *testing.T) {
+	api := http.NewServeMux()
+	var redirected bool
+	api.HandleFunc("/rec/download/a1", func(w http.ResponseWriter, r *http.Request) {
+		if !requireToken(w, r) {
+			return
+		}
+		http.Redirect(w, r, "/v2/storage/a1?signature=s", http.StatusFound)
+	})
+	api.HandleFunc("/storage/a1", func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			http.Error(w, "signed URLs reject "+auth, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "recording")
+	})
+	c, srv := newTestZoom(t, api)
+
+	body, _, err := c.downloadFile(context.Background(), srv.URL+"/v2/rec/download/a1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	raw, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !redirected || string(raw) != "recording" {
+		t.Errorf("got %q after redirect %t, want the recording", raw, redirected)
+	}
+}
+
+func TestDownloadFileFailsOnError(t *testing.T) {
+	api := http.NewServeMux()
+	api.HandleFunc("/rec/download/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	c, srv := newTestZoom(t, api)
+
+	_, _, err := c.downloadFile(context.Background(), srv.URL+"/v2/rec/download/gone", time.Minute)
+	if !errors.Is(err, errZoomNotFound) {
+		t.Errorf("got %v, want a not found error", err)
+	}
+}
+
+func TestDeleteRecordings(t *testing.T) {
+	api := http.NewServeMux()
+	var deleted []string
+	api.HandleFunc("/meetings/", func(w http.ResponseWriter, r *http.Request) {
+		if !requireToken(w, r) {
+			return
+		}
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		deleted = append(deleted, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusNoContent)
+	})
+	c, _ := newTestZoom(t, api)
+
+	if err := c.deleteRecordings(context.Background(), "/ab//c=="); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.deleteRecordingFile(context.Background(), "plain", "file 1"); err != nil {
+		t.Fatal(err)
+	}
+	want := "[/meetings/%252Fab%252F%252Fc==/recordings /meetings/plain/recordings/file%201]"
+	if fmt.Sprint(deleted) != want {
+		t.Errorf("got deletes %v, want %s", deleted, want)
+	}
+}
+
+func TestDeleteModeFilesDeletesOnlyArchivedFiles(t *testing.T) {
+	api := http.NewServeMux()
+	start := time.Now().AddDate(0, 0, -3).UTC().Format(time.RFC3339)
+	api.HandleFunc("/users/me/recordings", func(w http.ResponseWriter, r *http.Request) {
+		if !requireToken(w, r) {
+			return
+		}
+		file := func(id, fileType, status string) string {
+			return fmt.Sprintf(`{"id":%q,"file_type":%q,"status":%q,"recording_start":%q,"recording_type":"shared_screen","file_size":9,"download_url":"http://%s/v2/rec/download/%s"}`, id, fileType, status, start, r.Host, id)
+		}
+		fmt.Fprintf(w, `{"meetings":[
+			{"uuid":"archived","topic":"Archived","start_time":%q,"recording_files":[%s,%s,%s,%s,%s]},
+			{"uuid":"failed","topic":"Failed","start_time":%q,"recording_files":[%s,%s]}]}`,
+			start, file("a1", "MP4", "completed"), file("gone", "MP4", "completed"), file("t1", "TRANSCRIPT", "completed"), file("c1", "CHAT", "completed"), file("p1", "MP4", "processing"),
+			start, file("f1", "MP4", "completed"), file("f2", "MP4", "completed"))
+	})
+	api.HandleFunc("/rec/download/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rec/download/f1" {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "recording")
+	})
+	var deleted []string
+	api.HandleFunc("/meetings/", func(w http.ResponseWriter, r *http.Request) {
+		if !requireToken(w, r) {
+			return
+		}
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		if r.URL.Path == "/meetings/archived/recordings/gone" {
+			http.Error(w, `{"code":3301,"message":"This recording does not exist."}`, http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	cfg, storageClient, _ := newTestBackup(t, api)
+	cfg.DeleteFromZoom = true
+	cfg.DeleteMode = deleteModeFiles
+	cfg.DeleteAfterDays = 0
+	cfg.DownloadRetries = 0
+
+	report := runBackup(context.Background(), storageClient, cfg, nil)
+	want := "[/meetings/archived/recordings/a1 /meetings/archived/recordings/gone]"
+	if fmt.Sprint(deleted) != want {
+		t.Errorf("got deletes %v, want %s", deleted, want)
+	}
+	if report.MeetingsDeleted != 1 {
+		t.Errorf("got %d meetings deleted, want 1", report.MeetingsDeleted)
+	}
+}
+
+func TestSendRetriesRateLimitedRequests(t *testing.T) {
+	api := http.NewServeMux()
+	calls := 0
+	api.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"code":429,"message":"Too many requests"}`, http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"id":"u1"}`)
+	})
+	c, _ := newTestZoom(t, api)
+
+	var user struct {
+		ID string `json:"id"`
+	}
+	if err := c.getJSON(context.Background(), "/users/me", &user); err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "u1" || calls != 2 {
+		t.Errorf("got user %q after %d calls, want u1 after 2", user.ID, calls)
+	}
+}
+
+func TestDownloadRetryDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantDelay time.Duration
+		wantRetry bool
+	}{
+		{"unauthorized", &zoomError{StatusCode: http.StatusUnauthorized}, 0, false},
+		{"not found", fmt.Errorf("failed to download recording: %w", &zoomError{StatusCode: http.StatusNotFound}), 0, false},
+		{"server error", &zoomError{StatusCode: http.StatusBadGateway}, 2 * downloadRetryBackoff, true},
+		{"rate limited", &zoomError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute}, time.Minute, true},
+		{"rate limited briefly", &zoomError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Second}, 2 * downloadRetryBackoff, true},
+		{"network", errors.New("connection reset"), 2 * downloadRetryBackoff, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := downloadRetryDelay(tt.err, 2)
+			if delay != tt.wantDelay || retry != tt.wantRetry {
+				t.Errorf("got %v and retry %t, want %v and %t", delay, retry, tt.wantDelay, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	if got := retryAfter(header, time.Second); got != time.Second {
+		t.Errorf("got %v without a header, want the fallback", got)
+	}
+	header.Set("Retry-After", "7")
+	if got := retryAfter(header, time.Second); got != 7*time.Second {
+		t.Errorf("got %v for 7 seconds", got)
+	}
+	header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if got := retryAfter(header, time.Second); got != maxRetryAfter {
+		t.Errorf("got %v for an hour, want the cap %v", got, maxRetryAfter)
+	}
+}
+
+func TestAPIConcurrencyCoversEveryRequest(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, most := 0, 0
+	track := func(w http.ResponseWriter, body string) {
+		mu.Lock()
+		inFlight++
+		if inFlight > most {
+			most = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, body)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}
+	api := http.NewServeMux()
+	api.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		track(w, `{"meetings":[]}`)
+	})
+	api.HandleFunc("/past_meetings/", func(w http.ResponseWriter, r *http.Request) {
+		track(w, `{"participants":[]}`)
+	})
+	c, _ := newTestZoom(t, api)
+	c.api = semaphore.NewWeighted(2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := c.listRecordingsSince(context.Background(), fmt.Sprint("u", i), 1, nil); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			var v struct{}
+			if err := c.getJSON(context.Background(), fmt.Sprint("/past_meetings/m", i, "/participants"), &v); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if most > 2 {
+		t.Errorf("got %d requests in flight, want at most 2", most)
+	}
+}